@@ -0,0 +1,37 @@
+package migrate
+
+import (
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// AllSteps makes Apply migrate all the way up or down.
+const AllSteps = -1
+
+// Apply migrates n steps in direction, like Steps, or all the way like Up
+// and Down if n is AllSteps. It's meant for tooling getting the direction
+// as a parameter. n must be positive or AllSteps, 0 returns ErrNoChange.
+func (m *Migrate) Apply(direction source.Direction, n int) error {
+	if n == 0 {
+		return ErrNoChange
+	}
+	if n < 0 && n != AllSteps {
+		return fmt.Errorf("invalid number of steps %v", n)
+	}
+
+	switch direction {
+	case source.Up:
+		if n == AllSteps {
+			return m.Up()
+		}
+		return m.Steps(n)
+	case source.Down:
+		if n == AllSteps {
+			return m.Down()
+		}
+		return m.Steps(-n)
+	default:
+		return fmt.Errorf("invalid direction %q", direction)
+	}
+}
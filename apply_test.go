@@ -0,0 +1,70 @@
+package migrate
+
+import (
+	"errors"
+	"testing"
+
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	"github.com/golang-migrate/migrate/v4/source"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+func TestApply(t *testing.T) {
+	testCases := []struct {
+		name      string
+		from      int
+		direction source.Direction
+		n         int
+		expected  func(m *Migrate) error
+	}{
+		{name: "up steps", from: -1, direction: source.Up, n: 2, expected: func(m *Migrate) error { return m.Steps(2) }},
+		{name: "up all", from: -1, direction: source.Up, n: AllSteps, expected: func(m *Migrate) error { return m.Up() }},
+		{name: "up too many steps", from: 4, direction: source.Up, n: 3, expected: func(m *Migrate) error { return m.Steps(3) }},
+		{name: "down steps", from: 7, direction: source.Down, n: 2, expected: func(m *Migrate) error { return m.Steps(-2) }},
+		{name: "down all", from: 7, direction: source.Down, n: AllSteps, expected: func(m *Migrate) error { return m.Down() }},
+		{name: "down all from nil", from: -1, direction: source.Down, n: AllSteps, expected: func(m *Migrate) error { return m.Down() }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			applied, appliedDrv := newApplyTestMigrate(tc.from)
+			expected, expectedDrv := newApplyTestMigrate(tc.from)
+
+			err := applied.Apply(tc.direction, tc.n)
+			expectedErr := tc.expected(expected)
+			if (err == nil) != (expectedErr == nil) || (err != nil && err.Error() != expectedErr.Error()) {
+				t.Errorf("expected error %v, got %v", expectedErr, err)
+			}
+			if appliedDrv.CurrentVersion != expectedDrv.CurrentVersion {
+				t.Errorf("expected version %v, got %v", expectedDrv.CurrentVersion, appliedDrv.CurrentVersion)
+			}
+			if !appliedDrv.EqualSequence(expectedDrv.MigrationSequence) {
+				t.Errorf("expected sequence %v, got %v", expectedDrv.MigrationSequence, appliedDrv.MigrationSequence)
+			}
+		})
+	}
+}
+
+func TestApplyInvalid(t *testing.T) {
+	m, _ := newApplyTestMigrate(-1)
+
+	if err := m.Apply(source.Up, 0); !errors.Is(err, ErrNoChange) {
+		t.Errorf("expected ErrNoChange, got %v", err)
+	}
+	if err := m.Apply(source.Up, -2); err == nil {
+		t.Error("expected error for negative steps")
+	}
+	if err := m.Apply(source.Direction("sideways"), 1); err == nil {
+		t.Error("expected error for invalid direction")
+	}
+}
+
+// newApplyTestMigrate returns a Migrate at version from with the
+// source stub migrations.
+func newApplyTestMigrate(from int) (*Migrate, *dStub.Stub) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+	dbDrv.CurrentVersion = from
+	return m, dbDrv
+}
@@ -0,0 +1,50 @@
+package migrate
+
+import (
+	"errors"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// ErrAlreadyMigrated is returned by Baseline if the database already
+// has an active migration version.
+var ErrAlreadyMigrated = errors.New("database already has an active migration version")
+
+// Baseline marks the schema of an existing database as being at version,
+// without running any migration, so that subsequent calls to Up only run
+// the migrations following version. version must be available in the
+// source. The version is recorded as the baseline version if the database
+// driver implements database.Baseliner.
+// It returns ErrAlreadyMigrated if a migration version is already active.
+func (m *Migrate) Baseline(version uint) error {
+	if err := m.lock(); err != nil {
+		return err
+	}
+
+	curVersion, _, err := m.versions().GetVersion()
+	if err != nil {
+		return m.unlockErr(err)
+	}
+	if curVersion != database.NilVersion {
+		return m.unlockErr(ErrAlreadyMigrated)
+	}
+
+	if err := m.versionExists(version); err != nil {
+		return m.unlockErr(err)
+	}
+
+	if err := m.versions().SetVersion(int(version), false); err != nil {
+		return m.unlockErr(err)
+	}
+
+	if b, ok := m.databaseDrv.(database.Baseliner); ok {
+		if err := b.SetBaseline(int(version)); err != nil {
+			return m.unlockErr(err)
+		}
+	} else {
+		m.logVerbosePrintf("Database driver doesn't support baselines, not recording baseline version %v\n", version)
+	}
+
+	m.logPrintf("Baseline set to version %v\n", version)
+	return m.unlock()
+}
@@ -0,0 +1,61 @@
+package migrate
+
+import (
+	"testing"
+
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+func TestBaseline(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+
+	if err := m.Baseline(4); err != nil {
+		t.Fatal(err)
+	}
+	if dbDrv.CurrentVersion != 4 || dbDrv.IsDirty {
+		t.Errorf("expected clean version 4, got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+	if dbDrv.BaselineVersion != 4 {
+		t.Errorf("expected baseline version 4, got %v", dbDrv.BaselineVersion)
+	}
+	if len(dbDrv.MigrationSequence) != 0 {
+		t.Errorf("expected no migration to run, got %v", dbDrv.MigrationSequence)
+	}
+
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	equalDbSeq(t, 0, migrationSequence{mr("CREATE 7")}, dbDrv)
+}
+
+func TestBaselineAlreadyMigrated(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+
+	if err := m.Migrate(1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Baseline(4); err != ErrAlreadyMigrated {
+		t.Fatalf("expected ErrAlreadyMigrated, got %v", err)
+	}
+	if dbDrv.CurrentVersion != 1 || dbDrv.BaselineVersion != -1 {
+		t.Errorf("expected version 1 without baseline, got version %v and baseline %v", dbDrv.CurrentVersion, dbDrv.BaselineVersion)
+	}
+	if dbDrv.IsLocked {
+		t.Error("expected database to be unlocked")
+	}
+}
+
+func TestBaselineUnknownVersion(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+
+	if err := m.Baseline(2); err == nil {
+		t.Fatal("expected error for a version not available in the source")
+	}
+}
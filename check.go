@@ -0,0 +1,117 @@
+package migrate
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"unicode"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// CheckResult is the result of checking a pending up migration
+// with CheckPending.
+type CheckResult struct {
+	Version    uint
+	Identifier string
+
+	// ReadErr is the error reading the migration from the source, if any.
+	ReadErr error
+
+	// ParseErr is the error parsing the headers of the migration, if any.
+	ParseErr error
+
+	// Empty is set if the migration contains nothing but white space, which
+	// many databases reject. A migration consisting of a comment isn't empty.
+	Empty bool
+}
+
+// OK reports whether the migration is readable, parses and isn't empty.
+func (r CheckResult) OK() bool {
+	return r.ReadErr == nil && r.ParseErr == nil && !r.Empty
+}
+
+// CheckPending checks that the up migrations of the versions above
+// currentVersion can be read from sourceDrv, parse and aren't empty,
+// without connecting to a database, i.e. as a quick gate in CI.
+// Pass 0 as currentVersion if no migration has been applied yet.
+// Versions without an up migration are skipped, like Up does.
+// The returned error is only set if the versions can't be listed.
+func CheckPending(sourceDrv source.Driver, currentVersion uint) ([]CheckResult, error) {
+	var results []CheckResult
+
+	version, err := sourceDrv.First()
+	if errors.Is(err, os.ErrNotExist) {
+		return results, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	it, err := source.Iterate(sourceDrv, version)
+	if err != nil {
+		return nil, err
+	}
+
+	for ok := true; ok; version, ok = it.Next() {
+		if version <= currentVersion {
+			continue
+		}
+		if result, ok := checkUp(sourceDrv, version); ok {
+			results = append(results, result)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// checkUp checks the up migration of version. ok is false if
+// there's no up migration for version.
+func checkUp(sourceDrv source.Driver, version uint) (result CheckResult, ok bool) {
+	result.Version = version
+
+	r, identifier, err := sourceDrv.ReadUp(version)
+	if errors.Is(err, os.ErrNotExist) {
+		return result, false
+	} else if err != nil {
+		result.ReadErr = err
+		return result, true
+	}
+	result.Identifier = identifier
+	defer func() {
+		if errClose := r.Close(); errClose != nil {
+			result.ReadErr = multierror.Append(result.ReadErr, errClose)
+		}
+	}()
+
+	_, body, err := readHeaders(r)
+	if err != nil {
+		result.ParseErr = err
+		return result, true
+	}
+
+	result.Empty, result.ReadErr = isBlank(body)
+	return result, true
+}
+
+// isBlank reports whether r contains nothing but white space.
+// It stops reading at the first other character.
+func isBlank(r io.Reader) (bool, error) {
+	br := bufio.NewReader(r)
+	for {
+		c, _, err := br.ReadRune()
+		if err == io.EOF {
+			return true, nil
+		} else if err != nil {
+			return false, err
+		}
+		if !unicode.IsSpace(c) {
+			return false, nil
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/source"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+func TestCheckPending(t *testing.T) {
+	sourceDrv, err := (&sStub.Stub{}).Open("stub://")
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrations := source.NewMigrations()
+	migrations.Append(&source.Migration{Version: 1, Direction: source.Up, Identifier: "CREATE 1"})
+	migrations.Append(&source.Migration{Version: 2, Direction: source.Up, Identifier: "CREATE 2"})
+	migrations.Append(&source.Migration{Version: 3, Direction: source.Up, Identifier: " \n\t"})
+	migrations.Append(&source.Migration{Version: 4, Direction: source.Down, Identifier: "DROP 4"})
+	migrations.Append(&source.Migration{Version: 5, Direction: source.Up, Identifier: "-- migrate:timeout soon\nCREATE 5"})
+	migrations.Append(&source.Migration{Version: 6, Direction: source.Up, Identifier: "-- no-op"})
+	sourceDrv.(*sStub.Stub).Migrations = migrations
+
+	results, err := CheckPending(sourceDrv, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		version uint
+		ok      bool
+		empty   bool
+		parse   bool
+	}{
+		{version: 2, ok: true},
+		{version: 3, empty: true},
+		{version: 5, parse: true},
+		{version: 6, ok: true},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("expected %v results, got %+v", len(expected), results)
+	}
+	for i, e := range expected {
+		r := results[i]
+		if r.Version != e.version {
+			t.Errorf("expected version %v, got %v", e.version, r.Version)
+		}
+		if r.OK() != e.ok || r.Empty != e.empty || (r.ParseErr != nil) != e.parse || r.ReadErr != nil {
+			t.Errorf("unexpected result for version %v: %+v", e.version, r)
+		}
+	}
+	if results[0].Identifier != "2.up.stub" {
+		t.Errorf("expected identifier 2.up.stub, got %v", results[0].Identifier)
+	}
+}
+
+func TestCheckPendingNoMigrations(t *testing.T) {
+	sourceDrv, err := (&sStub.Stub{}).Open("stub://")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := CheckPending(sourceDrv, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 0 {
+		t.Errorf("expected no results, got %+v", results)
+	}
+}
@@ -0,0 +1,128 @@
+package migrate
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/multistmt"
+)
+
+// DefaultMaxStatementSize is the max size of a single statement of a
+// migration run statement by statement. See StatementCheckpoints.
+var DefaultMaxStatementSize = 10 * 1 << 20 // 10 MB
+
+// ErrNoCheckpoint is returned by Resume if no statement of the migration
+// to the dirty version has been recorded as completed, i.e. it was a down
+// migration, wasn't run with StatementCheckpoints or failed at its first
+// statement. Such a migration can't be resumed, see Force.
+var ErrNoCheckpoint = errors.New("no statement checkpoint recorded")
+
+// statementDelimiter separates the statements of migrations
+// run statement by statement.
+var statementDelimiter = []byte(";")
+
+// Resume continues the up migration to the dirty version, which has been
+// interrupted by a crash, after the statements recorded as completed with
+// StatementCheckpoints, and marks the version as clean.
+// It returns ErrNoChange if the version isn't dirty, ErrNoCheckpoint if no
+// statement has been recorded, and ErrNotSupported if the database driver
+// doesn't implement database.StatementCheckpointStore.
+func (m *Migrate) Resume() error {
+	store, ok := m.databaseDrv.(database.StatementCheckpointStore)
+	if !ok {
+		return ErrNotSupported
+	}
+
+	if err := m.lock(); err != nil {
+		return err
+	}
+
+	curVersion, dirty, err := m.versions().GetVersion()
+	if err != nil {
+		return m.unlockErr(err)
+	}
+	if !dirty {
+		return m.unlockErr(ErrNoChange)
+	}
+
+	skip, err := store.Checkpoint()
+	if err != nil {
+		return m.unlockErr(err)
+	}
+	if skip == 0 {
+		return m.unlockErr(ErrNoCheckpoint)
+	}
+
+	migr, err := m.newMigration(suint(curVersion), curVersion)
+	if err != nil {
+		return m.unlockErr(err)
+	}
+	go func() {
+		if err := migr.Buffer(); err != nil {
+			m.logErr(err)
+		}
+	}()
+
+	return m.unlockErr(m.resume(store, migr, skip))
+}
+
+// resume runs migr after its first skip statements and marks
+// its version as clean. The version must already be set as dirty.
+func (m *Migrate) resume(store database.StatementCheckpointStore, migr *Migration, skip int) error {
+	h, body, err := m.readBody(migr)
+	if err != nil {
+		return err
+	}
+
+	m.current.start(migr)
+	defer m.current.stop()
+
+	if body != nil {
+		m.logPrintf("Resuming %v after %v statements\n", migr.LogString(), skip)
+		if err := m.runStatements(store, body, skip); err != nil {
+			return err
+		}
+	}
+
+	if err := m.verify(migr, h); err != nil {
+		return err
+	}
+	return m.versions().SetVersion(migr.TargetVersion, false)
+}
+
+// checkpointStore returns the database driver as StatementCheckpointStore
+// if migr should be run statement by statement.
+func (m *Migrate) checkpointStore(migr *Migration) (database.StatementCheckpointStore, bool) {
+	if !m.StatementCheckpoints || migr.TargetVersion < int(migr.Version) {
+		return nil, false
+	}
+	store, ok := m.databaseDrv.(database.StatementCheckpointStore)
+	return store, ok
+}
+
+// runStatements runs the statements of body after the first skip ones
+// and records each completed one as checkpoint.
+func (m *Migrate) runStatements(store database.StatementCheckpointStore, body io.Reader, skip int) error {
+	var n int
+	var runErr error
+	err := multistmt.Parse(body, statementDelimiter, DefaultMaxStatementSize, func(statement []byte) bool {
+		if len(bytes.TrimSpace(statement)) == 0 {
+			return true
+		}
+		n++
+		if n <= skip {
+			return true
+		}
+		if runErr = store.RunStatement(statement); runErr != nil {
+			return false
+		}
+		runErr = store.SetCheckpoint(n)
+		return runErr == nil
+	})
+	if runErr != nil {
+		return runErr
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+package migrate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	"github.com/golang-migrate/migrate/v4/source"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+// crashingStub is a stub database driver failing at a statement.
+type crashingStub struct {
+	*dStub.Stub
+	failOn string
+}
+
+func (s *crashingStub) RunStatement(statement []byte) error {
+	if strings.TrimSpace(string(statement)) == s.failOn {
+		return errors.New("crash")
+	}
+	return s.Stub.RunStatement(statement)
+}
+
+func checkpointMigrations() *source.Migrations {
+	migrations := source.NewMigrations()
+	migrations.Append(&source.Migration{Version: 1, Direction: source.Up, Identifier: "CREATE TABLE t;\nINSERT 1;\nINSERT 2;\nINSERT 3;\n"})
+	migrations.Append(&source.Migration{Version: 1, Direction: source.Down, Identifier: "DROP TABLE t;\nDROP TABLE u;\n"})
+	migrations.Append(&source.Migration{Version: 2, Direction: source.Up, Identifier: "CREATE TABLE u;"})
+	return migrations
+}
+
+func statements(sequence []string) []string {
+	trimmed := make([]string, 0, len(sequence))
+	for _, s := range sequence {
+		trimmed = append(trimmed, strings.TrimSpace(s))
+	}
+	return trimmed
+}
+
+func TestResume(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.StatementCheckpoints = true
+	m.sourceDrv.(*sStub.Stub).Migrations = checkpointMigrations()
+	dbDrv := &crashingStub{Stub: m.databaseDrv.(*dStub.Stub), failOn: "INSERT 2;"}
+	m.databaseDrv = dbDrv
+
+	if err := m.Up(); err == nil {
+		t.Fatal("expected the migration to crash")
+	}
+	if dbDrv.CurrentVersion != 1 || !dbDrv.IsDirty {
+		t.Fatalf("expected dirty version 1, got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+	if dbDrv.CompletedStatements != 2 {
+		t.Fatalf("expected 2 completed statements, got %v", dbDrv.CompletedStatements)
+	}
+
+	// restart
+	dbDrv.failOn = ""
+	if err := m.Up(); !errors.As(err, &ErrDirty{}) {
+		t.Fatalf("expected ErrDirty, got %v", err)
+	}
+	if err := m.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	if dbDrv.CurrentVersion != 1 || dbDrv.IsDirty {
+		t.Fatalf("expected clean version 1, got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"CREATE TABLE t;", "INSERT 1;", "INSERT 2;", "INSERT 3;", "CREATE TABLE u;"}
+	if got := statements(dbDrv.MigrationSequence); strings.Join(got, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected statements %v, got %v", expected, got)
+	}
+
+	// down migrations are run as a whole
+	if err := m.Down(); err != nil {
+		t.Fatal(err)
+	}
+	if got := dbDrv.MigrationSequence[len(dbDrv.MigrationSequence)-1]; got != "DROP TABLE t;\nDROP TABLE u;\n" {
+		t.Errorf("expected the down migration to be run as a whole, got %q", got)
+	}
+}
+
+func TestResumeErrors(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.StatementCheckpoints = true
+	m.sourceDrv.(*sStub.Stub).Migrations = checkpointMigrations()
+	dbDrv := &crashingStub{Stub: m.databaseDrv.(*dStub.Stub), failOn: "CREATE TABLE t;"}
+	m.databaseDrv = dbDrv
+
+	if err := m.Resume(); !errors.Is(err, ErrNoChange) {
+		t.Errorf("expected ErrNoChange, got %v", err)
+	}
+
+	// crash at the first statement
+	if err := m.Up(); err == nil {
+		t.Fatal("expected the migration to crash")
+	}
+	if err := m.Resume(); !errors.Is(err, ErrNoCheckpoint) {
+		t.Errorf("expected ErrNoCheckpoint, got %v", err)
+	}
+
+	m.databaseDrv = nonTxStub{dbDrv}
+	if err := m.Resume(); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported, got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// SetContext makes the following migrations stop once ctx is done.
+// Acquiring the lock and the migration being run are aborted, if the
+// database driver implements database.ContextLocker and
+// database.ContextRunner respectively, leaving the version of an
+// aborted migration dirty. Otherwise the run stops before the next
+// migration. The migrations fail with the error of ctx then. The lock
+// is released even if ctx is done, within LockTimeout.
+// A nil ctx, which is the default, never stops.
+func (m *Migrate) SetContext(ctx context.Context) {
+	m.ctx = ctx
+}
+
+// context returns the context set by SetContext, or context.Background.
+func (m *Migrate) context() context.Context {
+	if m.ctx == nil {
+		return context.Background()
+	}
+	return m.ctx
+}
+
+// lockVersions locks m.versions(), aborting once ctx is done
+// if it implements database.ContextLocker.
+func (m *Migrate) lockVersions(ctx context.Context) error {
+	if cl, ok := m.versions().(database.ContextLocker); ok {
+		return cl.LockContext(ctx)
+	}
+	return m.versions().Lock()
+}
+
+// unlockVersions unlocks m.versions() within LockTimeout,
+// if it implements database.ContextLocker.
+func (m *Migrate) unlockVersions() error {
+	cl, ok := m.versions().(database.ContextLocker)
+	if !ok {
+		return m.versions().Unlock()
+	}
+	// not derived from m.ctx, so the lock is released after cancelling
+	ctx, cancel := context.WithTimeout(context.Background(), m.LockTimeout)
+	defer cancel()
+	return cl.UnlockContext(ctx)
+}
@@ -0,0 +1,68 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+func TestSetContextCancel(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+	dbDrv.RunDelay = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.SetContext(ctx)
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := m.Up()
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("expected Up to return promptly, took %v", d)
+	}
+
+	v, dirty, err := m.Version()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 1 || !dirty {
+		t.Errorf("expected dirty version 1, got %v (dirty: %v)", v, dirty)
+	}
+	if dbDrv.IsLocked {
+		t.Error("expected the lock to be released")
+	}
+}
+
+func TestSetContextDoneBeforeLock(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	m.SetContext(ctx)
+
+	if err := m.Up(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if dbDrv.IsLocked {
+		t.Error("expected the database not to be locked")
+	}
+	if len(dbDrv.MigrationSequence) != 0 {
+		t.Errorf("expected no migrations to run, got %v", dbDrv.MigrationSequence)
+	}
+
+	m.SetContext(context.Background())
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	equalDbSeq(t, 0, migrationSequence{mr("CREATE 1"), mr("CREATE 3"), mr("CREATE 4"), mr("CREATE 7")}, dbDrv)
+}
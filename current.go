@@ -0,0 +1,50 @@
+package migrate
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// currentMigration tracks the migration being run.
+// It's read concurrently with the run, see Current.
+type currentMigration struct {
+	mu        sync.Mutex
+	version   uint
+	direction source.Direction
+	since     time.Time
+	running   bool
+}
+
+// start marks migr as being run.
+func (c *currentMigration) start(migr *Migration) {
+	direction := source.Up
+	if migr.TargetVersion < int(migr.Version) {
+		direction = source.Down
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version, c.direction, c.since, c.running = migr.Version, direction, time.Now(), true
+}
+
+// stop marks that no migration is being run.
+func (c *currentMigration) stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.running = false
+}
+
+// Current returns the version and direction of the migration being run,
+// and since when it has been running, e.g. to report the progress of long
+// migrations. running is false if no migration is being run.
+// It's safe to call Current concurrently with running migrations.
+func (m *Migrate) Current() (version uint, direction source.Direction, since time.Time, running bool) {
+	m.current.mu.Lock()
+	defer m.current.mu.Unlock()
+	if !m.current.running {
+		return 0, "", time.Time{}, false
+	}
+	return m.current.version, m.current.direction, m.current.since, true
+}
@@ -0,0 +1,79 @@
+package migrate
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	"github.com/golang-migrate/migrate/v4/source"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+// blockingStub is a stub database driver running a migration
+// only once it's released.
+type blockingStub struct {
+	*dStub.Stub
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingStub) Run(migration io.Reader) error {
+	s.started <- struct{}{}
+	<-s.release
+	return s.Stub.Run(migration)
+}
+
+func TestCurrent(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := &blockingStub{
+		Stub:    m.databaseDrv.(*dStub.Stub),
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	m.databaseDrv = dbDrv
+
+	if _, _, _, running := m.Current(); running {
+		t.Fatal("expected no migration to be running")
+	}
+
+	before := time.Now()
+	done := make(chan error)
+	go func() {
+		done <- m.Steps(1)
+	}()
+
+	<-dbDrv.started
+	version, direction, since, running := m.Current()
+	if !running || version != 1 || direction != source.Up {
+		t.Errorf("expected version 1 (up) to be running, got %v (%v, running: %v)", version, direction, running)
+	}
+	if since.Before(before) || since.After(time.Now()) {
+		t.Errorf("expected the migration to be running since the call to Steps, got %v", since)
+	}
+	close(dbDrv.release)
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, running := m.Current(); running {
+		t.Error("expected no migration to be running")
+	}
+
+	// down migrations are reported as such
+	dbDrv.release = make(chan struct{})
+	go func() {
+		done <- m.Steps(-1)
+	}()
+
+	<-dbDrv.started
+	if version, direction, _, running := m.Current(); !running || version != 1 || direction != source.Down {
+		t.Errorf("expected version 1 (down) to be running, got %v (%v, running: %v)", version, direction, running)
+	}
+	close(dbDrv.release)
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
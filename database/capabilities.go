@@ -0,0 +1,80 @@
+package database
+
+import (
+	"sort"
+)
+
+// The capabilities returned by Capabilities, named after the optional
+// interfaces a Driver can implement.
+const (
+	CapabilityVerifier                  = "Verifier"
+	CapabilityContextRunner             = "ContextRunner"
+	CapabilityContextLocker             = "ContextLocker"
+	CapabilityPinger                    = "Pinger"
+	CapabilityTransactioner             = "Transactioner"
+	CapabilityEpochVersioner            = "EpochVersioner"
+	CapabilityBaseliner                 = "Baseliner"
+	CapabilitySchemaFingerprinter       = "SchemaFingerprinter"
+	CapabilityStatementCheckpointStore  = "StatementCheckpointStore"
+	CapabilityExplainer                 = "Explainer"
+	CapabilityVersionDropper            = "VersionDropper"
+	CapabilityMigrationFingerprintStore = "MigrationFingerprintStore"
+)
+
+// capabilities lists the optional interfaces checked by Capabilities.
+var capabilities = []struct {
+	name       string
+	implements func(d Driver) bool
+}{
+	{CapabilityVerifier, func(d Driver) bool { _, ok := d.(Verifier); return ok }},
+	{CapabilityContextRunner, func(d Driver) bool { _, ok := d.(ContextRunner); return ok }},
+	{CapabilityContextLocker, func(d Driver) bool { _, ok := d.(ContextLocker); return ok }},
+	{CapabilityPinger, func(d Driver) bool { _, ok := d.(Pinger); return ok }},
+	{CapabilityTransactioner, func(d Driver) bool { _, ok := d.(Transactioner); return ok }},
+	{CapabilityEpochVersioner, func(d Driver) bool { _, ok := d.(EpochVersioner); return ok }},
+	{CapabilityBaseliner, func(d Driver) bool { _, ok := d.(Baseliner); return ok }},
+	{CapabilitySchemaFingerprinter, func(d Driver) bool { _, ok := d.(SchemaFingerprinter); return ok }},
+	{CapabilityStatementCheckpointStore, func(d Driver) bool { _, ok := d.(StatementCheckpointStore); return ok }},
+	{CapabilityExplainer, func(d Driver) bool { _, ok := d.(Explainer); return ok }},
+	{CapabilityVersionDropper, func(d Driver) bool { _, ok := d.(VersionDropper); return ok }},
+	{CapabilityMigrationFingerprintStore, func(d Driver) bool { _, ok := d.(MigrationFingerprintStore); return ok }},
+}
+
+// Capabilities returns the names of the optional interfaces implemented by d,
+// e.g. Pinger or Transactioner, in the order they are declared in this
+// package. Tooling can use it to decide which features to offer.
+func Capabilities(d Driver) []string {
+	names := make([]string, 0, len(capabilities))
+	for _, c := range capabilities {
+		if c.implements(d) {
+			names = append(names, c.name)
+		}
+	}
+	return names
+}
+
+// DriverInfo describes a registered driver.
+type DriverInfo struct {
+	// Name is the URL scheme the driver is registered with.
+	Name string
+
+	// Capabilities are the optional interfaces implemented by the driver.
+	// See Capabilities.
+	Capabilities []string
+}
+
+// Registered describes all registered drivers, sorted by name.
+// The capabilities are those of the registered driver instance,
+// which are usually the same as those of the instances returned by Open.
+func Registered() []DriverInfo {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	infos := make([]DriverInfo, 0, len(drivers))
+	for name, d := range drivers {
+		infos = append(infos, DriverInfo{Name: name, Capabilities: Capabilities(d)})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
@@ -0,0 +1,26 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type pingerDriver struct {
+	mockDriver
+}
+
+func (p *pingerDriver) Ping(ctx context.Context) error {
+	return nil
+}
+
+func TestCapabilities(t *testing.T) {
+	if capabilities := Capabilities(&mockDriver{}); len(capabilities) != 0 {
+		t.Errorf("expected no capabilities, got %v", capabilities)
+	}
+
+	expected := []string{CapabilityPinger}
+	if capabilities := Capabilities(&pingerDriver{}); !reflect.DeepEqual(capabilities, expected) {
+		t.Errorf("expected capabilities %v, got %v", expected, capabilities)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"log"
 	nurl "net/url"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -29,11 +31,56 @@ var (
 
 var DefaultMigrationsTable = "schema_migrations"
 
+// leadingCommentsRegex matches the white space and comments preceding a statement.
+const leadingCommentsRegex = `(?:\s|--[^\n]*\n|//[^\n]*\n|/\*.*?\*/)*`
+
+// The statements rewritten by idempotentDDL. The first submatch is the
+// statement up to the name of the object, the second one the rest.
+var (
+	createDDLRegex   = regexp.MustCompile(`(?is)^(` + leadingCommentsRegex + `CREATE\s+(?:TABLE|COLUMNFAMILY|KEYSPACE|SCHEMA|TYPE)\s+)(.*)$`)
+	dropDDLRegex     = regexp.MustCompile(`(?is)^(` + leadingCommentsRegex + `DROP\s+(?:TABLE|COLUMNFAMILY|KEYSPACE|SCHEMA|TYPE|INDEX|MATERIALIZED\s+VIEW|FUNCTION|AGGREGATE|TRIGGER)\s+)(.*)$`)
+	ifNotExistsRegex = regexp.MustCompile(`(?i)^IF\s+NOT\s+EXISTS\s`)
+	ifExistsRegex    = regexp.MustCompile(`(?i)^IF\s+EXISTS\s`)
+)
+
+// The statements checked by RequireWriteTimestamp.
+var (
+	writeRegex          = regexp.MustCompile(`(?is)^` + leadingCommentsRegex + `(?:INSERT|UPDATE|DELETE|BEGIN\s+(?:UNLOGGED\s+|COUNTER\s+)?BATCH)\b`)
+	usingTimestampRegex = regexp.MustCompile(`(?is)\bUSING\s+(?:TTL\s+\S+\s+AND\s+)?TIMESTAMP\b`)
+	writeTableRegex     = regexp.MustCompile(`(?is)^` + leadingCommentsRegex + `(INSERT\s+INTO|UPDATE|DELETE\b.*?\bFROM|BEGIN)\s+([\w."]+)`)
+)
+
+// createKeyspaceRegex matches the statements checked by CreateKeyspace.
+var createKeyspaceRegex = regexp.MustCompile(`(?is)^` + leadingCommentsRegex + `CREATE\s+(?:KEYSPACE|SCHEMA)\b`)
+
+// selectRegex matches SELECT statements, which are retried by QueryRetries.
+var selectRegex = regexp.MustCompile(`(?is)^` + leadingCommentsRegex + `SELECT\b`)
+
+// The consistency directive of a statement, e.g. "-- consistency: local_quorum",
+// searched for in the comments preceding the statement.
+var (
+	leadingCommentsOnlyRegex  = regexp.MustCompile(`(?s)^` + leadingCommentsRegex)
+	consistencyDirectiveRegex = regexp.MustCompile(`(?im)^\s*--\s*consistency:\s*(\S+)\s*$`)
+)
+
+// DefaultVersionKeyspaceReplication is the replication used to create the
+// version keyspace when it doesn't exist yet.
+var DefaultVersionKeyspaceReplication = "{'class': 'SimpleStrategy', 'replication_factor': 1}"
+
 var (
 	ErrNilConfig     = errors.New("no config")
 	ErrNoKeyspace    = errors.New("no keyspace provided")
 	ErrDatabaseDirty = errors.New("database is dirty")
 	ErrClosedSession = errors.New("session is closed")
+	// ErrNoLocalDC is returned if token-aware routing is requested
+	// without a local datacenter.
+	ErrNoLocalDC = errors.New("x-local-dc is required for token-aware routing")
+	// ErrUnknownDatacenter is returned if the replication of the version
+	// keyspace references a datacenter that isn't live and StrictReplication is set.
+	ErrUnknownDatacenter = errors.New("unknown datacenter in version keyspace replication")
+	// ErrNoSpeculativeDelay is returned if x-speculative-execution-max is
+	// set without x-speculative-execution-delay.
+	ErrNoSpeculativeDelay = errors.New("x-speculative-execution-delay is required for speculative execution")
 )
 
 type Config struct {
@@ -41,12 +88,66 @@ type Config struct {
 	KeyspaceName          string
 	MultiStatementEnabled bool
 	MultiStatementMaxSize int
+	// VersionKeyspace is the keyspace holding the migrations table.
+	// Defaults to KeyspaceName. It is created if it doesn't exist.
+	VersionKeyspace string
+	// VersionKeyspaceReplication is the replication map used when creating
+	// VersionKeyspace. Defaults to DefaultVersionKeyspaceReplication.
+	VersionKeyspaceReplication string
+	// StrictReplication makes opening fail with ErrUnknownDatacenter if
+	// VersionKeyspaceReplication references a datacenter that isn't live,
+	// instead of only logging it.
+	StrictReplication bool
+	// IdempotentDDL rewrites CREATE TABLE, KEYSPACE and TYPE statements to
+	// CREATE ... IF NOT EXISTS and DROP statements to DROP ... IF EXISTS,
+	// so a migration can be run again after it failed halfway.
+	IdempotentDDL bool
+	// RequireWriteTimestamp logs a warning for INSERT, UPDATE, DELETE and
+	// BATCH statements without a USING TIMESTAMP clause, which may conflict
+	// with concurrent writes of the application. USING TIMESTAMP and
+	// USING TTL clauses are always passed to Cassandra untouched.
+	RequireWriteTimestamp bool
+	// QueryRetries is how often a statement failing with a transient
+	// error (unavailable replicas, read or write timeouts, lost connections)
+	// is run again. Only statements having the same effect when run more
+	// than once are retried: SELECT, CREATE ... IF NOT EXISTS and
+	// DROP ... IF EXISTS. Other statements, like ALTER or writes, are never
+	// retried, since a timed out statement may still have been applied.
+	QueryRetries int
+	// CreateKeyspace allows KeyspaceName not to exist yet, so it can be
+	// created by a migration. The session must not use a keyspace then.
+	// The migrations table is created once a CREATE KEYSPACE statement
+	// has created KeyspaceName; until then the version is kept in memory.
+	// Drivers returned by Open reconnect to the keyspace at that point,
+	// so the following statements can use unqualified table names.
+	CreateKeyspace bool
+	// SpeculativeExecution is the speculative execution policy of SELECT
+	// statements, sending them to further nodes if the first one is slow
+	// to respond. Like retries, it only applies to idempotent statements
+	// and never to DDL statements, even with IF NOT EXISTS or IF EXISTS,
+	// nor to writes.
+	SpeculativeExecution gocql.SpeculativeExecutionPolicy
+	// Logger receives the warnings of the driver, e.g. about writes
+	// without USING TIMESTAMP or unknown datacenters referenced by
+	// VersionKeyspaceReplication. Defaults to the standard logger of
+	// package log.
+	Logger database.Logger
 }
 
 type Cassandra struct {
 	session  *gocql.Session
 	isLocked bool
 
+	// cluster is used to reconnect to the keyspace once it has been
+	// created, if CreateKeyspace is set. It's nil for WithInstance.
+	cluster *gocql.ClusterConfig
+	// awaitKeyspace is set while the keyspace doesn't exist yet.
+	// See CreateKeyspace.
+	awaitKeyspace bool
+	// pending is the version set while the keyspace holding the
+	// migrations table doesn't exist yet.
+	pending *pendingVersion
+
 	// Open and WithInstance need to guarantee that config is never nil
 	config *Config
 }
@@ -70,11 +171,32 @@ func WithInstance(session *gocql.Session, config *Config) (database.Driver, erro
 		config.MultiStatementMaxSize = DefaultMultiStatementMaxSize
 	}
 
+	if len(config.VersionKeyspace) == 0 {
+		config.VersionKeyspace = config.KeyspaceName
+	}
+
+	if len(config.VersionKeyspaceReplication) == 0 {
+		config.VersionKeyspaceReplication = DefaultVersionKeyspaceReplication
+	}
+
 	c := &Cassandra{
 		session: session,
 		config:  config,
 	}
 
+	if config.CreateKeyspace {
+		exists, err := c.keyspaceExists()
+		if err != nil {
+			return nil, err
+		}
+		c.awaitKeyspace = !exists
+		if c.awaitKeyspace && config.VersionKeyspace == config.KeyspaceName {
+			// the migrations table is created with the keyspace
+			c.pending = &pendingVersion{version: database.NilVersion}
+			return c, nil
+		}
+	}
+
 	if err := c.ensureVersionTable(); err != nil {
 		return nil, err
 	}
@@ -93,8 +215,14 @@ func (c *Cassandra) Open(url string) (database.Driver, error) {
 		return nil, ErrNoKeyspace
 	}
 
+	createKeyspace := u.Query().Get("x-create-keyspace") == "true"
+
 	cluster := gocql.NewCluster(u.Host)
 	cluster.Keyspace = strings.TrimPrefix(u.Path, "/")
+	if createKeyspace {
+		// the keyspace may not exist yet, see useKeyspace
+		cluster.Keyspace = ""
+	}
 	cluster.Consistency = gocql.All
 	cluster.Timeout = 1 * time.Minute
 
@@ -155,6 +283,14 @@ func (c *Cassandra) Open(url string) (database.Driver, error) {
 		}
 	}
 
+	if err := setHostSelectionPolicy(cluster, u.Query()); err != nil {
+		return nil, err
+	}
+
+	if err := setReconnectionPolicy(cluster, u.Query()); err != nil {
+		return nil, err
+	}
+
 	session, err := cluster.CreateSession()
 	if err != nil {
 		return nil, err
@@ -168,12 +304,47 @@ func (c *Cassandra) Open(url string) (database.Driver, error) {
 		}
 	}
 
-	return WithInstance(session, &Config{
-		KeyspaceName:          strings.TrimPrefix(u.Path, "/"),
-		MigrationsTable:       u.Query().Get("x-migrations-table"),
-		MultiStatementEnabled: u.Query().Get("x-multi-statement") == "true",
-		MultiStatementMaxSize: multiStatementMaxSize,
+	queryRetries := 0
+	if s := u.Query().Get("x-query-retries"); len(s) > 0 {
+		queryRetries, err = strconv.Atoi(s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	speculativeExecution, err := speculativeExecutionPolicy(u.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := WithInstance(session, &Config{
+		KeyspaceName:               strings.TrimPrefix(u.Path, "/"),
+		MigrationsTable:            u.Query().Get("x-migrations-table"),
+		MultiStatementEnabled:      u.Query().Get("x-multi-statement") == "true",
+		MultiStatementMaxSize:      multiStatementMaxSize,
+		VersionKeyspace:            u.Query().Get("x-version-keyspace"),
+		VersionKeyspaceReplication: u.Query().Get("x-version-keyspace-replication"),
+		StrictReplication:          u.Query().Get("x-strict-replication") == "true",
+		IdempotentDDL:              u.Query().Get("x-idempotent-ddl") == "true",
+		RequireWriteTimestamp:      u.Query().Get("x-require-write-timestamp") == "true",
+		QueryRetries:               queryRetries,
+		CreateKeyspace:             createKeyspace,
+		SpeculativeExecution:       speculativeExecution,
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	if createKeyspace {
+		c := d.(*Cassandra)
+		c.cluster = cluster
+		if !c.awaitKeyspace {
+			if err := c.useKeyspace(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return d, nil
 }
 
 func (c *Cassandra) Close() error {
@@ -194,6 +365,16 @@ func (c *Cassandra) Unlock() error {
 	return nil
 }
 
+// logPrintf logs a warning to the Logger of the config, or to the standard
+// logger if it isn't set.
+func (c *Cassandra) logPrintf(format string, v ...interface{}) {
+	if c.config.Logger != nil {
+		c.config.Logger.Printf(format, v...)
+		return
+	}
+	log.Printf(format, v...)
+}
+
 func (c *Cassandra) Run(migration io.Reader) error {
 	if c.config.MultiStatementEnabled {
 		var err error
@@ -202,10 +383,25 @@ func (c *Cassandra) Run(migration io.Reader) error {
 			if tq == "" {
 				return true
 			}
-			if e := c.session.Query(tq).Exec(); e != nil {
+			if c.config.IdempotentDDL {
+				tq = idempotentDDL(tq)
+			}
+			if c.config.RequireWriteTimestamp && missingWriteTimestamp(tq) {
+				c.logPrintf("cassandra: %s without USING TIMESTAMP", describeWrite(tq))
+			}
+			q, e := c.statementQuery(tq)
+			if e != nil {
+				err = database.Error{OrigErr: e, Err: "migration failed", Query: m}
+				return false
+			}
+			if e := q.Exec(); e != nil {
 				err = database.Error{OrigErr: e, Err: "migration failed", Query: m}
 				return false
 			}
+			if e := c.checkKeyspaceCreated(tq); e != nil {
+				err = e
+				return false
+			}
 			return true
 		}); e != nil {
 			return e
@@ -217,16 +413,32 @@ func (c *Cassandra) Run(migration io.Reader) error {
 	if err != nil {
 		return err
 	}
+	query := string(migr)
+	if c.config.IdempotentDDL {
+		query = idempotentDDL(query)
+	}
+	if c.config.RequireWriteTimestamp && missingWriteTimestamp(query) {
+		c.logPrintf("cassandra: %s without USING TIMESTAMP", describeWrite(query))
+	}
+	q, err := c.statementQuery(query)
+	if err != nil {
+		return database.Error{OrigErr: err, Err: "migration failed", Query: migr}
+	}
 	// run migration
-	if err := c.session.Query(string(migr)).Exec(); err != nil {
+	if err := q.Exec(); err != nil {
 		// TODO: cast to Cassandra error and get line number
 		return database.Error{OrigErr: err, Err: "migration failed", Query: migr}
 	}
-	return nil
+	return c.checkKeyspaceCreated(query)
 }
 
 func (c *Cassandra) SetVersion(version int, dirty bool) error {
-	query := `TRUNCATE "` + c.config.MigrationsTable + `"`
+	if c.pending != nil {
+		c.pending = &pendingVersion{version: version, dirty: dirty}
+		return nil
+	}
+
+	query := `TRUNCATE ` + c.versionTable()
 	if err := c.session.Query(query).Exec(); err != nil {
 		return &database.Error{OrigErr: err, Query: []byte(query)}
 	}
@@ -235,7 +447,7 @@ func (c *Cassandra) SetVersion(version int, dirty bool) error {
 	// empty schema version for failed down migration on the first migration
 	// See: https://github.com/golang-migrate/migrate/issues/330
 	if version >= 0 || (version == database.NilVersion && dirty) {
-		query = `INSERT INTO "` + c.config.MigrationsTable + `" (version, dirty) VALUES (?, ?)`
+		query = `INSERT INTO ` + c.versionTable() + ` (version, dirty) VALUES (?, ?)`
 		if err := c.session.Query(query, version, dirty).Exec(); err != nil {
 			return &database.Error{OrigErr: err, Query: []byte(query)}
 		}
@@ -246,7 +458,11 @@ func (c *Cassandra) SetVersion(version int, dirty bool) error {
 
 // Return current keyspace version
 func (c *Cassandra) Version() (version int, dirty bool, err error) {
-	query := `SELECT version, dirty FROM "` + c.config.MigrationsTable + `" LIMIT 1`
+	if c.pending != nil {
+		return c.pending.version, c.pending.dirty, nil
+	}
+
+	query := `SELECT version, dirty FROM ` + c.versionTable() + ` LIMIT 1`
 	err = c.session.Query(query).Scan(&version, &dirty)
 	switch {
 	case err == gocql.ErrNotFound:
@@ -275,6 +491,15 @@ func (c *Cassandra) Drop() error {
 		}
 	}
 
+	// the migrations table in a separate keyspace isn't dropped above,
+	// so reset the version explicitly
+	if c.config.VersionKeyspace != c.config.KeyspaceName {
+		query = `TRUNCATE ` + c.versionTable()
+		if err := c.session.Query(query).Exec(); err != nil {
+			return &database.Error{OrigErr: err, Query: []byte(query)}
+		}
+	}
+
 	return nil
 }
 
@@ -296,7 +521,25 @@ func (c *Cassandra) ensureVersionTable() (err error) {
 		}
 	}()
 
-	err = c.session.Query(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (version bigint, dirty boolean, PRIMARY KEY(version))", c.config.MigrationsTable)).Exec()
+	return c.createVersionTable()
+}
+
+// createVersionTable creates the migrations table, and the version keyspace
+// if it's separate, if they don't exist yet.
+func (c *Cassandra) createVersionTable() (err error) {
+	if c.config.VersionKeyspace != c.config.KeyspaceName {
+		if err = c.validateReplication(); err != nil {
+			return err
+		}
+		query := versionKeyspaceQuery(c.config.VersionKeyspace, c.config.VersionKeyspaceReplication)
+		if err = c.session.Query(query).Exec(); err != nil {
+			return &database.Error{OrigErr: err, Query: []byte(query)}
+		}
+	}
+
+	// The version must never expire, so explicitly disable the default TTL
+	// in case it is set by a table template.
+	err = c.session.Query(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (version bigint, dirty boolean, PRIMARY KEY(version)) WITH default_time_to_live = 0", c.versionTable())).Exec()
 	if err != nil {
 		return err
 	}
@@ -306,6 +549,383 @@ func (c *Cassandra) ensureVersionTable() (err error) {
 	return nil
 }
 
+// pendingVersion is a version kept in memory until
+// the migrations table can be created.
+type pendingVersion struct {
+	version int
+	dirty   bool
+}
+
+// keyspaceExists reports whether KeyspaceName exists.
+func (c *Cassandra) keyspaceExists() (bool, error) {
+	query := `SELECT keyspace_name FROM system_schema.keyspaces WHERE keyspace_name = ?`
+	var name string
+	err := c.session.Query(query, c.config.KeyspaceName).Scan(&name)
+	if err == gocql.ErrNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+	return true, nil
+}
+
+// checkKeyspaceCreated checks if statement created KeyspaceName while
+// waiting for it, see CreateKeyspace. If so, it reconnects to the keyspace
+// and creates the migrations table with the pending version.
+func (c *Cassandra) checkKeyspaceCreated(statement string) error {
+	if !c.awaitKeyspace || !createKeyspaceRegex.MatchString(statement) {
+		return nil
+	}
+	exists, err := c.keyspaceExists()
+	if err != nil || !exists {
+		return err
+	}
+	c.awaitKeyspace = false
+
+	if c.cluster != nil {
+		if err := c.useKeyspace(); err != nil {
+			return err
+		}
+	}
+
+	if c.pending == nil {
+		return nil
+	}
+	pending := c.pending
+	c.pending = nil
+	if err := c.createVersionTable(); err != nil {
+		return err
+	}
+	return c.SetVersion(pending.version, pending.dirty)
+}
+
+// useKeyspace replaces the session with one using KeyspaceName.
+func (c *Cassandra) useKeyspace() error {
+	c.cluster.Keyspace = c.config.KeyspaceName
+	session, err := c.cluster.CreateSession()
+	if err != nil {
+		return err
+	}
+	c.session.Close()
+	c.session = session
+	return nil
+}
+
+// versionKeyspaceQuery returns the statement creating the version keyspace.
+// Durable writes are enabled explicitly, since the version must never be lost.
+func versionKeyspaceQuery(keyspace, replication string) string {
+	return fmt.Sprintf(`CREATE KEYSPACE IF NOT EXISTS "%s" WITH REPLICATION = %s AND DURABLE_WRITES = true`, keyspace, replication)
+}
+
+// validateReplication checks that the datacenters referenced by a
+// NetworkTopologyStrategy replication of the version keyspace are live.
+// Unknown datacenters are logged to Logger, or returned as an error if
+// StrictReplication is set.
+func (c *Cassandra) validateReplication() error {
+	dcs, err := replicationDatacenters(c.config.VersionKeyspaceReplication)
+	if err != nil || len(dcs) == 0 {
+		return err
+	}
+
+	live, err := c.liveDatacenters()
+	if err != nil {
+		return err
+	}
+
+	if missing := missingDatacenters(dcs, live); len(missing) > 0 {
+		err := fmt.Errorf("%w: %s (live: %s)", ErrUnknownDatacenter, strings.Join(missing, ", "), strings.Join(live, ", "))
+		if c.config.StrictReplication {
+			return err
+		}
+		c.logPrintf("cassandra: version keyspace %s: %v", c.config.VersionKeyspace, err)
+	}
+	return nil
+}
+
+// liveDatacenters returns the datacenters of the node the session is
+// connected to and of its peers.
+func (c *Cassandra) liveDatacenters() ([]string, error) {
+	var dcs []string
+	for _, query := range []string{`SELECT data_center FROM system.local`, `SELECT data_center FROM system.peers`} {
+		iter := c.session.Query(query).Iter()
+		var dc string
+		for iter.Scan(&dc) {
+			dcs = append(dcs, dc)
+		}
+		if err := iter.Close(); err != nil {
+			return nil, &database.Error{OrigErr: err, Query: []byte(query)}
+		}
+	}
+	return dcs, nil
+}
+
+// replicationDatacenters returns the datacenters referenced by a
+// NetworkTopologyStrategy replication map, i.e.
+// `{'class': 'NetworkTopologyStrategy', 'dc1': 3, 'dc2': 2}`.
+// It returns no datacenters for other replication strategies.
+func replicationDatacenters(replication string) ([]string, error) {
+	r := strings.TrimSpace(replication)
+	if !strings.HasPrefix(r, "{") || !strings.HasSuffix(r, "}") {
+		return nil, fmt.Errorf("invalid replication map: %s", replication)
+	}
+
+	var class string
+	var dcs []string
+	for _, entry := range strings.Split(r[1:len(r)-1], ",") {
+		kv := strings.SplitN(entry, ":", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid replication map: %s", replication)
+		}
+		key, value := strings.Trim(strings.TrimSpace(kv[0]), `'"`), strings.Trim(strings.TrimSpace(kv[1]), `'"`)
+		switch key {
+		case "class":
+			class = value
+		case "replication_factor":
+		default:
+			dcs = append(dcs, key)
+		}
+	}
+
+	if !strings.HasSuffix(class, "NetworkTopologyStrategy") {
+		return nil, nil
+	}
+	return dcs, nil
+}
+
+// missingDatacenters returns the datacenters of dcs not present in live.
+func missingDatacenters(dcs, live []string) []string {
+	var missing []string
+	for _, dc := range dcs {
+		found := false
+		for _, l := range live {
+			if dc == l {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, dc)
+		}
+	}
+	return missing
+}
+
+// setHostSelectionPolicy routes the queries of cluster to the datacenter
+// given by x-local-dc, preferring the replicas of the partition if
+// x-token-aware is set. Without x-local-dc the default routing is kept.
+func setHostSelectionPolicy(cluster *gocql.ClusterConfig, query nurl.Values) error {
+	localDC := query.Get("x-local-dc")
+	tokenAware := query.Get("x-token-aware") == "true"
+	if tokenAware && len(localDC) == 0 {
+		return ErrNoLocalDC
+	}
+	if len(localDC) == 0 {
+		return nil
+	}
+
+	policy := gocql.DCAwareRoundRobinPolicy(localDC)
+	if tokenAware {
+		policy = gocql.TokenAwareHostPolicy(policy)
+	}
+	cluster.PoolConfig.HostSelectionPolicy = policy
+	return nil
+}
+
+// setReconnectionPolicy sets the interval and the number of attempts
+// used to reconnect to a node after losing the connection to it, given by
+// x-reconnect-interval and x-reconnect-retries. gocql's defaults are kept
+// for unset values.
+func setReconnectionPolicy(cluster *gocql.ClusterConfig, query nurl.Values) error {
+	interval := query.Get("x-reconnect-interval")
+	retries := query.Get("x-reconnect-retries")
+	if len(interval) == 0 && len(retries) == 0 {
+		return nil
+	}
+
+	policy := &gocql.ConstantReconnectionPolicy{MaxRetries: 3, Interval: 1 * time.Second}
+	if p, ok := cluster.ReconnectionPolicy.(*gocql.ConstantReconnectionPolicy); ok {
+		*policy = *p
+	}
+	if len(interval) > 0 {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			return err
+		}
+		policy.Interval = d
+	}
+	if len(retries) > 0 {
+		n, err := strconv.Atoi(retries)
+		if err != nil {
+			return err
+		}
+		policy.MaxRetries = n
+	}
+	cluster.ReconnectionPolicy = policy
+	return nil
+}
+
+// speculativeExecutionPolicy returns the speculative execution policy
+// given by x-speculative-execution-delay, the time to wait for a node to
+// respond before sending the statement to the next one, and
+// x-speculative-execution-max, the number of additional executions, which
+// defaults to 1. It returns nil if no delay is set.
+func speculativeExecutionPolicy(query nurl.Values) (gocql.SpeculativeExecutionPolicy, error) {
+	delay := query.Get("x-speculative-execution-delay")
+	max := query.Get("x-speculative-execution-max")
+	if len(delay) == 0 {
+		if len(max) > 0 {
+			return nil, ErrNoSpeculativeDelay
+		}
+		return nil, nil
+	}
+
+	policy := &gocql.SimpleSpeculativeExecution{NumAttempts: 1}
+	d, err := time.ParseDuration(delay)
+	if err != nil {
+		return nil, err
+	}
+	policy.TimeoutDelay = d
+	if len(max) > 0 {
+		if policy.NumAttempts, err = strconv.Atoi(max); err != nil {
+			return nil, err
+		}
+	}
+	return policy, nil
+}
+
+// query returns the query running statement as part of a migration,
+// retrying it on transient errors if QueryRetries is set and the
+// statement is idempotent, and executing it speculatively if
+// SpeculativeExecution is set and the statement is a SELECT.
+func (c *Cassandra) query(statement string) *gocql.Query {
+	q := c.session.Query(statement)
+	if c.config.QueryRetries > 0 && isIdempotent(statement) {
+		q = q.RetryPolicy(&transientRetryPolicy{numRetries: c.config.QueryRetries})
+	}
+	if c.config.SpeculativeExecution != nil && isSpeculative(statement) {
+		q = q.SetSpeculativeExecutionPolicy(c.config.SpeculativeExecution).Idempotent(true)
+	}
+	return q
+}
+
+// statementQuery returns the query running a statement of a migration. The
+// consistency of the session is overridden for just this query by a
+// consistency directive preceding the statement.
+func (c *Cassandra) statementQuery(statement string) (*gocql.Query, error) {
+	consistency, ok, err := consistencyDirective(statement)
+	if err != nil {
+		return nil, err
+	}
+	q := c.query(statement)
+	if ok {
+		q = q.Consistency(consistency)
+	}
+	return q, nil
+}
+
+// consistencyDirective returns the consistency set by a
+// "-- consistency: <level>" comment preceding statement, if any.
+func consistencyDirective(statement string) (consistency gocql.Consistency, ok bool, err error) {
+	comments := leadingCommentsOnlyRegex.FindString(statement)
+	m := consistencyDirectiveRegex.FindStringSubmatch(comments)
+	if m == nil {
+		return 0, false, nil
+	}
+	if consistency, err = parseConsistency(m[1]); err != nil {
+		return 0, false, err
+	}
+	return consistency, true, nil
+}
+
+// isIdempotent reports whether running statement more than once has the
+// same effect as running it once. It is deliberately conservative: writes
+// aren't considered idempotent, since list appends and counter updates
+// aren't, and neither are lightweight transactions.
+func isIdempotent(statement string) bool {
+	if selectRegex.MatchString(statement) {
+		return true
+	}
+	if m := createDDLRegex.FindStringSubmatch(statement); m != nil {
+		return ifNotExistsRegex.MatchString(m[2])
+	}
+	if m := dropDDLRegex.FindStringSubmatch(statement); m != nil {
+		return ifExistsRegex.MatchString(m[2])
+	}
+	return false
+}
+
+// isSpeculative reports whether statement may be executed speculatively,
+// i.e. sent to more than one node. Only reads qualify: writes may not be
+// idempotent, and concurrent DDL statements, even idempotent ones, may
+// cause schema disagreements between the nodes.
+func isSpeculative(statement string) bool {
+	return selectRegex.MatchString(statement)
+}
+
+// transientRetryPolicy retries a query up to numRetries times if it
+// failed with an error that is likely to go away, like a node being
+// briefly unavailable. Other errors are returned right away.
+type transientRetryPolicy struct {
+	numRetries int
+}
+
+func (p *transientRetryPolicy) Attempt(q gocql.RetryableQuery) bool {
+	return q.Attempts() <= p.numRetries
+}
+
+func (p *transientRetryPolicy) GetRetryType(err error) gocql.RetryType {
+	switch err.(type) {
+	case *gocql.RequestErrUnavailable:
+		return gocql.RetryNextHost
+	case *gocql.RequestErrReadTimeout, *gocql.RequestErrWriteTimeout:
+		return gocql.Retry
+	}
+	if err == gocql.ErrTimeoutNoResponse || err == gocql.ErrConnectionClosed {
+		return gocql.RetryNextHost
+	}
+	return gocql.Rethrow
+}
+
+// idempotentDDL adds IF NOT EXISTS to CREATE TABLE, KEYSPACE and TYPE
+// statements and IF EXISTS to DROP statements, unless already present.
+// Other statements are returned untouched.
+func idempotentDDL(statement string) string {
+	if m := createDDLRegex.FindStringSubmatch(statement); m != nil && !ifNotExistsRegex.MatchString(m[2]) {
+		return m[1] + "IF NOT EXISTS " + m[2]
+	}
+	if m := dropDDLRegex.FindStringSubmatch(statement); m != nil && !ifExistsRegex.MatchString(m[2]) {
+		return m[1] + "IF EXISTS " + m[2]
+	}
+	return statement
+}
+
+// missingWriteTimestamp reports whether statement writes data without
+// a USING TIMESTAMP clause.
+func missingWriteTimestamp(statement string) bool {
+	return writeRegex.MatchString(statement) && !usingTimestampRegex.MatchString(statement)
+}
+
+// describeWrite describes a write statement matched by writeRegex by its
+// kind and table, e.g. "INSERT on users", leaving out its values, which
+// may be sensitive.
+func describeWrite(statement string) string {
+	m := writeTableRegex.FindStringSubmatch(statement)
+	if m == nil {
+		return "write"
+	}
+	switch kind := strings.ToUpper(strings.Fields(m[1])[0]); kind {
+	case "BEGIN":
+		return "BATCH"
+	default:
+		return kind + " on " + m[2]
+	}
+}
+
+// versionTable returns the quoted, keyspace qualified name of the migrations table.
+func (c *Cassandra) versionTable() string {
+	return fmt.Sprintf(`"%s"."%s"`, c.config.VersionKeyspace, c.config.MigrationsTable)
+}
+
 // ParseConsistency wraps gocql.ParseConsistency
 // to return an error instead of a panicking.
 func parseConsistency(consistencyStr string) (consistency gocql.Consistency, err error) {
@@ -4,8 +4,12 @@ import (
 	"context"
 	"fmt"
 	"github.com/golang-migrate/migrate/v4"
+	nurl "net/url"
+	"reflect"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 import (
@@ -14,6 +18,7 @@ import (
 )
 
 import (
+	"github.com/golang-migrate/migrate/v4/database"
 	dt "github.com/golang-migrate/migrate/v4/database/testing"
 	"github.com/golang-migrate/migrate/v4/dktesting"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
@@ -104,3 +109,621 @@ func TestMigrate(t *testing.T) {
 		dt.TestMigrate(t, m)
 	})
 }
+
+func TestVersionKeyspace(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.Port(9042)
+		if err != nil {
+			t.Fatal("Unable to get mapped port:", err)
+		}
+		addr := fmt.Sprintf("cassandra://%v:%v/testks?x-version-keyspace=testks_versions", ip, port)
+		p := &Cassandra{}
+		d, err := p.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		session := d.(*Cassandra).session
+		var count int
+		query := `SELECT COUNT(*) FROM system_schema.tables WHERE keyspace_name = ? AND table_name = ?`
+		if err := session.Query(query, "testks_versions", DefaultMigrationsTable).Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Fatalf("expected version table in keyspace testks_versions, got %v tables", count)
+		}
+
+		var ttl int
+		query = `SELECT default_time_to_live FROM system_schema.tables WHERE keyspace_name = ? AND table_name = ?`
+		if err := session.Query(query, "testks_versions", DefaultMigrationsTable).Scan(&ttl); err != nil {
+			t.Fatal(err)
+		}
+		if ttl != 0 {
+			t.Errorf("expected no default TTL on the version table, got %v", ttl)
+		}
+
+		if err := d.SetVersion(3, false); err != nil {
+			t.Fatal(err)
+		}
+		if v, dirty, err := d.Version(); err != nil {
+			t.Fatal(err)
+		} else if v != 3 || dirty {
+			t.Errorf("expected clean version 3, got %v (dirty: %v)", v, dirty)
+		}
+	})
+}
+
+func TestVersionKeyspaceQuery(t *testing.T) {
+	expected := `CREATE KEYSPACE IF NOT EXISTS "versions" WITH REPLICATION = {'class': 'SimpleStrategy', 'replication_factor': 1} AND DURABLE_WRITES = true`
+	if query := versionKeyspaceQuery("versions", DefaultVersionKeyspaceReplication); query != expected {
+		t.Errorf("expected %q, got %q", expected, query)
+	}
+}
+
+func TestReplicationDatacenters(t *testing.T) {
+	testCases := []struct {
+		name        string
+		replication string
+		dcs         []string
+		err         bool
+	}{
+		{name: "simple strategy", replication: DefaultVersionKeyspaceReplication},
+		{name: "network topology strategy", replication: "{'class': 'NetworkTopologyStrategy', 'dc1': 3, 'dc2': 2}", dcs: []string{"dc1", "dc2"}},
+		{name: "qualified class", replication: "{'class' : 'org.apache.cassandra.locator.NetworkTopologyStrategy', 'dc1' : '3'}", dcs: []string{"dc1"}},
+		{name: "not a map", replication: "NetworkTopologyStrategy", err: true},
+		{name: "invalid entry", replication: "{'class': 'NetworkTopologyStrategy', 'dc1'}", err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dcs, err := replicationDatacenters(tc.replication)
+			if (err != nil) != tc.err {
+				t.Fatalf("expected error: %v, got %v", tc.err, err)
+			}
+			if fmt.Sprint(dcs) != fmt.Sprint(tc.dcs) {
+				t.Errorf("expected datacenters %v, got %v", tc.dcs, dcs)
+			}
+		})
+	}
+}
+
+func TestMissingDatacenters(t *testing.T) {
+	peers := []string{"dc1", "dc1", "dc2"}
+
+	if missing := missingDatacenters([]string{"dc1", "dc2"}, peers); len(missing) != 0 {
+		t.Errorf("expected no missing datacenters, got %v", missing)
+	}
+	if missing := missingDatacenters([]string{"dc1", "dc3"}, peers); fmt.Sprint(missing) != "[dc3]" {
+		t.Errorf("expected missing datacenter dc3, got %v", missing)
+	}
+}
+
+func TestSetHostSelectionPolicy(t *testing.T) {
+	testCases := []struct {
+		name     string
+		query    string
+		policy   string
+		fallback string
+		err      error
+	}{
+		{name: "default", query: ""},
+		{name: "dc aware", query: "x-local-dc=dc1", policy: "*gocql.dcAwareRR"},
+		{name: "token aware", query: "x-local-dc=dc1&x-token-aware=true", policy: "*gocql.tokenAwareHostPolicy", fallback: "*gocql.dcAwareRR"},
+		{name: "token aware without local dc", query: "x-token-aware=true", err: ErrNoLocalDC},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, err := nurl.ParseQuery(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cluster := gocql.NewCluster("localhost")
+			if err := setHostSelectionPolicy(cluster, query); err != tc.err {
+				t.Fatalf("expected error %v, got %v", tc.err, err)
+			}
+
+			policy := cluster.PoolConfig.HostSelectionPolicy
+			if tc.policy == "" {
+				if policy != nil {
+					t.Errorf("expected default policy, got %T", policy)
+				}
+				return
+			}
+			if typ := fmt.Sprintf("%T", policy); typ != tc.policy {
+				t.Fatalf("expected policy %v, got %v", tc.policy, typ)
+			}
+			if tc.fallback != "" {
+				fallback := reflect.ValueOf(policy).Elem().FieldByName("fallback").Elem().Type().String()
+				if fallback != tc.fallback {
+					t.Errorf("expected fallback policy %v, got %v", tc.fallback, fallback)
+				}
+			}
+		})
+	}
+}
+
+func TestSetReconnectionPolicy(t *testing.T) {
+	testCases := []struct {
+		name       string
+		query      string
+		interval   time.Duration
+		maxRetries int
+		err        bool
+	}{
+		{name: "default", query: "", interval: 1 * time.Second, maxRetries: 3},
+		{name: "interval", query: "x-reconnect-interval=5s", interval: 5 * time.Second, maxRetries: 3},
+		{name: "retries", query: "x-reconnect-retries=10", interval: 1 * time.Second, maxRetries: 10},
+		{name: "invalid interval", query: "x-reconnect-interval=soon", err: true},
+		{name: "invalid retries", query: "x-reconnect-retries=many", err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, err := nurl.ParseQuery(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cluster := gocql.NewCluster("localhost")
+			err = setReconnectionPolicy(cluster, query)
+			if tc.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			policy := cluster.ReconnectionPolicy
+			if interval := policy.GetInterval(1); interval != tc.interval {
+				t.Errorf("expected interval %v, got %v", tc.interval, interval)
+			}
+			if maxRetries := policy.GetMaxRetries(); maxRetries != tc.maxRetries {
+				t.Errorf("expected %v retries, got %v", tc.maxRetries, maxRetries)
+			}
+		})
+	}
+}
+
+func TestSpeculativeExecutionPolicy(t *testing.T) {
+	testCases := []struct {
+		name     string
+		query    string
+		delay    time.Duration
+		attempts int
+		err      bool
+	}{
+		{name: "delay", query: "x-speculative-execution-delay=50ms", delay: 50 * time.Millisecond, attempts: 1},
+		{name: "delay and max", query: "x-speculative-execution-delay=50ms&x-speculative-execution-max=3", delay: 50 * time.Millisecond, attempts: 3},
+		{name: "max without delay", query: "x-speculative-execution-max=3", err: true},
+		{name: "invalid delay", query: "x-speculative-execution-delay=soon", err: true},
+		{name: "invalid max", query: "x-speculative-execution-delay=50ms&x-speculative-execution-max=many", err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, err := nurl.ParseQuery(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			policy, err := speculativeExecutionPolicy(query)
+			if tc.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if delay := policy.Delay(); delay != tc.delay {
+				t.Errorf("expected delay %v, got %v", tc.delay, delay)
+			}
+			if attempts := policy.Attempts(); attempts != tc.attempts {
+				t.Errorf("expected %v attempts, got %v", tc.attempts, attempts)
+			}
+		})
+	}
+
+	if policy, err := speculativeExecutionPolicy(nurl.Values{}); err != nil || policy != nil {
+		t.Errorf("expected no policy, got %v (%v)", policy, err)
+	}
+}
+
+func TestIsSpeculative(t *testing.T) {
+	testCases := []struct {
+		statement   string
+		speculative bool
+	}{
+		{statement: "SELECT * FROM users", speculative: true},
+		{statement: "-- read\nselect id FROM users", speculative: true},
+		{statement: "CREATE TABLE IF NOT EXISTS users (id int PRIMARY KEY)", speculative: false},
+		{statement: "DROP TABLE IF EXISTS users", speculative: false},
+		{statement: "INSERT INTO users (id) VALUES (1)", speculative: false},
+		{statement: "UPDATE counters SET n = n + 1 WHERE id = 1", speculative: false},
+	}
+
+	for _, tc := range testCases {
+		if speculative := isSpeculative(tc.statement); speculative != tc.speculative {
+			t.Errorf("%q: expected speculative %v, got %v", tc.statement, tc.speculative, speculative)
+		}
+	}
+}
+
+func TestIsIdempotent(t *testing.T) {
+	testCases := []struct {
+		statement string
+		expected  bool
+	}{
+		{statement: "SELECT * FROM users", expected: true},
+		{statement: "CREATE TABLE IF NOT EXISTS users (id int PRIMARY KEY)", expected: true},
+		{statement: "-- cleanup\nDROP TABLE IF EXISTS users", expected: true},
+		{statement: "CREATE TABLE users (id int PRIMARY KEY)", expected: false},
+		{statement: "DROP TABLE users", expected: false},
+		{statement: "ALTER TABLE users ADD name text", expected: false},
+		{statement: "INSERT INTO users (id) VALUES (1) IF NOT EXISTS", expected: false},
+		{statement: "UPDATE users SET tags = tags + ['a'] WHERE id = 1", expected: false},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if idempotent := isIdempotent(tc.statement); idempotent != tc.expected {
+				t.Errorf("expected %v for %q, got %v", tc.expected, tc.statement, idempotent)
+			}
+		})
+	}
+}
+
+// retryableQuery is a gocql.RetryableQuery counting the attempts made.
+type retryableQuery struct {
+	attempts int
+}
+
+func (q *retryableQuery) Attempts() int                      { return q.attempts }
+func (q *retryableQuery) SetConsistency(c gocql.Consistency) {}
+func (q *retryableQuery) GetConsistency() gocql.Consistency  { return gocql.All }
+func (q *retryableQuery) Context() context.Context           { return context.Background() }
+
+func TestTransientRetryPolicy(t *testing.T) {
+	policy := &transientRetryPolicy{numRetries: 2}
+	q := &retryableQuery{}
+
+	// a node becoming unavailable during the first attempt is retried
+	// on the next node, until the retries are used up
+	for q.attempts = 1; q.attempts <= 3; q.attempts++ {
+		retry := policy.Attempt(q)
+		if retry != (q.attempts <= 2) {
+			t.Fatalf("attempt %v: expected retry %v, got %v", q.attempts, q.attempts <= 2, retry)
+		}
+	}
+	if typ := policy.GetRetryType(&gocql.RequestErrUnavailable{}); typ != gocql.RetryNextHost {
+		t.Errorf("expected unavailable to be retried on the next host, got %v", typ)
+	}
+	if typ := policy.GetRetryType(&gocql.RequestErrWriteTimeout{}); typ != gocql.Retry {
+		t.Errorf("expected write timeout to be retried, got %v", typ)
+	}
+	if typ := policy.GetRetryType(gocql.ErrTimeoutNoResponse); typ != gocql.RetryNextHost {
+		t.Errorf("expected missing response to be retried on the next host, got %v", typ)
+	}
+	if typ := policy.GetRetryType(&gocql.RequestErrAlreadyExists{}); typ != gocql.Rethrow {
+		t.Errorf("expected other errors not to be retried, got %v", typ)
+	}
+}
+
+func TestCreateKeyspace(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.Port(9042)
+		if err != nil {
+			t.Fatal("Unable to get mapped port:", err)
+		}
+		addr := fmt.Sprintf("cassandra://%v:%v/bootstrapks?x-create-keyspace=true", ip, port)
+		p := &Cassandra{}
+		d, err := p.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		// run like migrate does: the first migration creates the keyspace,
+		// the second one a table in it
+		migrations := []string{
+			"CREATE KEYSPACE bootstrapks WITH REPLICATION = {'class': 'SimpleStrategy', 'replication_factor': 1}",
+			"CREATE TABLE users (id int PRIMARY KEY)",
+		}
+		for i, migr := range migrations {
+			version := i + 1
+			if err := d.SetVersion(version, true); err != nil {
+				t.Fatal(err)
+			}
+			dt.TestRun(t, d, strings.NewReader(migr))
+			if err := d.SetVersion(version, false); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		version, dirty, err := d.Version()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != 2 || dirty {
+			t.Errorf("expected clean version 2, got %v (dirty: %v)", version, dirty)
+		}
+
+		var table string
+		err = d.(*Cassandra).session.Query(`SELECT table_name FROM system_schema.tables WHERE keyspace_name = 'bootstrapks' AND table_name = 'users'`).Scan(&table)
+		if err != nil {
+			t.Fatalf("expected table users in keyspace bootstrapks: %v", err)
+		}
+	})
+}
+
+func TestPendingVersion(t *testing.T) {
+	c := &Cassandra{
+		config:        &Config{KeyspaceName: "bootstrapks", VersionKeyspace: "bootstrapks", CreateKeyspace: true},
+		awaitKeyspace: true,
+		pending:       &pendingVersion{version: database.NilVersion},
+	}
+
+	if err := c.SetVersion(1, true); err != nil {
+		t.Fatal(err)
+	}
+	version, dirty, err := c.Version()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 1 || !dirty {
+		t.Errorf("expected dirty version 1, got %v (dirty: %v)", version, dirty)
+	}
+
+	// only CREATE KEYSPACE statements are checked
+	if err := c.checkKeyspaceCreated("CREATE TYPE address (street text)"); err != nil {
+		t.Fatal(err)
+	}
+	if !createKeyspaceRegex.MatchString("-- bootstrap\ncreate keyspace IF NOT EXISTS bootstrapks WITH REPLICATION = {}") {
+		t.Error("expected CREATE KEYSPACE to match")
+	}
+}
+
+func TestIdempotentDDL(t *testing.T) {
+	testCases := []struct {
+		statement string
+		expected  string
+	}{
+		{statement: "CREATE TABLE users (id int PRIMARY KEY)", expected: "CREATE TABLE IF NOT EXISTS users (id int PRIMARY KEY)"},
+		{statement: "create  keyspace ks WITH REPLICATION = {'class': 'SimpleStrategy', 'replication_factor': 1};", expected: "create  keyspace IF NOT EXISTS ks WITH REPLICATION = {'class': 'SimpleStrategy', 'replication_factor': 1};"},
+		{statement: "CREATE TYPE address (street text)", expected: "CREATE TYPE IF NOT EXISTS address (street text)"},
+		{statement: "-- users\nCREATE TABLE users (id int PRIMARY KEY)", expected: "-- users\nCREATE TABLE IF NOT EXISTS users (id int PRIMARY KEY)"},
+		{statement: "CREATE TABLE IF NOT EXISTS users (id int PRIMARY KEY)", expected: "CREATE TABLE IF NOT EXISTS users (id int PRIMARY KEY)"},
+		{statement: "DROP TABLE users", expected: "DROP TABLE IF EXISTS users"},
+		{statement: "DROP MATERIALIZED VIEW users_by_email;", expected: "DROP MATERIALIZED VIEW IF EXISTS users_by_email;"},
+		{statement: "drop type if exists address", expected: "drop type if exists address"},
+		{statement: "CREATE INDEX ON users (email)", expected: "CREATE INDEX ON users (email)"},
+		{statement: "INSERT INTO users (id) VALUES (1)", expected: "INSERT INTO users (id) VALUES (1)"},
+		{statement: "ALTER TABLE users ADD email text", expected: "ALTER TABLE users ADD email text"},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if statement := idempotentDDL(tc.statement); statement != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, statement)
+			}
+		})
+	}
+}
+
+func TestIdempotentDDLRerun(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.Port(9042)
+		if err != nil {
+			t.Fatal("Unable to get mapped port:", err)
+		}
+		addr := fmt.Sprintf("cassandra://%v:%v/testks?x-multi-statement=true&x-idempotent-ddl=true", ip, port)
+		p := &Cassandra{}
+		d, err := p.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		migration := "CREATE TYPE address (street text); CREATE TABLE users (id int PRIMARY KEY, address frozen<address>);"
+		for i := 0; i < 2; i++ {
+			if err := d.Run(strings.NewReader(migration)); err != nil {
+				t.Fatalf("run %v: %v", i+1, err)
+			}
+		}
+
+		migration = "DROP TABLE users; DROP TYPE address;"
+		for i := 0; i < 2; i++ {
+			if err := d.Run(strings.NewReader(migration)); err != nil {
+				t.Fatalf("run %v: %v", i+1, err)
+			}
+		}
+	})
+}
+
+func TestMissingWriteTimestamp(t *testing.T) {
+	testCases := []struct {
+		statement string
+		expected  bool
+	}{
+		{statement: "INSERT INTO users (id) VALUES (1)", expected: true},
+		{statement: "INSERT INTO users (id) VALUES (1) USING TIMESTAMP 1600000000000000", expected: false},
+		{statement: "INSERT INTO users (id) VALUES (1) USING TTL 86400 AND TIMESTAMP 1600000000000000", expected: false},
+		{statement: "INSERT INTO users (id) VALUES (1) USING TTL 86400", expected: true},
+		{statement: "update users using timestamp 1600000000000000 SET name = 'a' WHERE id = 1", expected: false},
+		{statement: "-- backfill\nDELETE FROM users WHERE id = 1", expected: true},
+		{statement: "BEGIN UNLOGGED BATCH USING TIMESTAMP 1600000000000000 INSERT INTO users (id) VALUES (1); APPLY BATCH", expected: false},
+		{statement: "CREATE TABLE users (id int PRIMARY KEY)", expected: false},
+		{statement: "SELECT * FROM users", expected: false},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if missing := missingWriteTimestamp(tc.statement); missing != tc.expected {
+				t.Errorf("expected %v for %q, got %v", tc.expected, tc.statement, missing)
+			}
+		})
+	}
+}
+
+func TestDescribeWrite(t *testing.T) {
+	testCases := []struct {
+		statement string
+		expected  string
+	}{
+		{statement: "INSERT INTO users (id, password) VALUES (1, 's3cret')", expected: "INSERT on users"},
+		{statement: "update ks.users SET password = 's3cret' WHERE id = 1", expected: "UPDATE on ks.users"},
+		{statement: "-- cleanup\nDELETE tokens['s3cret'] FROM users WHERE id = 1", expected: "DELETE on users"},
+		{statement: "BEGIN UNLOGGED BATCH INSERT INTO users (id) VALUES (1); APPLY BATCH", expected: "BATCH"},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if description := describeWrite(tc.statement); description != tc.expected {
+				t.Errorf("expected %q for %q, got %q", tc.expected, tc.statement, description)
+			}
+		})
+	}
+}
+
+func TestUsingTimestamp(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.Port(9042)
+		if err != nil {
+			t.Fatal("Unable to get mapped port:", err)
+		}
+		addr := fmt.Sprintf("cassandra://%v:%v/testks?x-multi-statement=true&x-require-write-timestamp=true", ip, port)
+		p := &Cassandra{}
+		d, err := p.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		migration := "CREATE TABLE events (id int PRIMARY KEY, name text);" +
+			"INSERT INTO events (id, name) VALUES (1, 'a') USING TIMESTAMP 1600000000000000;" +
+			"UPDATE events USING TTL 86400 AND TIMESTAMP 1600000000000001 SET name = 'b' WHERE id = 1;"
+		if err := d.Run(strings.NewReader(migration)); err != nil {
+			t.Fatal(err)
+		}
+
+		var writeTime int64
+		query := "SELECT WRITETIME(name) FROM events WHERE id = 1"
+		if err := d.(*Cassandra).session.Query(query).Scan(&writeTime); err != nil {
+			t.Fatal(err)
+		}
+		if writeTime != 1600000000000001 {
+			t.Errorf("expected write time 1600000000000001, got %v", writeTime)
+		}
+	})
+}
+
+func TestConsistencyDirective(t *testing.T) {
+	testCases := []struct {
+		statement   string
+		consistency gocql.Consistency
+		ok          bool
+		err         bool
+	}{
+		{statement: "CREATE TABLE users (id int PRIMARY KEY)"},
+		{statement: "-- consistency: local_quorum\nINSERT INTO users (id) VALUES (1)", consistency: gocql.LocalQuorum, ok: true},
+		{statement: "-- backfill\n--consistency:ONE\nUPDATE users SET name = 'a' WHERE id = 1", consistency: gocql.One, ok: true},
+		{statement: "INSERT INTO users (id, name) VALUES (1, '-- consistency: one')"},
+		{statement: "-- consistency: bogus\nINSERT INTO users (id) VALUES (1)", err: true},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			consistency, ok, err := consistencyDirective(tc.statement)
+			if (err != nil) != tc.err {
+				t.Fatalf("expected error: %v, got %v", tc.err, err)
+			}
+			if consistency != tc.consistency || ok != tc.ok {
+				t.Errorf("expected %v (%v), got %v (%v)", tc.consistency, tc.ok, consistency, ok)
+			}
+		})
+	}
+}
+
+func TestConsistencyOverride(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.Port(9042)
+		if err != nil {
+			t.Fatal("Unable to get mapped port:", err)
+		}
+		addr := fmt.Sprintf("cassandra://%v:%v/testks?x-multi-statement=true", ip, port)
+		p := &Cassandra{}
+		d, err := p.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		ddl := "CREATE TABLE accounts (id int PRIMARY KEY, name text)"
+		dml := "-- consistency: local_quorum\nINSERT INTO accounts (id, name) VALUES (1, 'a')"
+		for _, tc := range []struct {
+			statement   string
+			consistency gocql.Consistency
+		}{
+			{statement: ddl, consistency: gocql.All},
+			{statement: dml, consistency: gocql.LocalQuorum},
+		} {
+			q, err := d.(*Cassandra).statementQuery(tc.statement)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if q.GetConsistency() != tc.consistency {
+				t.Errorf("expected consistency %v for %q, got %v", tc.consistency, tc.statement, q.GetConsistency())
+			}
+		}
+
+		if err := d.Run(strings.NewReader(ddl + ";\n" + dml + ";")); err != nil {
+			t.Fatal(err)
+		}
+		// the override doesn't leak into the session
+		if consistency := d.(*Cassandra).session.Query("SELECT * FROM accounts").GetConsistency(); consistency != gocql.All {
+			t.Errorf("expected session consistency ALL, got %v", consistency)
+		}
+	})
+}
+
+// recordingLogger is a database.Logger recording the logged messages.
+type recordingLogger struct {
+	messages []string
+}
+
+func (l *recordingLogger) Printf(format string, v ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, v...))
+}
+
+func TestLogPrintf(t *testing.T) {
+	logger := &recordingLogger{}
+	c := &Cassandra{config: &Config{Logger: logger}}
+	c.logPrintf("cassandra: version keyspace %s: %v", "migrations", ErrUnknownDatacenter)
+	expected := []string{"cassandra: version keyspace migrations: " + ErrUnknownDatacenter.Error()}
+	if !reflect.DeepEqual(logger.messages, expected) {
+		t.Errorf("expected %q, got %q", expected, logger.messages)
+	}
+}
@@ -0,0 +1,62 @@
+package cockroachdb
+
+import (
+	"context"
+	"database/sql"
+)
+
+import (
+	"github.com/cockroachdb/cockroach-go/crdb"
+	"github.com/lib/pq"
+)
+
+import (
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// baselineTable returns the name of the table recording the baseline
+// version. It's kept apart from the migrations table, whose rows are
+// replaced whenever the version is written.
+func (c *CockroachDb) baselineTable() string {
+	return c.config.MigrationsTable + "_baseline"
+}
+
+// SetBaseline implements database.Baseliner. The table recording the
+// baseline version is created on first use.
+func (c *CockroachDb) SetBaseline(version int) error {
+	query := `CREATE TABLE IF NOT EXISTS "` + c.baselineTable() + `" (version INT NOT NULL)`
+	if _, err := c.db.Exec(query); err != nil {
+		return &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+
+	return crdb.ExecuteTx(context.Background(), c.db, nil, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM "` + c.baselineTable() + `"`); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`INSERT INTO "`+c.baselineTable()+`" (version) VALUES ($1)`, version)
+		return err
+	})
+}
+
+// Baseline implements database.Baseliner.
+func (c *CockroachDb) Baseline() (version int, err error) {
+	query := `SELECT version FROM "` + c.baselineTable() + `" LIMIT 1`
+	err = c.db.QueryRow(query).Scan(&version)
+
+	switch {
+	case err == sql.ErrNoRows:
+		return database.NilVersion, nil
+
+	case err != nil:
+		if e, ok := err.(*pq.Error); ok {
+			// 42P01 is "UndefinedTableError" in CockroachDB
+			if e.Code == "42P01" {
+				return database.NilVersion, nil
+			}
+		}
+		return 0, &database.Error{OrigErr: err, Query: []byte(query)}
+
+	default:
+		return version, nil
+	}
+}
@@ -42,12 +42,26 @@ type Config struct {
 	LockTable       string
 	ForceLock       bool
 	DatabaseName    string
+	// VersionColumnType is the type of the version column of a migrations
+	// table created by the driver. Defaults to database.VersionColumnBigint.
+	// Versions not fitting the column fail with
+	// database.ErrVersionOutOfRange.
+	VersionColumnType database.VersionColumnType
+	// SurvivalGoal is the expected survival goal of a multi-region
+	// database. The migrations and lock tables are created for the survival
+	// goal of the database, see localityClause. If set, creating them fails
+	// with ErrSurvivalGoalMismatch if the database has another one. It's
+	// ignored for single-region databases.
+	SurvivalGoal SurvivalGoal
 }
 
 type CockroachDb struct {
 	db       *sql.DB
 	isLocked bool
 
+	// tx is the transaction started by Begin, if any
+	tx *sql.Tx
+
 	// Open and WithInstance need to guarantee that config is never nil
 	config *Config
 }
@@ -79,6 +93,10 @@ func WithInstance(instance *sql.DB, config *Config) (database.Driver, error) {
 		config.MigrationsTable = DefaultMigrationsTable
 	}
 
+	if len(config.VersionColumnType) == 0 {
+		config.VersionColumnType = database.VersionColumnBigint
+	}
+
 	if len(config.LockTable) == 0 {
 		config.LockTable = DefaultLockTable
 	}
@@ -111,7 +129,7 @@ func (c *CockroachDb) Open(url string) (database.Driver, error) {
 	re := regexp.MustCompile("^(cockroach(db)?|crdb-postgres)")
 	connectString := re.ReplaceAllString(migrate.FilterCustomQuery(purl).String(), "postgres")
 
-	db, err := sql.Open("postgres", connectString)
+	db, err := openDB(connectString, purl.Query().Get("x-use-declarative-schema-changer"))
 	if err != nil {
 		return nil, err
 	}
@@ -132,11 +150,23 @@ func (c *CockroachDb) Open(url string) (database.Driver, error) {
 		forceLock = false
 	}
 
+	versionColumnType, err := database.ParseVersionColumnType(purl.Query().Get("x-version-column-type"))
+	if err != nil {
+		return nil, err
+	}
+
+	survivalGoal, err := parseSurvivalGoal(purl.Query().Get("x-survival-goal"))
+	if err != nil {
+		return nil, err
+	}
+
 	px, err := WithInstance(db, &Config{
-		DatabaseName:    purl.Path,
-		MigrationsTable: migrationsTable,
-		LockTable:       lockTable,
-		ForceLock:       forceLock,
+		DatabaseName:      purl.Path,
+		MigrationsTable:   migrationsTable,
+		LockTable:         lockTable,
+		ForceLock:         forceLock,
+		VersionColumnType: versionColumnType,
+		SurvivalGoal:      survivalGoal,
 	})
 	if err != nil {
 		return nil, err
@@ -227,35 +257,54 @@ func (c *CockroachDb) Run(migration io.Reader) error {
 
 	// run migration
 	query := string(migr[:])
-	if _, err := c.db.Exec(query); err != nil {
+	if _, err := c.querier().Exec(query); err != nil {
 		return database.Error{OrigErr: err, Err: "migration failed", Query: migr}
 	}
 
 	return nil
 }
 
+// SetVersion writes the version row with UPSERT, so that writing the same
+// version concurrently (i.e. by two processes racing past the lock table)
+// doesn't fail with a unique constraint violation on the version column.
+// The version is written in the transaction started by Begin, if any.
 func (c *CockroachDb) SetVersion(version int, dirty bool) error {
+	if err := c.config.VersionColumnType.Check(version); err != nil {
+		return err
+	}
+
+	if c.tx != nil {
+		return c.setVersion(c.tx, version, dirty)
+	}
 	return crdb.ExecuteTx(context.Background(), c.db, nil, func(tx *sql.Tx) error {
-		if _, err := tx.Exec(`DELETE FROM "` + c.config.MigrationsTable + `"`); err != nil {
+		return c.setVersion(tx, version, dirty)
+	})
+}
+
+// setVersion replaces the version in the migrations table within tx.
+func (c *CockroachDb) setVersion(tx *sql.Tx, version int, dirty bool) error {
+	// Also re-write the schema version for nil dirty versions to prevent
+	// empty schema version for failed down migration on the first migration
+	// See: https://github.com/golang-migrate/migrate/issues/330
+	if version >= 0 || (version == database.NilVersion && dirty) {
+		if _, err := tx.Exec(`DELETE FROM "`+c.config.MigrationsTable+`" WHERE version != $1`, version); err != nil {
 			return err
 		}
-
-		// Also re-write the schema version for nil dirty versions to prevent
-		// empty schema version for failed down migration on the first migration
-		// See: https://github.com/golang-migrate/migrate/issues/330
-		if version >= 0 || (version == database.NilVersion && dirty) {
-			if _, err := tx.Exec(`INSERT INTO "`+c.config.MigrationsTable+`" (version, dirty) VALUES ($1, $2)`, version, dirty); err != nil {
-				return err
-			}
+		if _, err := tx.Exec(`UPSERT INTO "`+c.config.MigrationsTable+`" (version, dirty) VALUES ($1, $2)`, version, dirty); err != nil {
+			return err
 		}
-
 		return nil
-	})
+	}
+
+	if _, err := tx.Exec(`DELETE FROM "` + c.config.MigrationsTable + `"`); err != nil {
+		return err
+	}
+	return nil
 }
 
 func (c *CockroachDb) Version() (version int, dirty bool, err error) {
 	query := `SELECT version, dirty FROM "` + c.config.MigrationsTable + `" LIMIT 1`
-	err = c.db.QueryRow(query).Scan(&version, &dirty)
+	err = c.querier().QueryRow(query).Scan(&version, &dirty)
 
 	switch {
 	case err == sql.ErrNoRows:
@@ -346,13 +395,35 @@ func (c *CockroachDb) ensureVersionTable() (err error) {
 	}
 
 	// if not, create the empty migration table
-	query = `CREATE TABLE "` + c.config.MigrationsTable + `" (version INT NOT NULL PRIMARY KEY, dirty BOOL NOT NULL)`
+	goal, err := c.survivalGoal()
+	if err != nil {
+		return err
+	}
+	query = versionTableQuery(c.config.MigrationsTable, c.config.VersionColumnType, goal)
 	if _, err := c.db.Exec(query); err != nil {
 		return &database.Error{OrigErr: err, Query: []byte(query)}
 	}
 	return nil
 }
 
+// versionTableQuery returns the statement creating the migrations table.
+func versionTableQuery(table string, columnType database.VersionColumnType, goal SurvivalGoal) string {
+	return `CREATE TABLE "` + table + `" (version ` + versionColumnSQLType(columnType) + ` NOT NULL PRIMARY KEY, dirty BOOL NOT NULL)` + localityClause(goal)
+}
+
+// versionColumnSQLType returns the CockroachDB type of a version column of
+// type t. INT is a 64-bit integer in CockroachDB.
+func versionColumnSQLType(t database.VersionColumnType) string {
+	switch t {
+	case database.VersionColumnInt:
+		return "INT4"
+	case database.VersionColumnString:
+		return "STRING"
+	default:
+		return "INT8"
+	}
+}
+
 func (c *CockroachDb) ensureLockTable() error {
 	// check if lock table exists
 	var count int
@@ -365,7 +436,11 @@ func (c *CockroachDb) ensureLockTable() error {
 	}
 
 	// if not, create the empty lock table
-	query = `CREATE TABLE "` + c.config.LockTable + `" (lock_id INT NOT NULL PRIMARY KEY)`
+	goal, err := c.survivalGoal()
+	if err != nil {
+		return err
+	}
+	query = `CREATE TABLE "` + c.config.LockTable + `" (lock_id INT NOT NULL PRIMARY KEY)` + localityClause(goal)
 	if _, err := c.db.Exec(query); err != nil {
 		return &database.Error{OrigErr: err, Query: []byte(query)}
 	}
@@ -5,6 +5,7 @@ package cockroachdb
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/golang-migrate/migrate/v4"
 	"log"
@@ -18,9 +19,12 @@ import (
 )
 
 import (
+	"github.com/golang-migrate/migrate/v4/database"
 	dt "github.com/golang-migrate/migrate/v4/database/testing"
 	"github.com/golang-migrate/migrate/v4/dktesting"
+	"github.com/golang-migrate/migrate/v4/source"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
 )
 
 const defaultPort = 26257
@@ -155,6 +159,420 @@ func TestMultiStatement(t *testing.T) {
 	})
 }
 
+func TestTransactioner(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, ci dktest.ContainerInfo) {
+		createDB(t, ci)
+
+		ip, port, err := ci.Port(26257)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := fmt.Sprintf("cockroach://root@%v:%v/migrate?sslmode=disable", ip, port)
+		c := &CockroachDb{}
+		d, err := c.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dt.TestTransactioner(t, d)
+
+		// DDL is rolled back along with the version
+		tx := d.(database.Transactioner)
+		if err := tx.Begin(); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.Run(strings.NewReader("CREATE TABLE foo (foo text)")); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Rollback(); err != nil {
+			t.Fatal(err)
+		}
+		if tableExists(t, d, "foo") {
+			t.Fatalf("expected table foo to be rolled back")
+		}
+	})
+}
+
+func TestUpPhase(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, ci dktest.ContainerInfo) {
+		createDB(t, ci)
+
+		ip, port, err := ci.Port(26257)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := fmt.Sprintf("cockroach://root@%v:%v/migrate?sslmode=disable", ip, port)
+		c := &CockroachDb{}
+		d, err := c.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := newStubMigrate(t, d,
+			"-- migrate:phase expand\nCREATE TABLE foo (foo text)",
+			"-- migrate:phase expand\nCREATE TABLE bar (bar text); SELECT * FROM missing",
+		)
+		if err := m.UpPhase("expand"); err == nil {
+			t.Fatal("expected the phase to fail")
+		}
+		version, dirty, err := m.Version()
+		if !errors.Is(err, migrate.ErrNilVersion) {
+			t.Fatalf("expected no version, got %v (dirty: %v, err: %v)", version, dirty, err)
+		}
+		if tableExists(t, d, "foo") {
+			t.Fatalf("expected table foo of the failed phase to be rolled back")
+		}
+	})
+}
+
+func TestDryRun(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, ci dktest.ContainerInfo) {
+		createDB(t, ci)
+
+		ip, port, err := ci.Port(26257)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := fmt.Sprintf("cockroach://root@%v:%v/migrate?sslmode=disable", ip, port)
+		c := &CockroachDb{}
+		d, err := c.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := newStubMigrate(t, d, "CREATE TABLE foo (foo text)", "CREATE TABLE bar (bar text)")
+		if err := m.DryRun(); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := m.Version(); !errors.Is(err, migrate.ErrNilVersion) {
+			t.Fatalf("expected no version after a dry run, got %v", err)
+		}
+		if tableExists(t, d, "foo") {
+			t.Fatalf("expected table foo of the dry run to be rolled back")
+		}
+
+		m = newStubMigrate(t, d, "CREATE TABLE foo (foo text)", "SELECT * FROM missing")
+		if err := m.DryRun(); err == nil {
+			t.Fatal("expected the dry run to fail with the error of the migration")
+		}
+		if _, _, err := m.Version(); !errors.Is(err, migrate.ErrNilVersion) {
+			t.Fatalf("expected no version after a failed dry run, got %v", err)
+		}
+	})
+}
+
+func TestUpAtomic(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, ci dktest.ContainerInfo) {
+		createDB(t, ci)
+
+		ip, port, err := ci.Port(26257)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := fmt.Sprintf("cockroach://root@%v:%v/migrate?sslmode=disable", ip, port)
+		c := &CockroachDb{}
+		d, err := c.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := newStubMigrate(t, d,
+			"CREATE TABLE foo (foo text)",
+			"CREATE TABLE bar (bar text)",
+			"CREATE TABLE baz (baz text)",
+			"SELECT * FROM missing",
+		)
+		if err := m.Steps(1); err != nil {
+			t.Fatal(err)
+		}
+
+		// the last migration fails, rolling back the ones before it
+		if err := m.UpAtomic(); err == nil {
+			t.Fatal("expected UpAtomic to fail")
+		}
+		version, dirty, err := m.Version()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != 1 || dirty {
+			t.Fatalf("expected clean version 1, got %v (dirty: %v)", version, dirty)
+		}
+		if tableExists(t, d, "bar") || tableExists(t, d, "baz") {
+			t.Fatalf("expected tables bar and baz to be rolled back")
+		}
+		if !tableExists(t, d, "foo") {
+			t.Fatalf("expected table foo applied before UpAtomic to be kept")
+		}
+
+		m = newStubMigrate(t, d,
+			"CREATE TABLE foo (foo text)",
+			"CREATE TABLE bar (bar text)",
+			"CREATE TABLE baz (baz text)",
+		)
+		if err := m.UpAtomic(); err != nil {
+			t.Fatal(err)
+		}
+		version, dirty, err = m.Version()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != 3 || dirty {
+			t.Fatalf("expected clean version 3, got %v (dirty: %v)", version, dirty)
+		}
+		if !tableExists(t, d, "baz") {
+			t.Fatalf("expected table baz to be created")
+		}
+	})
+}
+
+// newStubMigrate returns a Migrate applying the given up migrations as
+// versions 1, 2, ... to d.
+func newStubMigrate(t *testing.T, d database.Driver, migrations ...string) *migrate.Migrate {
+	src, err := sStub.WithInstance(nil, &sStub.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, migration := range migrations {
+		src.(*sStub.Stub).Migrations.Append(&source.Migration{Version: uint(i + 1), Direction: source.Up, Identifier: migration})
+	}
+	m, err := migrate.NewWithInstance("stub", src, "migrate", d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func tableExists(t *testing.T, d database.Driver, table string) bool {
+	var exists bool
+	if err := d.(*CockroachDb).db.QueryRow("SELECT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_name = $1 AND table_schema = (SELECT current_schema()))", table).Scan(&exists); err != nil {
+		t.Fatal(err)
+	}
+	return exists
+}
+
+func TestBaseliner(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, ci dktest.ContainerInfo) {
+		createDB(t, ci)
+
+		ip, port, err := ci.Port(26257)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := fmt.Sprintf("cockroach://root@%v:%v/migrate?sslmode=disable", ip, port)
+		c := &CockroachDb{}
+		d, err := c.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dt.TestBaseliner(t, d)
+	})
+}
+
+func TestExplain(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, ci dktest.ContainerInfo) {
+		createDB(t, ci)
+
+		ip, port, err := ci.Port(26257)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := fmt.Sprintf("cockroach://root@%v:%v/migrate?sslmode=disable", ip, port)
+		c := &CockroachDb{}
+		d, err := c.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := d.Run(strings.NewReader("CREATE TABLE foo (foo text)")); err != nil {
+			t.Fatal(err)
+		}
+
+		plan, err := d.(*CockroachDb).Explain(strings.NewReader("INSERT INTO foo (foo) VALUES ('a;b'); SELECT * FROM foo;"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(plan, "-- INSERT INTO foo (foo) VALUES ('a;b')\n") || !strings.Contains(plan, "-- SELECT * FROM foo\n") {
+			t.Fatalf("expected the plan to name both statements, got %q", plan)
+		}
+		if !strings.Contains(plan, "insert") || !strings.Contains(plan, "scan") {
+			t.Fatalf("expected the plan to contain insert and scan, got %q", plan)
+		}
+
+		// nothing was inserted
+		var count int
+		if err := d.(*CockroachDb).db.QueryRow("SELECT COUNT(*) FROM foo").Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Fatalf("expected no rows, got %v", count)
+		}
+	})
+}
+
+func TestSplitStatements(t *testing.T) {
+	migration := `-- create
+CREATE TABLE foo (foo text);
+INSERT INTO foo VALUES ('a;b'), ("c;d"); -- trailing; comment
+/* block; comment */
+;
+UPDATE foo SET foo = 'x'`
+	expected := []string{
+		"-- create\nCREATE TABLE foo (foo text)",
+		`INSERT INTO foo VALUES ('a;b'), ("c;d")`,
+		"UPDATE foo SET foo = 'x'",
+	}
+	statements := splitStatements(migration)
+	if len(statements) != len(expected) {
+		t.Fatalf("expected %q, got %q", expected, statements)
+	}
+	for i := range expected {
+		if statements[i] != expected[i] {
+			t.Errorf("expected statement %v to be %q, got %q", i, expected[i], statements[i])
+		}
+	}
+}
+
+func TestSetVersionIdempotent(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, ci dktest.ContainerInfo) {
+		createDB(t, ci)
+
+		ip, port, err := ci.Port(26257)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := fmt.Sprintf("cockroach://root@%v:%v/migrate?sslmode=disable", ip, port)
+		c := &CockroachDb{}
+		d, err := c.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := d.SetVersion(2, true); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.SetVersion(2, true); err != nil {
+			t.Fatalf("expected writing the same version twice to succeed, got %v", err)
+		}
+		if err := d.SetVersion(2, false); err != nil {
+			t.Fatal(err)
+		}
+
+		version, dirty, err := d.Version()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != 2 || dirty {
+			t.Fatalf("expected clean version 2, got %v (dirty: %v)", version, dirty)
+		}
+
+		var count int
+		if err := d.(*CockroachDb).db.QueryRow(`SELECT COUNT(*) FROM "` + DefaultMigrationsTable + `"`).Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Fatalf("expected a single version row, got %v", count)
+		}
+	})
+}
+
+func TestVersionColumnType(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, ci dktest.ContainerInfo) {
+		createDB(t, ci)
+
+		ip, port, err := ci.Port(defaultPort)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		const timestamp = 20230101120000
+		for _, columnType := range []string{"", "bigint", "string", "int"} {
+			addr := fmt.Sprintf("cockroach://root@%v:%v/migrate?sslmode=disable&x-migrations-table=versions_%v&x-version-column-type=%v", ip, port, columnType, columnType)
+			c := &CockroachDb{}
+			d, err := c.Open(addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = d.SetVersion(timestamp, false)
+			if columnType == "int" {
+				if !errors.Is(err, database.ErrVersionOutOfRange) {
+					t.Errorf("expected database.ErrVersionOutOfRange for an int column, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			} else if version, dirty, err := d.Version(); err != nil {
+				t.Fatal(err)
+			} else if version != timestamp || dirty {
+				t.Errorf("expected clean version %v for a %q column, got %v (dirty: %v)", timestamp, columnType, version, dirty)
+			}
+
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+}
+
+func TestVersionTableQuery(t *testing.T) {
+	testCases := []struct {
+		goal     SurvivalGoal
+		expected string
+	}{
+		{expected: `CREATE TABLE "schema_migrations" (version INT8 NOT NULL PRIMARY KEY, dirty BOOL NOT NULL)`},
+		{goal: SurvivalGoalZone, expected: `CREATE TABLE "schema_migrations" (version INT8 NOT NULL PRIMARY KEY, dirty BOOL NOT NULL) LOCALITY REGIONAL BY TABLE IN PRIMARY REGION`},
+		{goal: SurvivalGoalRegion, expected: `CREATE TABLE "schema_migrations" (version INT8 NOT NULL PRIMARY KEY, dirty BOOL NOT NULL) LOCALITY GLOBAL`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.goal), func(t *testing.T) {
+			query := versionTableQuery(DefaultMigrationsTable, database.VersionColumnBigint, tc.goal)
+			if query != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, query)
+			}
+		})
+	}
+}
+
+func TestParseSurvivalGoal(t *testing.T) {
+	for _, value := range []string{"", "zone", "region"} {
+		if goal, err := parseSurvivalGoal(value); err != nil || string(goal) != value {
+			t.Errorf("expected survival goal %q, got %q (%v)", value, goal, err)
+		}
+	}
+	if _, err := parseSurvivalGoal("cluster"); err == nil {
+		t.Error("expected error for unknown survival goal")
+	}
+}
+
+func TestSurvivalGoalSingleRegion(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, ci dktest.ContainerInfo) {
+		createDB(t, ci)
+
+		ip, port, err := ci.Port(defaultPort)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the survival goal is ignored for single-region clusters
+		addr := fmt.Sprintf("cockroach://root@%v:%v/migrate?sslmode=disable&x-survival-goal=region", ip, port)
+		c := &CockroachDb{}
+		d, err := c.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+		if goal, err := d.(*CockroachDb).survivalGoal(); err != nil || goal != "" {
+			t.Errorf("expected no survival goal, got %q (%v)", goal, err)
+		}
+	})
+}
+
 func TestFilterCustomQuery(t *testing.T) {
 	dktesting.ParallelTest(t, specs, func(t *testing.T, ci dktest.ContainerInfo) {
 		createDB(t, ci)
@@ -0,0 +1,137 @@
+package cockroachdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// Explain implements database.Explainer. It runs EXPLAIN for each statement
+// of migration and returns the plans, each preceded by its statement as a
+// comment. Statements are explained against the current schema, so
+// statements referencing objects created earlier in the same migration fail.
+func (c *CockroachDb) Explain(migration io.Reader) (string, error) {
+	migr, err := ioutil.ReadAll(migration)
+	if err != nil {
+		return "", err
+	}
+
+	var plan strings.Builder
+	for _, statement := range splitStatements(string(migr)) {
+		fmt.Fprintf(&plan, "-- %s\n", strings.Replace(statement, "\n", "\n-- ", -1))
+		if err := c.explain(&plan, statement); err != nil {
+			return "", database.Error{OrigErr: err, Err: "explain failed", Query: []byte(statement)}
+		}
+	}
+	return plan.String(), nil
+}
+
+// explain writes the rows returned by EXPLAIN for statement to plan,
+// one per line with their columns separated by tabs.
+func (c *CockroachDb) explain(plan *strings.Builder, statement string) (err error) {
+	rows, err := c.db.QueryContext(context.Background(), "EXPLAIN "+statement)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if errClose := rows.Close(); errClose != nil && err == nil {
+			err = errClose
+		}
+	}()
+
+	// the columns of EXPLAIN differ between versions
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		fields := make([]string, len(values))
+		for i, v := range values {
+			fields[i] = v.String
+		}
+		plan.WriteString(strings.TrimRight(strings.Join(fields, "\t"), "\t"))
+		plan.WriteByte('\n')
+	}
+	return rows.Err()
+}
+
+// splitStatements splits migration into its statements at semicolons
+// outside of quotes and comments. Empty statements are dropped.
+func splitStatements(migration string) []string {
+	var statements []string
+	var quote byte
+	var lineComment, blockComment bool
+	start := 0
+	for i := 0; i < len(migration); i++ {
+		ch := migration[i]
+		switch {
+		case lineComment:
+			lineComment = ch != '\n'
+		case blockComment:
+			if ch == '*' && i+1 < len(migration) && migration[i+1] == '/' {
+				blockComment = false
+				i++
+			}
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+		case ch == '-' && i+1 < len(migration) && migration[i+1] == '-':
+			lineComment = true
+		case ch == '/' && i+1 < len(migration) && migration[i+1] == '*':
+			blockComment = true
+		case ch == ';':
+			statements = appendStatement(statements, migration[start:i])
+			start = i + 1
+		}
+	}
+	return appendStatement(statements, migration[start:])
+}
+
+// appendStatement appends statement to statements,
+// unless it's empty or only consists of comments.
+func appendStatement(statements []string, statement string) []string {
+	statement = strings.TrimSpace(statement)
+	if stripComments(statement) == "" {
+		return statements
+	}
+	return append(statements, statement)
+}
+
+// stripComments removes the leading comments of statement.
+func stripComments(statement string) string {
+	for {
+		statement = strings.TrimSpace(statement)
+		switch {
+		case strings.HasPrefix(statement, "--"):
+			i := strings.IndexByte(statement, '\n')
+			if i < 0 {
+				return ""
+			}
+			statement = statement[i+1:]
+		case strings.HasPrefix(statement, "/*"):
+			i := strings.Index(statement, "*/")
+			if i < 0 {
+				return ""
+			}
+			statement = statement[i+2:]
+		default:
+			return statement
+		}
+	}
+}
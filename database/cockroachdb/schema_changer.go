@@ -0,0 +1,69 @@
+package cockroachdb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+)
+
+import (
+	"github.com/lib/pq"
+)
+
+import (
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// ErrInvalidSchemaChanger is returned for values of
+// x-use-declarative-schema-changer other than on and off.
+var ErrInvalidSchemaChanger = fmt.Errorf("x-use-declarative-schema-changer must be on or off")
+
+// openDB opens the database at dsn. If schemaChanger is set, every session
+// of the database sets use_declarative_schema_changer to it once connected,
+// e.g. to run migrations relying on the timing of the legacy schema changer
+// on versions using the declarative one by default.
+func openDB(dsn string, schemaChanger string) (*sql.DB, error) {
+	if len(schemaChanger) == 0 {
+		return sql.Open("postgres", dsn)
+	}
+	if schemaChanger != "on" && schemaChanger != "off" {
+		return nil, ErrInvalidSchemaChanger
+	}
+
+	connector, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(&sessionConnector{
+		Connector: connector,
+		statement: "SET use_declarative_schema_changer = '" + schemaChanger + "'",
+	}), nil
+}
+
+// sessionConnector runs statement on each connection it opens, before the
+// connection is used, so the setting applies to the whole session no matter
+// which connection of the pool runs a query.
+type sessionConnector struct {
+	driver.Connector
+	statement string
+}
+
+// Connect implements driver.Connector.
+func (c *sessionConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		_ = conn.Close()
+		return nil, fmt.Errorf("connection can't execute %v", c.statement)
+	}
+	if _, err := execer.ExecContext(ctx, c.statement, nil); err != nil {
+		_ = conn.Close()
+		return nil, &database.Error{OrigErr: err, Query: []byte(c.statement)}
+	}
+	return conn, nil
+}
@@ -0,0 +1,75 @@
+package cockroachdb
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+// recordingConnector opens connections recording the statements run on them.
+type recordingConnector struct {
+	statements []string
+}
+
+func (c *recordingConnector) Connect(context.Context) (driver.Conn, error) {
+	return &recordingConn{connector: c}, nil
+}
+
+func (c *recordingConnector) Driver() driver.Driver {
+	return nil
+}
+
+type recordingConn struct {
+	connector *recordingConnector
+}
+
+func (c *recordingConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *recordingConn) Close() error                        { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c *recordingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.connector.statements = append(c.connector.statements, query)
+	return driver.RowsAffected(0), nil
+}
+
+func TestSessionConnector(t *testing.T) {
+	recording := &recordingConnector{}
+	connector := &sessionConnector{Connector: recording, statement: "SET use_declarative_schema_changer = 'off'"}
+
+	for i := 0; i < 2; i++ {
+		if _, err := connector.Connect(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// once per session
+	expected := []string{"SET use_declarative_schema_changer = 'off'", "SET use_declarative_schema_changer = 'off'"}
+	if len(recording.statements) != len(expected) {
+		t.Fatalf("expected statements %v, got %v", expected, recording.statements)
+	}
+	for i := range expected {
+		if recording.statements[i] != expected[i] {
+			t.Errorf("expected statements %v, got %v", expected, recording.statements)
+		}
+	}
+}
+
+func TestOpenDBSchemaChanger(t *testing.T) {
+	for _, value := range []string{"", "on", "off"} {
+		db, err := openDB("postgres://root@localhost:26257/defaultdb?sslmode=disable", value)
+		if err != nil {
+			t.Errorf("%q: %v", value, err)
+			continue
+		}
+		if err := db.Close(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	for _, value := range []string{"false", "unsafe", "OFF"} {
+		if _, err := openDB("postgres://root@localhost:26257/defaultdb?sslmode=disable", value); !errors.Is(err, ErrInvalidSchemaChanger) {
+			t.Errorf("%q: expected ErrInvalidSchemaChanger, got %v", value, err)
+		}
+	}
+}
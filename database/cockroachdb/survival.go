@@ -0,0 +1,82 @@
+package cockroachdb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// SurvivalGoal is the survival goal of a multi-region database, see
+// https://www.cockroachlabs.com/docs/stable/multiregion-overview.html#survival-goals
+type SurvivalGoal string
+
+const (
+	// SurvivalGoalZone survives the failure of an availability zone.
+	SurvivalGoalZone SurvivalGoal = "zone"
+
+	// SurvivalGoalRegion survives the failure of a whole region.
+	SurvivalGoalRegion SurvivalGoal = "region"
+)
+
+// ErrSurvivalGoalMismatch is returned when the configured survival goal
+// differs from the one of the database. Survival goals are set per
+// database, so the tables of the driver can't have another one.
+var ErrSurvivalGoalMismatch = errors.New("survival goal doesn't match the survival goal of the database")
+
+// parseSurvivalGoal parses the value of x-survival-goal.
+func parseSurvivalGoal(s string) (SurvivalGoal, error) {
+	switch goal := SurvivalGoal(s); goal {
+	case "", SurvivalGoalZone, SurvivalGoalRegion:
+		return goal, nil
+	default:
+		return "", fmt.Errorf("unknown survival goal %q, must be zone or region", s)
+	}
+}
+
+// survivalGoal returns the survival goal the tables of the driver are
+// created for. It's empty for single-region databases and versions of
+// CockroachDB without multi-region support, even if one is configured.
+func (c *CockroachDb) survivalGoal() (SurvivalGoal, error) {
+	query := `SELECT survival_goal FROM [SHOW DATABASES] WHERE database_name = current_database()`
+	var goal sql.NullString
+	if err := c.db.QueryRow(query).Scan(&goal); err != nil {
+		// versions before 21.1 have no survival_goal column, and older
+		// ones don't support SHOW statements as data sources. Without
+		// multi-region support, tables are created as before.
+		var e *pq.Error
+		if errors.As(err, &e) {
+			return "", nil
+		}
+		return "", &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+
+	detected := SurvivalGoal(goal.String)
+	if detected == "" {
+		return "", nil
+	}
+	if c.config.SurvivalGoal != "" && c.config.SurvivalGoal != detected {
+		return "", fmt.Errorf("%w: configured %v, database %v", ErrSurvivalGoalMismatch, c.config.SurvivalGoal, detected)
+	}
+	return detected, nil
+}
+
+// localityClause returns the LOCALITY clause of the tables of the driver for
+// a database with the survival goal. Surviving region failures, the tables
+// are GLOBAL, so they can be read from every remaining region without
+// contacting the region of their leaseholder. Writes are slower, but the
+// tables are rarely written to. Otherwise they're kept in the primary
+// region.
+func localityClause(goal SurvivalGoal) string {
+	switch goal {
+	case SurvivalGoalRegion:
+		return ` LOCALITY GLOBAL`
+	case SurvivalGoalZone:
+		return ` LOCALITY REGIONAL BY TABLE IN PRIMARY REGION`
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,70 @@
+package cockroachdb
+
+import (
+	"database/sql"
+	"errors"
+)
+
+import (
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// querier is implemented by *sql.DB and *sql.Tx.
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// querier returns the transaction started by Begin, if any, or else the
+// database of c.
+func (c *CockroachDb) querier() querier {
+	if c.tx != nil {
+		return c.tx
+	}
+	return c.db
+}
+
+// Begin implements database.Transactioner. The transaction spans Run,
+// SetVersion and Version until Commit or Rollback is called. Unlike
+// SetVersion outside of a transaction, it isn't retried on transaction
+// restarts, so migrations failing with a retryable error fail the whole
+// transaction. Migrations run in it must not contain BEGIN or COMMIT
+// statements of their own.
+func (c *CockroachDb) Begin() error {
+	if c.tx != nil {
+		return errors.New("transaction already started")
+	}
+	tx, err := c.db.Begin()
+	if err != nil {
+		return &database.Error{OrigErr: err, Err: "transaction start failed"}
+	}
+	c.tx = tx
+	return nil
+}
+
+// Commit implements database.Transactioner. If it fails, the transaction
+// is still ended by Rollback.
+func (c *CockroachDb) Commit() error {
+	if c.tx == nil {
+		return errors.New("no transaction started")
+	}
+	if err := c.tx.Commit(); err != nil {
+		return &database.Error{OrigErr: err, Err: "transaction commit failed"}
+	}
+	c.tx = nil
+	return nil
+}
+
+// Rollback implements database.Transactioner.
+func (c *CockroachDb) Rollback() error {
+	if c.tx == nil {
+		return errors.New("no transaction started")
+	}
+	tx := c.tx
+	c.tx = nil
+	// a failed Commit already ended the transaction
+	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		return &database.Error{OrigErr: err, Err: "transaction rollback failed"}
+	}
+	return nil
+}
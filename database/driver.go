@@ -5,6 +5,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"sync"
@@ -15,6 +16,9 @@ import (
 var (
 	ErrLocked    = fmt.Errorf("can't acquire lock")
 	ErrNotLocked = fmt.Errorf("can't unlock, as not currently locked")
+	// ErrConcurrentModification is returned by EpochVersioner.SetVersionEpoch
+	// if the version has been written by someone else since it was read.
+	ErrConcurrentModification = fmt.Errorf("version was modified concurrently")
 )
 
 const NilVersion int = -1
@@ -42,6 +46,8 @@ var drivers = make(map[string]Driver)
 //     When in doubt, return an error and explain the situation to the user.
 //   * All configuration input must come from the URL string in func Open()
 //     or the Config{} struct in WithInstance. Don't os.Getenv().
+//   * Wrap engine specific errors into ConstraintError, ConnectionError or
+//     SyntaxError where possible, so they can be handled with errors.Is/As.
 type Driver interface {
 	// Open returns a new driver instance configured with parameters
 	// coming from the URL string. Migrate will call this function
@@ -81,8 +87,151 @@ type Driver interface {
 	Drop() error
 }
 
+// Verifier is an optional interface a Driver can implement to support
+// verification queries declared with a `-- migrate:verify <query>` header.
+// Migrate calls Verify right after the migration has been applied, within
+// the transaction of the migration if the Driver implements Transactioner.
+type Verifier interface {
+	// Verify runs query and reports whether it returned a truthy result.
+	// No rows, NULL, false and zero values are not truthy.
+	Verify(query string) (ok bool, err error)
+}
+
+// ContextRunner is an optional interface a Driver can implement to support
+// per-migration timeouts declared with a `-- migrate:timeout <duration>` header,
+// and cancelling migrations with the context set by migrate.SetContext.
+type ContextRunner interface {
+	// RunContext is like Run, but must abort the migration once ctx is done.
+	// A deadline on ctx takes precedence over any timeout configured for the driver.
+	RunContext(ctx context.Context, migration io.Reader) error
+}
+
+// ContextLocker is an optional interface a Driver can implement to abort
+// acquiring and releasing the lock once a context is done.
+// See migrate.SetContext.
+type ContextLocker interface {
+	// LockContext is like Lock, but must stop waiting for the lock
+	// once ctx is done.
+	LockContext(ctx context.Context) error
+
+	// UnlockContext is like Unlock, but must return once ctx is done.
+	UnlockContext(ctx context.Context) error
+}
+
+// Pinger is an optional interface a Driver can implement to check that the
+// database is reachable. See WaitFor.
+type Pinger interface {
+	// Ping checks the connection to the database.
+	Ping(ctx context.Context) error
+}
+
+// Transactioner is an optional interface a Driver can implement to apply
+// several migrations atomically. See migrate.UpPhase.
+type Transactioner interface {
+	// Begin starts a transaction spanning the following calls to Run
+	// and SetVersion until Commit or Rollback is called.
+	Begin() error
+
+	// Commit commits the transaction started by Begin.
+	Commit() error
+
+	// Rollback discards all changes made since Begin, including the version.
+	Rollback() error
+}
+
+// EpochVersioner is an optional interface a Driver can implement to detect
+// concurrent writes of the version that slipped past a best-effort lock.
+// An epoch is stored along with the version and increased by every write.
+// Migrate writes the version with the epoch it last read or wrote.
+type EpochVersioner interface {
+	// VersionEpoch is like Version, but also returns the current epoch.
+	VersionEpoch() (version int, dirty bool, epoch uint64, err error)
+
+	// SetVersionEpoch is like SetVersion, but must fail with
+	// ErrConcurrentModification unless the current epoch equals epoch.
+	// On success the epoch is increased by one.
+	SetVersionEpoch(version int, dirty bool, epoch uint64) error
+}
+
+// Baseliner is an optional interface a Driver can implement to record the
+// baseline version set on a database that already had a schema before
+// adopting migrate. See migrate.Baseline.
+type Baseliner interface {
+	// SetBaseline records version as the baseline version.
+	SetBaseline(version int) error
+
+	// Baseline returns the recorded baseline version.
+	// When no baseline has been recorded, it must return version -1.
+	Baseline() (version int, err error)
+}
+
+// SchemaFingerprinter is an optional interface a Driver can implement to
+// detect changes of the schema made outside of migrations.
+// See migrate.DriftCheck.
+type SchemaFingerprinter interface {
+	// SchemaFingerprint returns a hash of the current schema, i.e. its
+	// tables, columns and indexes, excluding the tables kept by the
+	// driver, like the migrations table.
+	// It must only change if the schema changes.
+	SchemaFingerprint() (string, error)
+}
+
+// StatementCheckpointStore is an optional interface a Driver can implement
+// to resume migrations interrupted by a crash, see migrate.Resume.
+// Statements must be run individually and outside of a transaction, so the
+// completed ones are kept when a later one fails.
+type StatementCheckpointStore interface {
+	// RunStatement runs a single statement of a migration.
+	RunStatement(statement []byte) error
+
+	// SetCheckpoint records that the first n statements of the migration to
+	// the current (dirty) version have completed. SetVersion resets it to 0.
+	SetCheckpoint(n int) error
+
+	// Checkpoint returns the number of statements recorded by SetCheckpoint.
+	Checkpoint() (n int, err error)
+}
+
+// Explainer is an optional interface a Driver can implement to show what a
+// migration would do, e.g. with the EXPLAIN statement of the database,
+// without applying it. See migrate.Explain.
+type Explainer interface {
+	// Explain returns the plan of the database for the statements of
+	// migration. It must not change the database.
+	Explain(migration io.Reader) (string, error)
+}
+
+// VersionDropper is an optional interface a Driver can implement to reset
+// the migration state without touching the migrated data, e.g. to baseline
+// an existing database again. See migrate.ResetVersionState.
+type VersionDropper interface {
+	// DropVersion drops the migrations table and the other state kept
+	// by the driver, like the lock table. Version must return NilVersion
+	// afterwards.
+	DropVersion() error
+}
+
+// MigrationFingerprintStore is an optional interface a Driver can implement
+// to record a fingerprint of the up and down migrations of each applied
+// version, detecting migrations changed after they were applied.
+// See migrate.CheckFingerprints.
+type MigrationFingerprintStore interface {
+	// SetMigrationFingerprint records the fingerprint of version.
+	SetMigrationFingerprint(version int, fingerprint string) error
+
+	// MigrationFingerprint returns the fingerprint recorded for version,
+	// or an empty string if none has been recorded.
+	MigrationFingerprint(version int) (fingerprint string, err error)
+}
+
 // Open returns a new driver instance.
+// Environment variables in url are expanded, see README.md.
 func Open(url string) (Driver, error) {
+	url, err := iurl.ExpandEnv(url)
+	if err != nil {
+		return nil, err
+	}
+
 	scheme, err := iurl.SchemeFromURL(url)
 	if err != nil {
 		return nil, err
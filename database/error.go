@@ -1,6 +1,7 @@
 package database
 
 import (
+	"errors"
 	"fmt"
 )
 
@@ -25,3 +26,52 @@ func (e Error) Error() string {
 	}
 	return fmt.Sprintf("%v in line %v: %s (details: %v)", e.Err, e.Line, e.Query, e.OrigErr)
 }
+
+// Unwrap returns the underlying error.
+func (e Error) Unwrap() error {
+	return e.OrigErr
+}
+
+var (
+	// ErrConstraint matches a ConstraintError with errors.Is.
+	ErrConstraint = errors.New("constraint violation")
+	// ErrConnection matches a ConnectionError with errors.Is.
+	ErrConnection = errors.New("connection failure")
+	// ErrSyntax matches a SyntaxError with errors.Is.
+	ErrSyntax = errors.New("syntax error")
+)
+
+// ConstraintError wraps an engine specific error caused by a violated
+// constraint, i.e. a unique, foreign key or not null constraint.
+type ConstraintError struct {
+	Err error
+}
+
+func (e *ConstraintError) Error() string { return e.Err.Error() }
+
+func (e *ConstraintError) Unwrap() error { return e.Err }
+
+func (e *ConstraintError) Is(target error) bool { return target == ErrConstraint }
+
+// ConnectionError wraps an engine specific error caused by a broken or
+// refused connection to the database.
+type ConnectionError struct {
+	Err error
+}
+
+func (e *ConnectionError) Error() string { return e.Err.Error() }
+
+func (e *ConnectionError) Unwrap() error { return e.Err }
+
+func (e *ConnectionError) Is(target error) bool { return target == ErrConnection }
+
+// SyntaxError wraps an engine specific error caused by an invalid statement.
+type SyntaxError struct {
+	Err error
+}
+
+func (e *SyntaxError) Error() string { return e.Err.Error() }
+
+func (e *SyntaxError) Unwrap() error { return e.Err }
+
+func (e *SyntaxError) Is(target error) bool { return target == ErrSyntax }
@@ -0,0 +1,158 @@
+// +build go1.9
+
+package firebird
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/multistmt"
+	"github.com/hashicorp/go-multierror"
+)
+
+// batchInsertDirective marks a migration whose INSERT statements are run
+// in batches, see runBatched.
+const batchInsertDirective = "-- firebird:batch-insert"
+
+// DefaultBatchInsertSize is the number of INSERT statements committed
+// together in batch-insert mode.
+var DefaultBatchInsertSize = 1000
+
+// hasBatchInsertDirective reports whether a line of migration
+// consists of the batch-insert directive.
+func hasBatchInsertDirective(migration []byte) bool {
+	for _, line := range bytes.Split(migration, []byte("\n")) {
+		if string(bytes.TrimSpace(line)) == batchInsertDirective {
+			return true
+		}
+	}
+	return false
+}
+
+// stripLeadingComments removes the whitespace and `--` line comments
+// preceding the first keyword of statement.
+func stripLeadingComments(statement string) string {
+	for {
+		statement = strings.TrimSpace(statement)
+		if !strings.HasPrefix(statement, "--") {
+			return statement
+		}
+		i := strings.IndexByte(statement, '\n')
+		if i < 0 {
+			return ""
+		}
+		statement = statement[i+1:]
+	}
+}
+
+// isInsert reports whether statement is an INSERT statement.
+func isInsert(statement string) bool {
+	fields := strings.Fields(stripLeadingComments(statement))
+	return len(fields) > 0 && strings.EqualFold(fields[0], "INSERT")
+}
+
+// insertBatch runs INSERT statements in a transaction, committing
+// it once size statements have been run.
+type insertBatch struct {
+	f    *Firebird
+	size int
+	tx   *sql.Tx
+	n    int
+}
+
+// exec runs the INSERT statement in the current transaction,
+// starting one if needed.
+func (b *insertBatch) exec(ctx context.Context, statement string) error {
+	if b.tx == nil {
+		tx, err := b.f.conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		b.tx = tx
+	}
+	res, err := b.tx.ExecContext(ctx, statement)
+	if err != nil {
+		return err
+	}
+	b.f.rowsAffected([]byte(statement), res)
+	if b.n++; b.n >= b.size {
+		return b.flush()
+	}
+	return nil
+}
+
+// flush commits the current transaction, if any.
+func (b *insertBatch) flush() error {
+	if b.tx == nil {
+		return nil
+	}
+	tx := b.tx
+	b.tx, b.n = nil, 0
+	return tx.Commit()
+}
+
+// rollback discards the current transaction, if any.
+func (b *insertBatch) rollback() error {
+	if b.tx == nil {
+		return nil
+	}
+	tx := b.tx
+	b.tx, b.n = nil, 0
+	return tx.Rollback()
+}
+
+// runBatched runs the statements of a migration containing the
+// `-- firebird:batch-insert` directive. Consecutive INSERT statements are
+// run in a single transaction, which is committed every BatchInsertSize
+// statements, instead of committing each statement on its own. Any other
+// statement, like DDL, commits the pending INSERT statements first and is
+// then run on its own, as in multi-statement mode. If a statement fails,
+// the pending INSERT statements are rolled back, while the batches
+// committed before are kept.
+func (f *Firebird) runBatched(migration io.Reader) error {
+	ctx := context.Background()
+	b := &insertBatch{f: f, size: f.config.BatchInsertSize}
+
+	var err error
+	if e := multistmt.Parse(migration, multiStmtDelimiter, f.config.MultiStatementMaxSize, func(m []byte) bool {
+		tq := strings.TrimSpace(string(m))
+		if stripLeadingComments(strings.TrimSuffix(tq, string(multiStmtDelimiter))) == "" {
+			return true
+		}
+		if isInsert(tq) {
+			if e := b.exec(ctx, tq); e != nil {
+				err = database.Error{OrigErr: e, Err: "migration failed", Query: m}
+				return false
+			}
+			return true
+		}
+		if e := b.flush(); e != nil {
+			err = database.Error{OrigErr: e, Err: "batch insert failed", Query: m}
+			return false
+		}
+		res, e := f.conn.ExecContext(ctx, tq)
+		if e != nil {
+			err = database.Error{OrigErr: e, Err: "migration failed", Query: m}
+			return false
+		}
+		f.rowsAffected([]byte(tq), res)
+		return true
+	}); e != nil && err == nil {
+		err = e
+	}
+
+	if err != nil {
+		if errRollback := b.rollback(); errRollback != nil {
+			err = multierror.Append(err, errRollback)
+		}
+		return err
+	}
+	if err := b.flush(); err != nil {
+		return database.Error{OrigErr: err, Err: "batch insert failed"}
+	}
+	return nil
+}
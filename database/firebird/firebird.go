@@ -3,16 +3,20 @@
 package firebird
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"fmt"
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/multistmt"
 	"github.com/hashicorp/go-multierror"
 	_ "github.com/nakagami/firebirdsql"
 	"io"
 	"io/ioutil"
 	nurl "net/url"
+	"strconv"
+	"strings"
 )
 
 func init() {
@@ -21,15 +25,43 @@ func init() {
 	database.Register("firebirdsql", &db)
 }
 
+var (
+	multiStmtDelimiter = []byte(";")
+
+	DefaultMultiStatementMaxSize = 10 * 1 << 20 // 10 MB
+)
+
 var DefaultMigrationsTable = "schema_migrations"
 
 var (
 	ErrNilConfig = fmt.Errorf("no config")
+	// ErrInvalidWireCrypt is returned for an x-wire-crypt value other than
+	// Enabled, Required or Disabled, or if Required can't be satisfied.
+	ErrInvalidWireCrypt = fmt.Errorf("invalid x-wire-crypt")
+	// ErrInvalidAuthPlugin is returned for an x-auth-plugins value
+	// not supported by the firebirdsql driver.
+	ErrInvalidAuthPlugin = fmt.Errorf("invalid x-auth-plugins")
 )
 
+// authPlugins are the authentication plugins supported by firebirdsql.
+var authPlugins = []string{"Srp256", "Srp", "Legacy_Auth"}
+
+// RowsAffectedFunc is called with each statement run by a migration and the
+// number of rows it affected, e.g. to log how many rows a backfill touched.
+type RowsAffectedFunc func(statement []byte, rowsAffected int64)
+
 type Config struct {
-	DatabaseName    string
-	MigrationsTable string
+	DatabaseName          string
+	MigrationsTable       string
+	MultiStatementEnabled bool
+	MultiStatementMaxSize int
+	// BatchInsertSize is the number of INSERT statements committed together
+	// in migrations containing the `-- firebird:batch-insert` directive.
+	// Defaults to DefaultBatchInsertSize.
+	BatchInsertSize int
+	// OnRowsAffected is called after each statement of a migration,
+	// if set. With x-multi-statement, it's called for every statement.
+	OnRowsAffected RowsAffectedFunc
 }
 
 type Firebird struct {
@@ -55,6 +87,14 @@ func WithInstance(instance *sql.DB, config *Config) (database.Driver, error) {
 		config.MigrationsTable = DefaultMigrationsTable
 	}
 
+	if config.MultiStatementMaxSize <= 0 {
+		config.MultiStatementMaxSize = DefaultMultiStatementMaxSize
+	}
+
+	if config.BatchInsertSize <= 0 {
+		config.BatchInsertSize = DefaultBatchInsertSize
+	}
+
 	conn, err := instance.Conn(context.Background())
 	if err != nil {
 		return nil, err
@@ -79,14 +119,38 @@ func (f *Firebird) Open(dsn string) (database.Driver, error) {
 		return nil, err
 	}
 
-	db, err := sql.Open("firebirdsql", migrate.FilterCustomQuery(purl).String())
+	multiStatementMaxSize := DefaultMultiStatementMaxSize
+	if s := purl.Query().Get("x-multi-statement-max-size"); len(s) > 0 {
+		multiStatementMaxSize, err = strconv.Atoi(s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	batchInsertSize := DefaultBatchInsertSize
+	if s := purl.Query().Get("x-batch-insert-size"); len(s) > 0 {
+		batchInsertSize, err = strconv.Atoi(s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fbdsn, err := driverDSN(purl)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("firebirdsql", fbdsn)
 	if err != nil {
 		return nil, err
 	}
 
 	px, err := WithInstance(db, &Config{
-		MigrationsTable: purl.Query().Get("x-migrations-table"),
-		DatabaseName:    purl.Path,
+		MigrationsTable:       purl.Query().Get("x-migrations-table"),
+		DatabaseName:          purl.Path,
+		MultiStatementEnabled: purl.Query().Get("x-multi-statement") == "true",
+		MultiStatementMaxSize: multiStatementMaxSize,
+		BatchInsertSize:       batchInsertSize,
 	})
 
 	if err != nil {
@@ -96,6 +160,57 @@ func (f *Firebird) Open(dsn string) (database.Driver, error) {
 	return px, nil
 }
 
+// driverDSN returns the DSN passed to firebirdsql, forwarding x-wire-crypt
+// and x-auth-plugins as its wire_crypt and auth_plugin_name params.
+// Like the server's WireCrypt setting, x-wire-crypt is one of Enabled,
+// Required or Disabled. firebirdsql encrypts the connection whenever the
+// server agrees to, so Enabled and Required both enable encryption, but
+// Required is rejected with the Legacy_Auth plugin, which doesn't
+// support it.
+func driverDSN(purl *nurl.URL) (string, error) {
+	q := purl.Query()
+	filtered := migrate.FilterCustomQuery(purl)
+	params := filtered.Query()
+
+	if plugin := q.Get("x-auth-plugins"); len(plugin) > 0 {
+		name, ok := authPluginName(plugin)
+		if !ok {
+			return "", fmt.Errorf("%w: %q, supported: %s", ErrInvalidAuthPlugin, plugin, strings.Join(authPlugins, ", "))
+		}
+		params.Set("auth_plugin_name", name)
+	}
+
+	if wireCrypt := q.Get("x-wire-crypt"); len(wireCrypt) > 0 {
+		switch {
+		case strings.EqualFold(wireCrypt, "Enabled"):
+			params.Set("wire_crypt", "true")
+		case strings.EqualFold(wireCrypt, "Required"):
+			if params.Get("auth_plugin_name") == "Legacy_Auth" {
+				return "", fmt.Errorf("%w: Required isn't supported by the Legacy_Auth plugin", ErrInvalidWireCrypt)
+			}
+			params.Set("wire_crypt", "true")
+		case strings.EqualFold(wireCrypt, "Disabled"):
+			params.Set("wire_crypt", "false")
+		default:
+			return "", fmt.Errorf("%w: %q, supported: Enabled, Required, Disabled", ErrInvalidWireCrypt, wireCrypt)
+		}
+	}
+
+	filtered.RawQuery = params.Encode()
+	return filtered.String(), nil
+}
+
+// authPluginName returns the name of the authentication plugin
+// matching plugin case-insensitively.
+func authPluginName(plugin string) (string, bool) {
+	for _, name := range authPlugins {
+		if strings.EqualFold(plugin, name) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
 func (f *Firebird) Close() error {
 	connErr := f.conn.Close()
 	dbErr := f.db.Close()
@@ -118,21 +233,70 @@ func (f *Firebird) Unlock() error {
 	return nil
 }
 
+// Run executes the migration on the driver's connection, which runs in
+// autocommit mode: every statement is followed by a COMMIT RETAINING.
+// Firebird only makes objects created by DDL usable once the DDL has been
+// committed, so with x-multi-statement each statement is executed and
+// committed on its own, which lets later statements of the same migration
+// reference tables (including global temporary tables) created by earlier ones.
+// Migrations containing the `-- firebird:batch-insert` directive are split
+// into statements, too, and their INSERT statements are run in batches,
+// see runBatched.
 func (f *Firebird) Run(migration io.Reader) error {
 	migr, err := ioutil.ReadAll(migration)
 	if err != nil {
 		return err
 	}
 
+	if hasBatchInsertDirective(migr) {
+		return f.runBatched(bytes.NewReader(migr))
+	}
+
+	if f.config.MultiStatementEnabled {
+		var err error
+		if e := multistmt.Parse(bytes.NewReader(migr), multiStmtDelimiter, f.config.MultiStatementMaxSize, func(m []byte) bool {
+			tq := strings.TrimSpace(string(m))
+			if tq == "" || tq == string(multiStmtDelimiter) {
+				return true
+			}
+			res, e := f.conn.ExecContext(context.Background(), tq)
+			if e != nil {
+				err = database.Error{OrigErr: e, Err: "migration failed", Query: m}
+				return false
+			}
+			f.rowsAffected([]byte(tq), res)
+			return true
+		}); e != nil {
+			return e
+		}
+		return err
+	}
+
 	// run migration
 	query := string(migr[:])
-	if _, err := f.conn.ExecContext(context.Background(), query); err != nil {
+	res, err := f.conn.ExecContext(context.Background(), query)
+	if err != nil {
 		return database.Error{OrigErr: err, Err: "migration failed", Query: migr}
 	}
+	f.rowsAffected(migr, res)
 
 	return nil
 }
 
+// rowsAffected reports the number of rows affected by statement to
+// OnRowsAffected, if set. Since the statement has already been run,
+// a failure to get the number of rows is not reported.
+func (f *Firebird) rowsAffected(statement []byte, res sql.Result) {
+	if f.config.OnRowsAffected == nil {
+		return
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	f.config.OnRowsAffected(statement, n)
+}
+
 func (f *Firebird) SetVersion(version int, dirty bool) error {
 	// Always re-write the schema version to prevent empty schema version
 	// for failed down migration on the first migration
@@ -4,8 +4,10 @@ import (
 	"context"
 	"database/sql"
 	sqldriver "database/sql/driver"
+	"errors"
 	"fmt"
 	"log"
+	nurl "net/url"
 
 	"github.com/golang-migrate/migrate/v4"
 	"io"
@@ -125,6 +127,184 @@ func TestMigrate(t *testing.T) {
 	})
 }
 
+func TestMultiStatement(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.FirstPort()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := fbConnectionString(ip, port) + "?x-multi-statement=true"
+		p := &Firebird{}
+		d, err := p.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		migration := `CREATE TABLE foo (foo varchar(40));
+			INSERT INTO foo (foo) VALUES ('bar');
+			CREATE GLOBAL TEMPORARY TABLE tmp_foo (foo varchar(40)) ON COMMIT PRESERVE ROWS;
+			INSERT INTO tmp_foo (foo) SELECT foo FROM foo;`
+		if err := d.Run(strings.NewReader(migration)); err != nil {
+			t.Fatalf("expected err to be nil, got %v", err)
+		}
+
+		// make sure the inserts are visible
+		var count int
+		query := "SELECT COUNT(*) FROM foo"
+		if err := d.(*Firebird).conn.QueryRowContext(context.Background(), query).Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Fatalf("expected 1 row in foo, got %v", count)
+		}
+		query = "SELECT COUNT(*) FROM tmp_foo"
+		if err := d.(*Firebird).conn.QueryRowContext(context.Background(), query).Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Fatalf("expected 1 row in tmp_foo, got %v", count)
+		}
+	})
+}
+
+func TestRowsAffected(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.FirstPort()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := fbConnectionString(ip, port) + "?x-multi-statement=true"
+		p := &Firebird{}
+		d, err := p.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		rowsAffected := make(map[string]int64)
+		d.(*Firebird).config.OnRowsAffected = func(statement []byte, n int64) {
+			rowsAffected[string(statement)] = n
+		}
+
+		migration := `CREATE TABLE foo (foo integer);
+			INSERT INTO foo (foo) VALUES (1);
+			INSERT INTO foo (foo) VALUES (2);
+			INSERT INTO foo (foo) VALUES (3);
+			UPDATE foo SET foo = foo * 10 WHERE foo < 3;`
+		if err := d.Run(strings.NewReader(migration)); err != nil {
+			t.Fatalf("expected err to be nil, got %v", err)
+		}
+
+		if n := rowsAffected["UPDATE foo SET foo = foo * 10 WHERE foo < 3;"]; n != 2 {
+			t.Fatalf("expected UPDATE to affect 2 rows, got %v", n)
+		}
+	})
+}
+
+func TestBatchInsert(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.FirstPort()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := fbConnectionString(ip, port) + "?x-multi-statement=true&x-batch-insert-size=100"
+		p := &Firebird{}
+		d, err := p.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		// the same rows, inserted one by one and in batches,
+		// with DDL flushing the batch in between
+		var inserts strings.Builder
+		for i := 0; i < 250; i++ {
+			fmt.Fprintf(&inserts, "INSERT INTO %%[1]s (id, name) VALUES (%d, 'row %d');\n", i, i)
+		}
+		single := fmt.Sprintf("CREATE TABLE single_rows (id integer, name varchar(40));\n"+inserts.String(), "single_rows")
+		batched := fmt.Sprintf(batchInsertDirective+"\nCREATE TABLE batch_rows (id integer, name varchar(40));\n"+inserts.String()+
+			"CREATE TABLE batch_later (id integer);\nINSERT INTO batch_later (id) SELECT id FROM %[1]s;\n", "batch_rows")
+		if err := d.Run(strings.NewReader(single)); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.Run(strings.NewReader(batched)); err != nil {
+			t.Fatal(err)
+		}
+
+		conn := d.(*Firebird).conn
+		var count int
+		query := `SELECT COUNT(*) FROM single_rows s FULL JOIN batch_rows b ON s.id = b.id AND s.name = b.name
+			WHERE s.id IS NULL OR b.id IS NULL`
+		if err := conn.QueryRowContext(context.Background(), query).Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Fatalf("expected batched rows to equal single rows, got %v differences", count)
+		}
+		if err := conn.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM batch_later").Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		if count != 250 {
+			t.Fatalf("expected 250 rows in batch_later, got %v", count)
+		}
+
+		// a failing insert rolls back its batch
+		failing := batchInsertDirective + "\nINSERT INTO batch_rows (id, name) VALUES (1000, 'x');\nINSERT INTO missing (id) VALUES (1);"
+		if err := d.Run(strings.NewReader(failing)); err == nil {
+			t.Fatal("expected error")
+		}
+		if err := conn.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM batch_rows WHERE id = 1000").Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Fatalf("expected the failed batch to be rolled back, got %v rows", count)
+		}
+	})
+}
+
+func TestBatchInsertStatements(t *testing.T) {
+	migration := []byte("CREATE TABLE foo (id integer);\n  " + batchInsertDirective + "  \nINSERT INTO foo (id) VALUES (1);")
+	if !hasBatchInsertDirective(migration) {
+		t.Error("expected the directive to be found")
+	}
+	if hasBatchInsertDirective([]byte("INSERT INTO foo (id) VALUES (1); -- firebird:batch-insert")) {
+		t.Error("expected a trailing comment not to be the directive")
+	}
+
+	testCases := []struct {
+		statement string
+		insert    bool
+	}{
+		{statement: "INSERT INTO foo (id) VALUES (1);", insert: true},
+		{statement: batchInsertDirective + "\n  insert into foo (id) values (1);", insert: true},
+		{statement: "-- seed\n-- more\nINSERT INTO foo SELECT * FROM bar;", insert: true},
+		{statement: "CREATE TABLE foo (id integer);"},
+		{statement: "UPDATE foo SET inserted = 1;"},
+		{statement: "-- INSERT INTO foo (id) VALUES (1);"},
+	}
+	for _, tc := range testCases {
+		if insert := isInsert(tc.statement); insert != tc.insert {
+			t.Errorf("expected isInsert(%q) to be %v", tc.statement, tc.insert)
+		}
+	}
+}
+
 func TestErrorParsing(t *testing.T) {
 	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
 		ip, port, err := c.FirstPort()
@@ -180,6 +360,40 @@ func TestFilterCustomQuery(t *testing.T) {
 	})
 }
 
+func TestDriverDSN(t *testing.T) {
+	testCases := []struct {
+		name  string
+		query string
+		dsn   string
+		err   error
+	}{
+		{name: "none", query: "role=admin", dsn: "firebirdsql://user:pw@localhost/test.fdb?role=admin"},
+		{name: "wire crypt disabled", query: "x-wire-crypt=Disabled", dsn: "firebirdsql://user:pw@localhost/test.fdb?wire_crypt=false"},
+		{name: "wire crypt enabled", query: "x-wire-crypt=enabled", dsn: "firebirdsql://user:pw@localhost/test.fdb?wire_crypt=true"},
+		{name: "wire crypt required", query: "x-wire-crypt=Required&x-auth-plugins=Srp256", dsn: "firebirdsql://user:pw@localhost/test.fdb?auth_plugin_name=Srp256&wire_crypt=true"},
+		{name: "legacy auth", query: "x-auth-plugins=legacy_auth&x-wire-crypt=Disabled", dsn: "firebirdsql://user:pw@localhost/test.fdb?auth_plugin_name=Legacy_Auth&wire_crypt=false"},
+		{name: "invalid wire crypt", query: "x-wire-crypt=Always", err: ErrInvalidWireCrypt},
+		{name: "wire crypt required with legacy auth", query: "x-wire-crypt=Required&x-auth-plugins=Legacy_Auth", err: ErrInvalidWireCrypt},
+		{name: "invalid auth plugin", query: "x-auth-plugins=Win_Sspi", err: ErrInvalidAuthPlugin},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			purl, err := nurl.Parse("firebirdsql://user:pw@localhost/test.fdb?" + tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dsn, err := driverDSN(purl)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected error %v, got %v", tc.err, err)
+			}
+			if dsn != tc.dsn {
+				t.Errorf("expected DSN %q, got %q", tc.dsn, dsn)
+			}
+		})
+	}
+}
+
 func Test_Lock(t *testing.T) {
 	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
 		ip, port, err := c.FirstPort()
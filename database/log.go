@@ -0,0 +1,9 @@
+package database
+
+// Logger is an interface so you can pass in your own logging
+// implementation for the warnings of database drivers, e.g. a *log.Logger
+// or a migrate.Logger.
+type Logger interface {
+	// Printf is like fmt.Printf
+	Printf(format string, v ...interface{})
+}
@@ -2,19 +2,24 @@ package mongodb
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/cenkalti/backoff/v4"
 	"github.com/golang-migrate/migrate/v4/database"
 	"github.com/hashicorp/go-multierror"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 	"go.mongodb.org/mongo-driver/x/mongo/driver/connstring"
 	"io"
 	"io/ioutil"
 	"net/url"
 	os "os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -33,29 +38,199 @@ const DefaultLockTimeoutInterval = 10                    // the default maximum
 const DefaultAdvisoryLockingFlag = true                  // the default value for the advisory locking feature flag. Default is true.
 const LockIndexName = "lock_unique_key"                  // the name of the index which adds unique constraint to the locking_key field.
 const contextWaitTimeout = 5 * time.Second               // how long to wait for the request to mongo to block/wait for.
+const dropIndexKeysCommand = "dropIndexKeys"             // the command dropping an index by its keys instead of its name.
+const bulkWriteCommand = "bulkWrite"                     // the command running mixed write operations on a collection.
 
 var (
 	ErrNoDatabaseName = fmt.Errorf("no database name")
 	ErrNilConfig      = fmt.Errorf("no config")
+	// ErrTimeSeries is returned for commands which time-series collections
+	// don't support, like arbitrary updates.
+	ErrTimeSeries = fmt.Errorf("command not supported on time-series collections")
+	// ErrUnsupportedCompressor is returned for values of x-compressors
+	// the driver can't compress the connection with.
+	ErrUnsupportedCompressor = fmt.Errorf("unsupported compressor")
+	// ErrUnknownCommand is returned by Validate for commands whose first
+	// field isn't a command known to the driver.
+	ErrUnknownCommand = fmt.Errorf("unknown command")
+	// ErrAPIStrictWithoutVersion is returned if APIStrict is set
+	// without APIVersion.
+	ErrAPIStrictWithoutVersion = fmt.Errorf("api strict requires an api version")
+	// ErrNoOplog is returned by OplogPosition if the server isn't a member
+	// of a replica set, so it has no oplog.
+	ErrNoOplog = fmt.Errorf("oplog positions require a replica set")
+	// ErrInvalidMaxStaleness is returned for a max staleness of the read
+	// preference below MinMaxStaleness, or with the primary mode.
+	ErrInvalidMaxStaleness = fmt.Errorf("invalid max staleness")
 )
 
+// MinMaxStaleness is the smallest max staleness servers accept in a read
+// preference.
+const MinMaxStaleness = 90 * time.Second
+
+// readCommands are the read commands run with the ReadPreference of the
+// config. Other commands always run on the primary.
+var readCommands = map[string]bool{
+	"count":           true,
+	"distinct":        true,
+	"find":            true,
+	"listCollections": true,
+	"listIndexes":     true,
+	"collStats":       true,
+	"dbStats":         true,
+}
+
+// supportedCompressors are the wire protocol compressors of the mongo driver.
+// zstd requires a newer version of it.
+var supportedCompressors = map[string]bool{
+	"snappy": true,
+	"zlib":   true,
+}
+
+// timeSeriesUnsupportedCommands are the commands rejected for time-series
+// collections, which only support inserts and queries (and deletes and
+// updates on the metaField since MongoDB 5.1).
+var timeSeriesUnsupportedCommands = map[string]bool{
+	"update":        true,
+	"findAndModify": true,
+}
+
+// knownCommands are the commands accepted by Validate as the first field
+// of a command: the database commands usable in migrations and the
+// commands handled by the driver itself.
+var knownCommands = map[string]bool{
+	"aggregate":                      true,
+	"applyOps":                       true,
+	"bulkWrite":                      true,
+	"cloneCollectionAsCapped":        true,
+	"collMod":                        true,
+	"compact":                        true,
+	"convertToCapped":                true,
+	"count":                          true,
+	"create":                         true,
+	"createIndexes":                  true,
+	"createRole":                     true,
+	"createSearchIndexes":            true,
+	"createUser":                     true,
+	"delete":                         true,
+	"distinct":                       true,
+	"drop":                           true,
+	"dropDatabase":                   true,
+	"dropIndexes":                    true,
+	dropIndexKeysCommand:             true,
+	"dropRole":                       true,
+	"dropSearchIndex":                true,
+	"dropUser":                       true,
+	"enableSharding":                 true,
+	"find":                           true,
+	"findAndModify":                  true,
+	"grantPrivilegesToRole":          true,
+	"grantRolesToRole":               true,
+	"grantRolesToUser":               true,
+	"insert":                         true,
+	"listCollections":                true,
+	"listIndexes":                    true,
+	"ping":                           true,
+	"refineCollectionShardKey":       true,
+	"renameCollection":               true,
+	"reshardCollection":              true,
+	"revokePrivilegesFromRole":       true,
+	"revokeRolesFromRole":            true,
+	"revokeRolesFromUser":            true,
+	"setFeatureCompatibilityVersion": true,
+	"setParameter":                   true,
+	"shardCollection":                true,
+	"update":                         true,
+	"updateRole":                     true,
+	"updateSearchIndex":              true,
+	"updateUser":                     true,
+	"validate":                       true,
+}
+
+// CommandError is returned by Validate for an invalid command of a migration.
+type CommandError struct {
+	// Index is the index of the command in the migration.
+	Index int
+	// Field is the offending field of the command, empty for empty commands.
+	Field string
+	Err   error
+}
+
+func (e CommandError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("command %d: %v", e.Index, e.Err)
+	}
+	return fmt.Sprintf("command %d: field %q: %v", e.Index, e.Field, e.Err)
+}
+
+func (e CommandError) Unwrap() error {
+	return e.Err
+}
+
 type Mongo struct {
 	client *mongo.Client
 	db     *mongo.Database
 	config *Config
+
+	// versionDB holds the migrations and lock collections.
+	versionDB *mongo.Database
+
+	// comment is attached to the commands of migrations, if the server
+	// supports it.
+	comment string
+
+	// pendingVersion is the dirty version set before a migration is run in
+	// transaction mode. The version is marked clean in the transaction of
+	// the migration, so both are committed together.
+	pendingVersion *int
+
+	// oplogPositionFunc receives the oplog positions around each migration
+	// if set. See SetOplogPositionFunc.
+	oplogPositionFunc OplogPositionFunc
 }
 
+// OplogPositionFunc receives the oplog positions before and after a
+// migration. See SetOplogPositionFunc.
+type OplogPositionFunc func(before, after string)
+
 type Locking struct {
 	CollectionName string
 	Timeout        int
 	Enabled        bool
 	Interval       int
+	// Owner is stored in the lock document along with the hostname and pid
+	// of the process holding the lock, to help debugging lock contention.
+	Owner string
 }
 type Config struct {
 	DatabaseName         string
 	MigrationsCollection string
 	TransactionMode      bool
 	Locking              Locking
+	// Comment is attached to each command of a migration, so its
+	// operations can be attributed in the profiler and the slow query log.
+	// It's skipped on servers older than 4.4, which don't support the
+	// comment field for all commands.
+	Comment string
+	// VersionDatabase is the database holding the migrations and lock
+	// collections, if migrations are run against another database, e.g.
+	// to run admin commands against the admin database.
+	// Defaults to DatabaseName.
+	VersionDatabase string
+	// APIVersion is the Stable API version the commands of migrations are
+	// declared with, e.g. "1", so they behave the same on later server
+	// versions. Requires MongoDB 5.0. The apiVersion field is added to
+	// each command, or only to the first one in TransactionMode, as the
+	// server rejects it for later commands of a transaction.
+	APIVersion string
+	// APIStrict makes the server reject commands of migrations which
+	// aren't part of APIVersion. Requires APIVersion.
+	APIStrict bool
+	// ReadPreference is the read preference of the read commands of
+	// migrations, like count or dbStats, e.g. to run them on secondaries.
+	// Other commands, and all commands in TransactionMode, run on the
+	// primary. Defaults to the primary.
+	ReadPreference *readpref.ReadPref
 }
 type versionInfo struct {
 	Version int  `bson:"version"`
@@ -66,6 +241,7 @@ type lockObj struct {
 	Key       int       `bson:"locking_key"`
 	Pid       int       `bson:"pid"`
 	Hostname  string    `bson:"hostname"`
+	Owner     string    `bson:"owner,omitempty"`
 	CreatedAt time.Time `bson:"created_at"`
 }
 type findFilter struct {
@@ -91,11 +267,23 @@ func WithInstance(instance *mongo.Client, config *Config) (database.Driver, erro
 	if config.Locking.Interval <= 0 {
 		config.Locking.Interval = DefaultLockTimeoutInterval
 	}
+	if len(config.VersionDatabase) == 0 {
+		config.VersionDatabase = config.DatabaseName
+	}
+	if config.APIStrict && len(config.APIVersion) == 0 {
+		return nil, ErrAPIStrictWithoutVersion
+	}
+	if config.ReadPreference != nil {
+		if err := checkMaxStaleness(config.ReadPreference); err != nil {
+			return nil, err
+		}
+	}
 
 	mc := &Mongo{
-		client: instance,
-		db:     instance.Database(config.DatabaseName),
-		config: config,
+		client:    instance,
+		db:        instance.Database(config.DatabaseName),
+		config:    config,
+		versionDB: instance.Database(config.VersionDatabase),
 	}
 
 	if mc.config.Locking.Enabled {
@@ -106,10 +294,37 @@ func WithInstance(instance *mongo.Client, config *Config) (database.Driver, erro
 	if err := mc.ensureVersionTable(); err != nil {
 		return nil, err
 	}
+	if len(mc.config.Comment) > 0 {
+		supported, err := mc.supportsComment()
+		if err != nil {
+			return nil, err
+		}
+		if supported {
+			mc.comment = mc.config.Comment
+		}
+	}
 
 	return mc, nil
 }
 
+// supportsComment returns whether the server supports the comment field
+// for all commands, i.e. is at least version 4.4.
+func (m *Mongo) supportsComment() (bool, error) {
+	var info struct {
+		VersionArray []int32 `bson:"versionArray"`
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), contextWaitTimeout)
+	defer cancel()
+	if err := m.db.RunCommand(ctx, bson.D{{Key: "buildInfo", Value: 1}}).Decode(&info); err != nil {
+		return false, &database.Error{OrigErr: err, Err: "failed to get server version"}
+	}
+	if len(info.VersionArray) < 2 {
+		return false, nil
+	}
+	major, minor := info.VersionArray[0], info.VersionArray[1]
+	return major > 4 || (major == 4 && minor >= 4), nil
+}
+
 func (m *Mongo) Open(dsn string) (database.Driver, error) {
 	//connstring is experimental package, but it used for parse connection string in mongo.Connect function
 	uri, err := connstring.Parse(dsn)
@@ -139,7 +354,25 @@ func (m *Mongo) Open(dsn string) (database.Driver, error) {
 	if err != nil {
 		return nil, err
 	}
-	client, err := mongo.Connect(context.TODO(), options.Client().ApplyURI(dsn))
+	lockOwner := unknown.Get("x-advisory-lock-owner")
+	comment := unknown.Get("x-comment")
+	apiStrict, err := parseBoolean(unknown.Get("x-api-strict"), false)
+	if err != nil {
+		return nil, err
+	}
+	maxStaleness, err := parseInt(unknown.Get("x-max-staleness"), 0)
+	if err != nil {
+		return nil, err
+	}
+	readPref, err := readPreference(unknown.Get("x-read-preference"), time.Duration(maxStaleness)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	clientOptions, err := clientOptions(dsn, unknown.Get("x-compressors"))
+	if err != nil {
+		return nil, err
+	}
+	client, err := mongo.Connect(context.TODO(), clientOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -156,7 +389,13 @@ func (m *Mongo) Open(dsn string) (database.Driver, error) {
 			Timeout:        lockingTimout,
 			Enabled:        advisoryLockingFlag,
 			Interval:       maxLockingIntervals,
+			Owner:          lockOwner,
 		},
+		Comment:         comment,
+		VersionDatabase: unknown.Get("x-version-database"),
+		APIVersion:      unknown.Get("x-api-version"),
+		APIStrict:       apiStrict,
+		ReadPreference:  readPref,
 	})
 	if err != nil {
 		return nil, err
@@ -164,7 +403,63 @@ func (m *Mongo) Open(dsn string) (database.Driver, error) {
 	return mc, nil
 }
 
-//Parse the url param, convert it to boolean
+// clientOptions returns the options to connect to dsn with, compressing
+// the connection with the comma separated compressors, if any.
+func clientOptions(dsn string, compressors string) (*options.ClientOptions, error) {
+	opts := options.Client().ApplyURI(dsn)
+	if compressors == "" {
+		return opts, nil
+	}
+
+	var comps []string
+	for _, comp := range strings.Split(compressors, ",") {
+		comp = strings.TrimSpace(comp)
+		if !supportedCompressors[comp] {
+			return nil, fmt.Errorf("%w: %q", ErrUnsupportedCompressor, comp)
+		}
+		comps = append(comps, comp)
+	}
+	return opts.SetCompressors(comps), nil
+}
+
+// readPreference returns the read preference of read commands with the given
+// mode, e.g. secondaryPreferred, and max staleness. It returns nil if neither
+// is set.
+func readPreference(mode string, maxStaleness time.Duration) (*readpref.ReadPref, error) {
+	if len(mode) == 0 {
+		if maxStaleness > 0 {
+			return nil, fmt.Errorf("%w: requires a read preference other than primary", ErrInvalidMaxStaleness)
+		}
+		return nil, nil
+	}
+	m, err := readpref.ModeFromString(mode)
+	if err != nil {
+		return nil, err
+	}
+	var opts []readpref.Option
+	if maxStaleness > 0 {
+		opts = append(opts, readpref.WithMaxStaleness(maxStaleness))
+	}
+	rp, err := readpref.New(m, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMaxStaleness, err)
+	}
+	if err := checkMaxStaleness(rp); err != nil {
+		return nil, err
+	}
+	return rp, nil
+}
+
+// checkMaxStaleness returns ErrInvalidMaxStaleness if the max staleness of
+// rp is below MinMaxStaleness.
+func checkMaxStaleness(rp *readpref.ReadPref) error {
+	if maxStaleness, ok := rp.MaxStaleness(); ok && maxStaleness < MinMaxStaleness {
+		return fmt.Errorf("%w: %v is below %v", ErrInvalidMaxStaleness, maxStaleness, MinMaxStaleness)
+	}
+	return nil
+}
+
+// Parse the url param, convert it to boolean
 // returns error if param invalid. returns defaultValue if param not present
 func parseBoolean(urlParam string, defaultValue bool) (bool, error) {
 
@@ -181,7 +476,7 @@ func parseBoolean(urlParam string, defaultValue bool) (bool, error) {
 	return defaultValue, nil
 }
 
-//Parse the url param, convert it to int
+// Parse the url param, convert it to int
 // returns error if param invalid. returns defaultValue if param not present
 func parseInt(urlParam string, defaultValue int) (int, error) {
 
@@ -198,7 +493,12 @@ func parseInt(urlParam string, defaultValue int) (int, error) {
 	return defaultValue, nil
 }
 func (m *Mongo) SetVersion(version int, dirty bool) error {
-	migrationsCollection := m.db.Collection(m.config.MigrationsCollection)
+	m.pendingVersion = nil
+	if m.config.TransactionMode && dirty {
+		m.pendingVersion = &version
+	}
+
+	migrationsCollection := m.migrationsCollection()
 	if err := migrationsCollection.Drop(context.TODO()); err != nil {
 		return &database.Error{OrigErr: err, Err: "drop migrations collection failed"}
 	}
@@ -211,7 +511,7 @@ func (m *Mongo) SetVersion(version int, dirty bool) error {
 
 func (m *Mongo) Version() (version int, dirty bool, err error) {
 	var versionInfo versionInfo
-	err = m.db.Collection(m.config.MigrationsCollection).FindOne(context.TODO(), bson.M{}).Decode(&versionInfo)
+	err = m.versionDB.Collection(m.config.MigrationsCollection).FindOne(context.TODO(), bson.M{}).Decode(&versionInfo)
 	switch {
 	case err == mongo.ErrNoDocuments:
 		return database.NilVersion, false, nil
@@ -232,42 +532,205 @@ func (m *Mongo) Run(migration io.Reader) error {
 	if err != nil {
 		return fmt.Errorf("unmarshaling json error: %s", err)
 	}
-	if m.config.TransactionMode {
-		if err := m.executeCommandsWithTransaction(context.TODO(), cmds); err != nil {
-			return err
-		}
-	} else {
-		if err := m.executeCommands(context.TODO(), cmds); err != nil {
-			return err
+	// all commands run in a single causally consistent session, so later
+	// commands observe the writes of earlier ones, even on secondaries
+	opts := options.Session().SetCausalConsistency(true)
+	run := func() error {
+		return m.db.Client().UseSessionWithOptions(context.TODO(), opts, func(sessionContext mongo.SessionContext) error {
+			if m.config.TransactionMode {
+				return m.executeCommandsWithTransaction(sessionContext, cmds)
+			}
+			return m.executeCommands(sessionContext, cmds)
+		})
+	}
+	if m.oplogPositionFunc == nil {
+		return run()
+	}
+
+	before, err := m.OplogPosition()
+	if err != nil {
+		return err
+	}
+	if err := run(); err != nil {
+		return err
+	}
+	after, err := m.OplogPosition()
+	if err != nil {
+		return err
+	}
+	m.oplogPositionFunc(before, after)
+	return nil
+}
+
+// OplogPosition returns the position of the last write in the oplog, e.g.
+// `Timestamp(1621234567, 3)`, which change streams can be started at with
+// startAtOperationTime. Positions of the same replica set are ordered by
+// their seconds and then their increment. Returns ErrNoOplog if the server
+// isn't a member of a replica set.
+func (m *Mongo) OplogPosition() (string, error) {
+	var result struct {
+		LastWrite *struct {
+			OpTime struct {
+				Ts primitive.Timestamp `bson:"ts"`
+			} `bson:"opTime"`
+		} `bson:"lastWrite"`
+	}
+	err := m.client.Database("admin").RunCommand(context.TODO(), bson.D{{Key: "isMaster", Value: 1}}).Decode(&result)
+	if err != nil {
+		return "", &database.Error{OrigErr: err, Err: "reading oplog position failed"}
+	}
+	if result.LastWrite == nil {
+		return "", ErrNoOplog
+	}
+	ts := result.LastWrite.OpTime.Ts
+	return fmt.Sprintf("Timestamp(%d, %d)", ts.T, ts.I), nil
+}
+
+// SetOplogPositionFunc calls fn with the oplog positions before and after
+// each following successful migration, e.g. to log them, so consumers of
+// change streams can reconcile the changes made by the migration. The
+// server must be a member of a replica set, otherwise migrations fail with
+// ErrNoOplog. A nil fn resets it.
+func (m *Mongo) SetOplogPositionFunc(fn OplogPositionFunc) {
+	m.oplogPositionFunc = fn
+}
+
+// Validate parses migration like Run and checks that the first field of each
+// command is a known command, without executing anything. Invalid commands
+// are reported with a CommandError.
+func (m *Mongo) Validate(migration io.Reader) error {
+	migr, err := ioutil.ReadAll(migration)
+	if err != nil {
+		return err
+	}
+	var cmds []bson.D
+	if err := bson.UnmarshalExtJSON(migr, true, &cmds); err != nil {
+		return fmt.Errorf("unmarshaling json error: %s", err)
+	}
+	for i, cmd := range cmds {
+		if err := validateCommand(cmd); err != nil {
+			var cmdErr CommandError
+			if errors.As(err, &cmdErr) {
+				cmdErr.Index = i
+				return cmdErr
+			}
+			return CommandError{Index: i, Field: cmd[0].Key, Err: err}
 		}
 	}
 	return nil
 }
 
-func (m *Mongo) executeCommandsWithTransaction(ctx context.Context, cmds []bson.D) error {
-	err := m.db.Client().UseSession(ctx, func(sessionContext mongo.SessionContext) error {
-		if err := sessionContext.StartTransaction(); err != nil {
-			return &database.Error{OrigErr: err, Err: "failed to start transaction"}
+// validateCommand checks a single command of a migration.
+func validateCommand(cmd bson.D) error {
+	if len(cmd) == 0 {
+		return CommandError{Err: fmt.Errorf("empty command")}
+	}
+	name := cmd[0].Key
+	if !knownCommands[name] {
+		return CommandError{Field: name, Err: ErrUnknownCommand}
+	}
+
+	switch name {
+	case dropIndexKeysCommand:
+		if _, ok := cmd[0].Value.(bson.D); !ok {
+			return fmt.Errorf("%s requires a document", dropIndexKeysCommand)
 		}
-		if err := m.executeCommands(sessionContext, cmds); err != nil {
-			//When command execution is failed, it's aborting transaction
-			//If you tried to call abortTransaction, it`s return error that transaction already aborted
-			return err
+	case bulkWriteCommand:
+		if _, ok := bulkWriteTarget(cmd); !ok {
+			return nil
 		}
-		if err := sessionContext.CommitTransaction(sessionContext); err != nil {
-			return &database.Error{OrigErr: err, Err: "failed to commit transaction"}
+		for _, e := range cmd[1:] {
+			if e.Key != "operations" {
+				continue
+			}
+			operations, ok := e.Value.(bson.A)
+			if !ok {
+				return CommandError{Field: e.Key, Err: fmt.Errorf("operations must be an array")}
+			}
+			ops := make([]bson.D, 0, len(operations))
+			for _, op := range operations {
+				d, ok := op.(bson.D)
+				if !ok {
+					return CommandError{Field: e.Key, Err: fmt.Errorf("operation must be a document, got %v", op)}
+				}
+				ops = append(ops, d)
+			}
+			if _, err := bulkWriteModels(ops); err != nil {
+				return CommandError{Field: e.Key, Err: err}
+			}
+			return nil
 		}
-		return nil
-	})
-	if err != nil {
+		return CommandError{Field: "operations", Err: fmt.Errorf("%s requires operations", bulkWriteCommand)}
+	}
+	return nil
+}
+
+// migrationsCollection returns the collection holding the version. In
+// transaction mode, writes are acknowledged by a majority of the replica set,
+// so a failover can't roll back the version of an applied migration.
+func (m *Mongo) migrationsCollection() *mongo.Collection {
+	if !m.config.TransactionMode {
+		return m.versionDB.Collection(m.config.MigrationsCollection)
+	}
+	opts := options.Collection().SetWriteConcern(writeconcern.New(writeconcern.WMajority()))
+	return m.versionDB.Collection(m.config.MigrationsCollection, opts)
+}
+
+// executeCommandsWithTransaction runs cmds in a transaction committed with
+// majority write concern. If a version has been set before the migration,
+// it's marked clean in the same transaction, so the data and the version
+// are committed, or rolled back, together.
+func (m *Mongo) executeCommandsWithTransaction(sessionContext mongo.SessionContext, cmds []bson.D) error {
+	opts := options.Transaction().SetWriteConcern(writeconcern.New(writeconcern.WMajority()))
+	if err := sessionContext.StartTransaction(opts); err != nil {
+		return &database.Error{OrigErr: err, Err: "failed to start transaction"}
+	}
+	if err := m.executeCommands(sessionContext, cmds); err != nil {
+		//When command execution is failed, it's aborting transaction
+		//If you tried to call abortTransaction, it`s return error that transaction already aborted
 		return err
 	}
+	if m.pendingVersion != nil {
+		// the collection has been created by SetVersion, as it can't be
+		// created within a transaction before MongoDB 4.4
+		doc := bson.M{"version": *m.pendingVersion, "dirty": false}
+		if _, err := m.migrationsCollection().ReplaceOne(sessionContext, bson.M{}, doc, options.Replace().SetUpsert(true)); err != nil {
+			_ = sessionContext.AbortTransaction(sessionContext)
+			return &database.Error{OrigErr: err, Err: "save version failed"}
+		}
+	}
+	if err := sessionContext.CommitTransaction(sessionContext); err != nil {
+		return &database.Error{OrigErr: err, Err: "failed to commit transaction"}
+	}
 	return nil
 }
 
 func (m *Mongo) executeCommands(ctx context.Context, cmds []bson.D) error {
-	for _, cmd := range cmds {
-		err := m.db.RunCommand(ctx, cmd).Err()
+	for i, cmd := range cmds {
+		if len(cmd) > 0 && cmd[0].Key == dropIndexKeysCommand {
+			if err := m.dropIndexKeys(ctx, cmd[0].Value); err != nil {
+				return &database.Error{OrigErr: err, Err: fmt.Sprintf("failed to execute command:%v", cmd)}
+			}
+			continue
+		}
+		if collection, ok := bulkWriteTarget(cmd); ok {
+			if err := m.bulkWrite(ctx, collection, cmd); err != nil {
+				return &database.Error{OrigErr: err, Err: fmt.Sprintf("failed to execute command:%v", cmd)}
+			}
+			continue
+		}
+		if err := m.checkTimeSeries(cmd); err != nil {
+			return &database.Error{OrigErr: err, Err: fmt.Sprintf("failed to execute command:%v", cmd)}
+		}
+		cmd = withComment(cmd, m.comment)
+		if i == 0 || !m.config.TransactionMode {
+			cmd = withAPIVersion(cmd, m.config.APIVersion, m.config.APIStrict)
+		}
+		var opts []*options.RunCmdOptions
+		if m.config.ReadPreference != nil && !m.config.TransactionMode && len(cmd) > 0 && readCommands[cmd[0].Key] {
+			opts = append(opts, options.RunCmd().SetReadPreference(m.config.ReadPreference))
+		}
+		err := m.db.RunCommand(ctx, cmd, opts...).Err()
 		if err != nil {
 			return &database.Error{OrigErr: err, Err: fmt.Sprintf("failed to execute command:%v", cmd)}
 		}
@@ -275,16 +738,276 @@ func (m *Mongo) executeCommands(ctx context.Context, cmds []bson.D) error {
 	return nil
 }
 
+// checkTimeSeries returns ErrTimeSeries if cmd isn't supported by
+// the time-series collection it targets.
+func (m *Mongo) checkTimeSeries(cmd bson.D) error {
+	collection, ok := timeSeriesTarget(cmd)
+	if !ok {
+		return nil
+	}
+
+	// listCollections isn't allowed in transactions, so it's not run in the
+	// session of the migration
+	ctx, cancel := context.WithTimeout(context.Background(), contextWaitTimeout)
+	defer cancel()
+	names, err := m.db.ListCollectionNames(ctx, bson.D{{Key: "name", Value: collection}, {Key: "type", Value: "timeseries"}})
+	if err != nil {
+		return err
+	}
+	if len(names) > 0 {
+		return fmt.Errorf("%w: %s on %s", ErrTimeSeries, cmd[0].Key, collection)
+	}
+	return nil
+}
+
+// timeSeriesTarget returns the collection targeted by cmd,
+// if it is one of timeSeriesUnsupportedCommands.
+func timeSeriesTarget(cmd bson.D) (collection string, ok bool) {
+	if len(cmd) == 0 || !timeSeriesUnsupportedCommands[cmd[0].Key] {
+		return "", false
+	}
+	collection, ok = cmd[0].Value.(string)
+	return collection, ok
+}
+
+// withComment returns cmd with the comment field set to comment, unless
+// comment is empty or cmd already has a comment.
+func withComment(cmd bson.D, comment string) bson.D {
+	if len(comment) == 0 {
+		return cmd
+	}
+	for _, e := range cmd {
+		if e.Key == "comment" {
+			return cmd
+		}
+	}
+	return append(cmd[:len(cmd):len(cmd)], bson.E{Key: "comment", Value: comment})
+}
+
+// withAPIVersion returns cmd declared with the Stable API version, unless
+// version is empty or cmd already declares one.
+func withAPIVersion(cmd bson.D, version string, strict bool) bson.D {
+	if len(version) == 0 {
+		return cmd
+	}
+	for _, e := range cmd {
+		if e.Key == "apiVersion" {
+			return cmd
+		}
+	}
+	cmd = append(cmd[:len(cmd):len(cmd)], bson.E{Key: "apiVersion", Value: version})
+	if strict {
+		cmd = append(cmd, bson.E{Key: "apiStrict", Value: true})
+	}
+	return cmd
+}
+
+// dropIndexKeys drops the index of a collection matching the given keys,
+// resolving its name with listIndexes. spec is the value of a
+// `{"dropIndexKeys": {"collection": "x", "keys": {"field": 1}}}` command.
+func (m *Mongo) dropIndexKeys(ctx context.Context, spec interface{}) error {
+	var args struct {
+		Collection string `bson:"collection"`
+		Keys       bson.D `bson:"keys"`
+	}
+	raw, err := bson.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	if err := bson.Unmarshal(raw, &args); err != nil {
+		return err
+	}
+	if args.Collection == "" || len(args.Keys) == 0 {
+		return fmt.Errorf("%s requires a collection and keys", dropIndexKeysCommand)
+	}
+
+	cursor, err := m.db.Collection(args.Collection).Indexes().List(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	for cursor.Next(ctx) {
+		var index struct {
+			Name string `bson:"name"`
+			Key  bson.D `bson:"key"`
+		}
+		if err := cursor.Decode(&index); err != nil {
+			return err
+		}
+		if equalIndexKeys(index.Key, args.Keys) {
+			cmd := bson.D{{Key: "dropIndexes", Value: args.Collection}, {Key: "index", Value: index.Name}}
+			return m.db.RunCommand(ctx, withComment(cmd, m.comment)).Err()
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("no index with keys %v on collection %s", args.Keys, args.Collection)
+}
+
+// bulkWriteTarget returns the collection of a
+// `{"bulkWrite": "users", "operations": [...], "ordered": true}` command.
+// The bulkWrite command of MongoDB 8.0, whose value is 1, isn't matched
+// and is sent to the server untouched.
+func bulkWriteTarget(cmd bson.D) (collection string, ok bool) {
+	if len(cmd) == 0 || cmd[0].Key != bulkWriteCommand {
+		return "", false
+	}
+	collection, ok = cmd[0].Value.(string)
+	return collection, ok
+}
+
+// bulkWrite runs the operations of a bulkWrite command on collection with a
+// single bulk write, in order and stopping at the first error unless
+// ordered is false. It's only atomic in transaction mode.
+func (m *Mongo) bulkWrite(ctx context.Context, collection string, cmd bson.D) error {
+	var args struct {
+		Operations []bson.D `bson:"operations"`
+		Ordered    *bool    `bson:"ordered"`
+	}
+	raw, err := bson.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+	if err := bson.Unmarshal(raw, &args); err != nil {
+		return err
+	}
+
+	models, err := bulkWriteModels(args.Operations)
+	if err != nil {
+		return err
+	}
+
+	opts := options.BulkWrite()
+	if args.Ordered != nil {
+		opts.SetOrdered(*args.Ordered)
+	}
+	_, err = m.db.Collection(collection).BulkWrite(ctx, models, opts)
+	return err
+}
+
+// bulkWriteModels converts the operations of a bulkWrite command, like
+// `{"updateOne": {"filter": {...}, "update": {...}, "upsert": true}}`,
+// to the write models of the mongo driver.
+func bulkWriteModels(operations []bson.D) ([]mongo.WriteModel, error) {
+	if len(operations) == 0 {
+		return nil, fmt.Errorf("%s requires operations", bulkWriteCommand)
+	}
+
+	models := make([]mongo.WriteModel, 0, len(operations))
+	for _, op := range operations {
+		if len(op) != 1 {
+			return nil, fmt.Errorf("%s operation must have a single key, got %v", bulkWriteCommand, op)
+		}
+
+		var args struct {
+			Document    bson.D `bson:"document"`
+			Filter      bson.D `bson:"filter"`
+			Update      bson.D `bson:"update"`
+			Replacement bson.D `bson:"replacement"`
+			Upsert      bool   `bson:"upsert"`
+		}
+		raw, err := bson.Marshal(op[0].Value)
+		if err != nil {
+			return nil, err
+		}
+		if err := bson.Unmarshal(raw, &args); err != nil {
+			return nil, err
+		}
+		if args.Filter == nil {
+			args.Filter = bson.D{}
+		}
+
+		switch op[0].Key {
+		case "insertOne":
+			models = append(models, mongo.NewInsertOneModel().SetDocument(args.Document))
+		case "updateOne":
+			models = append(models, mongo.NewUpdateOneModel().SetFilter(args.Filter).SetUpdate(args.Update).SetUpsert(args.Upsert))
+		case "updateMany":
+			models = append(models, mongo.NewUpdateManyModel().SetFilter(args.Filter).SetUpdate(args.Update).SetUpsert(args.Upsert))
+		case "replaceOne":
+			models = append(models, mongo.NewReplaceOneModel().SetFilter(args.Filter).SetReplacement(args.Replacement).SetUpsert(args.Upsert))
+		case "deleteOne":
+			models = append(models, mongo.NewDeleteOneModel().SetFilter(args.Filter))
+		case "deleteMany":
+			models = append(models, mongo.NewDeleteManyModel().SetFilter(args.Filter))
+		default:
+			return nil, fmt.Errorf("unknown %s operation %s", bulkWriteCommand, op[0].Key)
+		}
+	}
+	return models, nil
+}
+
+// equalIndexKeys reports whether the index keys a and b are equal.
+// Numeric directions are compared by value, since their type depends
+// on how the index was created.
+func equalIndexKeys(a, b bson.D) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Key != b[i].Key {
+			return false
+		}
+		fa, aok := toFloat(a[i].Value)
+		fb, bok := toFloat(b[i].Value)
+		if aok != bok || (aok && fa != fb) || (!aok && a[i].Value != b[i].Value) {
+			return false
+		}
+	}
+	return true
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
 func (m *Mongo) Close() error {
 	return m.client.Disconnect(context.TODO())
 }
 
+// Drop drops the database migrations are run against, and the migrations
+// collection if it's kept in another database.
 func (m *Mongo) Drop() error {
-	return m.db.Drop(context.TODO())
+	if err := m.db.Drop(context.TODO()); err != nil {
+		return err
+	}
+	if m.config.VersionDatabase != m.config.DatabaseName {
+		return m.versionDB.Collection(m.config.MigrationsCollection).Drop(context.TODO())
+	}
+	return nil
+}
+
+// DropVersion implements database.VersionDropper. It drops the migrations
+// and locking collections, leaving the collections of the database intact.
+// The unique index of the locking collection is created again, so locking
+// keeps working. A lock held while dropping is released.
+func (m *Mongo) DropVersion() error {
+	if err := m.versionDB.Collection(m.config.MigrationsCollection).Drop(context.TODO()); err != nil {
+		return &database.Error{OrigErr: err, Err: "failed to drop migrations collection"}
+	}
+	if !m.config.Locking.Enabled {
+		return nil
+	}
+	if err := m.versionDB.Collection(m.config.Locking.CollectionName).Drop(context.TODO()); err != nil {
+		return &database.Error{OrigErr: err, Err: "failed to drop locking collection"}
+	}
+	return m.ensureLockTable()
 }
 
 func (m *Mongo) ensureLockTable() error {
-	indexes := m.db.Collection(m.config.Locking.CollectionName).Indexes()
+	indexes := m.versionDB.Collection(m.config.Locking.CollectionName).Indexes()
 
 	indexOptions := options.Index().SetUnique(true).SetName(LockIndexName)
 	_, err := indexes.CreateOne(context.TODO(), mongo.IndexModel{
@@ -340,11 +1063,12 @@ func (m *Mongo) Lock() error {
 		Key:       lockKeyUniqueValue,
 		Pid:       pid,
 		Hostname:  hostname,
+		Owner:     m.config.Locking.Owner,
 		CreatedAt: time.Now(),
 	}
 	operation := func() error {
 		timeout, cancelFunc := context.WithTimeout(context.Background(), contextWaitTimeout)
-		_, err := m.db.Collection(m.config.Locking.CollectionName).InsertOne(timeout, newLockObj)
+		_, err := m.versionDB.Collection(m.config.Locking.CollectionName).InsertOne(timeout, newLockObj)
 		defer cancelFunc()
 		return err
 	}
@@ -355,12 +1079,35 @@ func (m *Mongo) Lock() error {
 
 	err = backoff.Retry(operation, exponentialBackOff)
 	if err != nil {
-		return database.ErrLocked
+		return m.lockedErr()
 	}
 
 	return nil
 
 }
+
+// lockedErr returns database.ErrLocked, describing the current holder
+// of the lock if its lock document can be read.
+func (m *Mongo) lockedErr() error {
+	ctx, cancel := context.WithTimeout(context.Background(), contextWaitTimeout)
+	defer cancel()
+
+	var holder lockObj
+	filter := findFilter{Key: lockKeyUniqueValue}
+	if err := m.versionDB.Collection(m.config.Locking.CollectionName).FindOne(ctx, filter).Decode(&holder); err != nil {
+		return database.ErrLocked
+	}
+	return fmt.Errorf("%w: held by %v", database.ErrLocked, holder)
+}
+
+// String describes the holder of the lock.
+func (l lockObj) String() string {
+	s := fmt.Sprintf("host=%s pid=%d", l.Hostname, l.Pid)
+	if l.Owner != "" {
+		s += fmt.Sprintf(" owner=%s", l.Owner)
+	}
+	return s + fmt.Sprintf(" since=%s", l.CreatedAt.Format(time.RFC3339))
+}
 func (m *Mongo) Unlock() error {
 	if !m.config.Locking.Enabled {
 		return nil
@@ -371,7 +1118,7 @@ func (m *Mongo) Unlock() error {
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), contextWaitTimeout)
-	_, err := m.db.Collection(m.config.Locking.CollectionName).DeleteMany(ctx, filter)
+	_, err := m.versionDB.Collection(m.config.Locking.CollectionName).DeleteMany(ctx, filter)
 	defer cancel()
 
 	if err != nil {
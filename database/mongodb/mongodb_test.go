@@ -3,6 +3,7 @@ package mongodb
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 
 	"log"
@@ -11,6 +12,7 @@ import (
 	"io"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -20,9 +22,11 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 import (
+	"github.com/golang-migrate/migrate/v4/database"
 	dt "github.com/golang-migrate/migrate/v4/database/testing"
 	"github.com/golang-migrate/migrate/v4/dktesting"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
@@ -247,6 +251,783 @@ func TestLockWorks(t *testing.T) {
 	})
 }
 
+func TestLockOwner(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.FirstPort()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := mongoConnectionString(ip, port)
+		p := &Mongo{}
+		d, err := p.Open(addr + "&x-advisory-lock-owner=deployer")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		mc := d.(*Mongo)
+		if err := mc.Lock(); err != nil {
+			t.Fatal(err)
+		}
+
+		mc.config.Locking.Timeout = 1
+		err = mc.Lock()
+		if !errors.Is(err, database.ErrLocked) {
+			t.Fatalf("expected database.ErrLocked, got %v", err)
+		}
+		hostname, _ := os.Hostname()
+		for _, holder := range []string{"host=" + hostname, fmt.Sprintf("pid=%d", os.Getpid()), "owner=deployer"} {
+			if !strings.Contains(err.Error(), holder) {
+				t.Errorf("expected error to contain %q, got %v", holder, err)
+			}
+		}
+
+		if err := mc.Unlock(); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestCurrentDate(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.FirstPort()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := mongoConnectionString(ip, port)
+		p := &Mongo{}
+		d, err := p.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		before := time.Now().Add(-time.Minute)
+		dt.TestRun(t, d, bytes.NewReader([]byte(`[
+				{"insert":"users","documents":[{"name":"gopher"}]},
+				{"update":"users","updates":[{"q":{"name":"gopher"},"u":{"$currentDate":{"updatedAt":true}}}]}
+			]`)))
+
+		var user struct {
+			UpdatedAt time.Time `bson:"updatedAt"`
+		}
+		mc := d.(*Mongo)
+		if err := mc.db.Collection("users").FindOne(context.TODO(), bson.M{"name": "gopher"}).Decode(&user); err != nil {
+			t.Fatal(err)
+		}
+		if user.UpdatedAt.Before(before) {
+			t.Fatalf("expected updatedAt to be set to the server time, got %v", user.UpdatedAt)
+		}
+	})
+}
+
+func TestCausalConsistency(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.FirstPort()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := mongoConnectionString(ip, port)
+		p := &Mongo{}
+		d, err := p.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		// the aggregation must see the documents inserted before
+		// in the same migration
+		dt.TestRun(t, d, bytes.NewReader([]byte(`[
+				{"insert":"accounts","documents":[{"name":"gopher","active":true},{"name":"ferris","active":false}]},
+				{"aggregate":"accounts","pipeline":[{"$match":{"active":true}},{"$out":"active_accounts"}],"cursor":{}},
+				{"update":"active_accounts","updates":[{"q":{"name":"gopher"},"u":{"$set":{"migrated":true}}}]}
+			]`)))
+
+		mc := d.(*Mongo)
+		n, err := mc.db.Collection("active_accounts").CountDocuments(context.TODO(), bson.M{"migrated": true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 1 {
+			t.Fatalf("expected 1 migrated account, got %v", n)
+		}
+	})
+}
+
+func TestDropIndexKeys(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.FirstPort()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := mongoConnectionString(ip, port)
+		p := &Mongo{}
+		d, err := p.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		dt.TestRun(t, d, bytes.NewReader([]byte(`[
+				{"createIndexes": "users", "indexes": [
+					{"key": {"email": 1}, "name": "users_email"},
+					{"key": {"email": 1, "name": -1}, "name": "users_email_name"}
+				]}
+			]`)))
+		dt.TestRun(t, d, bytes.NewReader([]byte(`[{"dropIndexKeys": {"collection": "users", "keys": {"email": 1}}}]`)))
+
+		cursor, err := d.(*Mongo).db.Collection("users").Indexes().List(context.TODO())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var indexes []struct {
+			Name string `bson:"name"`
+		}
+		if err := cursor.All(context.TODO(), &indexes); err != nil {
+			t.Fatal(err)
+		}
+		names := make([]string, 0, len(indexes))
+		for _, index := range indexes {
+			names = append(names, index.Name)
+		}
+		if strings.Join(names, ",") != "_id_,users_email_name" {
+			t.Errorf("expected only users_email to be dropped, got indexes %v", names)
+		}
+
+		err = d.Run(bytes.NewReader([]byte(`[{"dropIndexKeys": {"collection": "users", "keys": {"email": 1}}}]`)))
+		if err == nil {
+			t.Error("expected error dropping a missing index")
+		}
+	})
+}
+
+func TestCollationMarshaling(t *testing.T) {
+	var cmds []bson.D
+	migr := `[{"createIndexes": "users", "indexes": [
+		{"key": {"email": 1}, "name": "users_email_ci", "unique": true, "collation": {"locale": "en", "strength": 2}}
+	]}]`
+	if err := bson.UnmarshalExtJSON([]byte(migr), true, &cmds); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := bson.Marshal(withComment(cmds[0], "migrate"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cmd struct {
+		Indexes []struct {
+			Collation bson.D `bson:"collation"`
+		} `bson:"indexes"`
+	}
+	if err := bson.Unmarshal(raw, &cmd); err != nil {
+		t.Fatal(err)
+	}
+	if len(cmd.Indexes) != 1 {
+		t.Fatalf("expected 1 index, got %v", len(cmd.Indexes))
+	}
+	collation := cmd.Indexes[0].Collation
+	if len(collation) != 2 || collation[0].Key != "locale" || collation[0].Value != "en" ||
+		collation[1].Key != "strength" || collation[1].Value != int32(2) {
+		t.Errorf("expected collation {locale: en, strength: 2}, got %v", collation)
+	}
+}
+
+func TestCollation(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.FirstPort()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := mongoConnectionString(ip, port)
+		p := &Mongo{}
+		d, err := p.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		dt.TestRun(t, d, bytes.NewReader([]byte(`[
+				{"createIndexes": "users", "indexes": [
+					{"key": {"email": 1}, "name": "users_email_ci", "unique": true, "collation": {"locale": "en", "strength": 2}}
+				]}
+			]`)))
+
+		users := d.(*Mongo).db.Collection("users")
+		if _, err := users.InsertOne(context.TODO(), bson.M{"email": "gopher@example.com"}); err != nil {
+			t.Fatal(err)
+		}
+		_, err = users.InsertOne(context.TODO(), bson.M{"email": "Gopher@Example.com"})
+		if err == nil || !strings.Contains(err.Error(), "duplicate key") {
+			t.Errorf("expected duplicate key error for emails differing by case, got %v", err)
+		}
+	})
+}
+
+func TestBulkWrite(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.FirstPort()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := mongoConnectionString(ip, port)
+		p := &Mongo{}
+		d, err := p.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		dt.TestRun(t, d, bytes.NewReader([]byte(`[
+				{"bulkWrite": "users", "operations": [
+					{"insertOne": {"document": {"_id": 1, "name": "gopher"}}},
+					{"insertOne": {"document": {"_id": 2, "name": "rustacean"}}},
+					{"insertOne": {"document": {"_id": 3, "name": "crab"}}},
+					{"updateOne": {"filter": {"_id": 1}, "update": {"$set": {"name": "Gopher"}}}},
+					{"deleteOne": {"filter": {"_id": 3}}}
+				]}
+			]`)))
+
+		// unordered operations continue after the duplicate key error
+		err = d.Run(bytes.NewReader([]byte(`[
+				{"bulkWrite": "users", "ordered": false, "operations": [
+					{"insertOne": {"document": {"_id": 1, "name": "duplicate"}}},
+					{"insertOne": {"document": {"_id": 4, "name": "pythonista"}}}
+				]}
+			]`)))
+		if err == nil {
+			t.Error("expected duplicate key error")
+		}
+
+		cursor, err := d.(*Mongo).db.Collection("users").Find(context.TODO(), bson.D{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var users []struct {
+			ID   int    `bson:"_id"`
+			Name string `bson:"name"`
+		}
+		if err := cursor.All(context.TODO(), &users); err != nil {
+			t.Fatal(err)
+		}
+		names := make([]string, 0, len(users))
+		for _, u := range users {
+			names = append(names, fmt.Sprintf("%d:%s", u.ID, u.Name))
+		}
+		if strings.Join(names, ",") != "1:Gopher,2:rustacean,4:pythonista" {
+			t.Errorf("unexpected users %v", names)
+		}
+	})
+}
+
+func TestBulkWriteModels(t *testing.T) {
+	var cmds []bson.D
+	migr := `[{"bulkWrite": "users", "operations": [
+		{"insertOne": {"document": {"name": "gopher"}}},
+		{"updateMany": {"filter": {"name": "gopher"}, "update": {"$set": {"active": true}}, "upsert": true}},
+		{"replaceOne": {"filter": {"name": "gopher"}, "replacement": {"name": "Gopher"}}},
+		{"deleteMany": {}}
+	]}]`
+	if err := bson.UnmarshalExtJSON([]byte(migr), true, &cmds); err != nil {
+		t.Fatal(err)
+	}
+
+	collection, ok := bulkWriteTarget(cmds[0])
+	if !ok || collection != "users" {
+		t.Fatalf("expected bulkWrite on users, got %q (%v)", collection, ok)
+	}
+	if _, ok := bulkWriteTarget(bson.D{{Key: "bulkWrite", Value: int32(1)}}); ok {
+		t.Error("expected the server's bulkWrite command not to be matched")
+	}
+
+	var args struct {
+		Operations []bson.D `bson:"operations"`
+	}
+	raw, err := bson.Marshal(cmds[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bson.Unmarshal(raw, &args); err != nil {
+		t.Fatal(err)
+	}
+	models, err := bulkWriteModels(args.Operations)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := make([]string, 0, len(models))
+	for _, m := range models {
+		types = append(types, fmt.Sprintf("%T", m))
+	}
+	expected := "*mongo.InsertOneModel,*mongo.UpdateManyModel,*mongo.ReplaceOneModel,*mongo.DeleteManyModel"
+	if strings.Join(types, ",") != expected {
+		t.Errorf("expected models %v, got %v", expected, types)
+	}
+	if upsert := models[1].(*mongo.UpdateManyModel).Upsert; upsert == nil || !*upsert {
+		t.Error("expected upsert to be set")
+	}
+	if filter, ok := models[3].(*mongo.DeleteManyModel).Filter.(bson.D); !ok || len(filter) != 0 {
+		t.Errorf("expected empty filter, got %v", models[3].(*mongo.DeleteManyModel).Filter)
+	}
+
+	if _, err := bulkWriteModels([]bson.D{{{Key: "upsertOne", Value: bson.D{}}}}); err == nil {
+		t.Error("expected error for unknown operation")
+	}
+	if _, err := bulkWriteModels(nil); err == nil {
+		t.Error("expected error without operations")
+	}
+}
+
+func TestEqualIndexKeys(t *testing.T) {
+	testcases := []struct {
+		name  string
+		a, b  bson.D
+		equal bool
+	}{
+		{name: "int32 and float64", a: bson.D{{Key: "a", Value: int32(1)}}, b: bson.D{{Key: "a", Value: 1.0}}, equal: true},
+		{name: "int64 and int32", a: bson.D{{Key: "a", Value: int64(-1)}}, b: bson.D{{Key: "a", Value: int32(-1)}}, equal: true},
+		{name: "direction", a: bson.D{{Key: "a", Value: int32(1)}}, b: bson.D{{Key: "a", Value: int32(-1)}}},
+		{name: "order", a: bson.D{{Key: "a", Value: int32(1)}, {Key: "b", Value: int32(1)}}, b: bson.D{{Key: "b", Value: int32(1)}, {Key: "a", Value: int32(1)}}},
+		{name: "prefix", a: bson.D{{Key: "a", Value: int32(1)}}, b: bson.D{{Key: "a", Value: int32(1)}, {Key: "b", Value: int32(1)}}},
+		{name: "text", a: bson.D{{Key: "a", Value: "text"}}, b: bson.D{{Key: "a", Value: "text"}}, equal: true},
+		{name: "text and number", a: bson.D{{Key: "a", Value: "text"}}, b: bson.D{{Key: "a", Value: int32(1)}}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if equal := equalIndexKeys(tc.a, tc.b); equal != tc.equal {
+				t.Errorf("expected %v, got %v", tc.equal, equal)
+			}
+		})
+	}
+}
+
+func TestComment(t *testing.T) {
+	commentSpecs := []dktesting.ContainerSpec{
+		{ImageName: "mongo:4.2", Options: opts},
+		{ImageName: "mongo:4.4", Options: opts},
+	}
+	dktesting.ParallelTest(t, commentSpecs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.FirstPort()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := mongoConnectionString(ip, port) + "&x-comment=migrate-test"
+		p := &Mongo{}
+		d, err := p.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		mc := d.(*Mongo)
+		if err := mc.db.RunCommand(context.TODO(), bson.D{{Key: "profile", Value: 2}}).Err(); err != nil {
+			t.Fatal(err)
+		}
+		dt.TestRun(t, d, bytes.NewReader([]byte(`[{"insert":"users","documents":[{"name":"gopher"}]}]`)))
+
+		if mc.comment == "" {
+			// the comment is skipped on servers not supporting it
+			return
+		}
+		count, err := mc.db.Collection("system.profile").CountDocuments(context.TODO(), bson.M{"command.comment": "migrate-test"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count == 0 {
+			t.Error("expected the comment to be attached to the executed commands")
+		}
+	})
+}
+
+func TestStableAPI(t *testing.T) {
+	apiSpecs := []dktesting.ContainerSpec{
+		{ImageName: "mongo:5.0", Options: opts},
+	}
+	dktesting.ParallelTest(t, apiSpecs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.FirstPort()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := mongoConnectionString(ip, port) + "&x-api-version=1&x-api-strict=true"
+		p := &Mongo{}
+		d, err := p.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		dt.TestRun(t, d, bytes.NewReader([]byte(`[{"insert":"users","documents":[{"name":"gopher"}]}]`)))
+
+		// validate isn't part of API version 1
+		if err := d.Run(bytes.NewReader([]byte(`[{"validate":"users"}]`))); err == nil {
+			t.Error("expected a command outside of the API version to be rejected")
+		}
+	})
+}
+
+func TestWithComment(t *testing.T) {
+	testcases := []struct {
+		name     string
+		cmd      bson.D
+		comment  string
+		expected bson.D
+	}{
+		{
+			name:     "comment",
+			cmd:      bson.D{{Key: "insert", Value: "users"}},
+			comment:  "migrate",
+			expected: bson.D{{Key: "insert", Value: "users"}, {Key: "comment", Value: "migrate"}},
+		},
+		{
+			name:     "no comment",
+			cmd:      bson.D{{Key: "insert", Value: "users"}},
+			expected: bson.D{{Key: "insert", Value: "users"}},
+		},
+		{
+			name:     "comment of command",
+			cmd:      bson.D{{Key: "insert", Value: "users"}, {Key: "comment", Value: "backfill"}},
+			comment:  "migrate",
+			expected: bson.D{{Key: "insert", Value: "users"}, {Key: "comment", Value: "backfill"}},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := withComment(tc.cmd, tc.comment); fmt.Sprint(got) != fmt.Sprint(tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestWithAPIVersion(t *testing.T) {
+	testcases := []struct {
+		name     string
+		cmd      bson.D
+		version  string
+		strict   bool
+		expected bson.D
+	}{
+		{
+			name:     "version",
+			cmd:      bson.D{{Key: "insert", Value: "users"}},
+			version:  "1",
+			expected: bson.D{{Key: "insert", Value: "users"}, {Key: "apiVersion", Value: "1"}},
+		},
+		{
+			name:     "strict",
+			cmd:      bson.D{{Key: "insert", Value: "users"}},
+			version:  "1",
+			strict:   true,
+			expected: bson.D{{Key: "insert", Value: "users"}, {Key: "apiVersion", Value: "1"}, {Key: "apiStrict", Value: true}},
+		},
+		{
+			name:     "no version",
+			cmd:      bson.D{{Key: "insert", Value: "users"}},
+			expected: bson.D{{Key: "insert", Value: "users"}},
+		},
+		{
+			name:     "version of command",
+			cmd:      bson.D{{Key: "insert", Value: "users"}, {Key: "apiVersion", Value: "2"}},
+			version:  "1",
+			strict:   true,
+			expected: bson.D{{Key: "insert", Value: "users"}, {Key: "apiVersion", Value: "2"}},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := withAPIVersion(tc.cmd, tc.version, tc.strict); fmt.Sprint(got) != fmt.Sprint(tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestClientOptions(t *testing.T) {
+	testcases := []struct {
+		name        string
+		compressors string
+		expected    []string
+		err         error
+	}{
+		{name: "no compressors"},
+		{name: "single", compressors: "snappy", expected: []string{"snappy"}},
+		{name: "list", compressors: "zlib, snappy", expected: []string{"zlib", "snappy"}},
+		{name: "unsupported", compressors: "snappy,lz4", err: ErrUnsupportedCompressor},
+		{name: "empty entry", compressors: "snappy,", err: ErrUnsupportedCompressor},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts, err := clientOptions("mongodb://localhost:27017/testMigration", tc.compressors)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected error %v, got %v", tc.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if fmt.Sprint(opts.Compressors) != fmt.Sprint(tc.expected) {
+				t.Errorf("expected compressors %v, got %v", tc.expected, opts.Compressors)
+			}
+		})
+	}
+}
+
+func TestCompressors(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.FirstPort()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := mongoConnectionString(ip, port) + "&x-compressors=snappy,zlib"
+		p := &Mongo{}
+		d, err := p.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+		dt.TestNilVersion(t, d)
+		dt.TestSetVersion(t, d)
+	})
+}
+
+func TestVersionDatabase(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.FirstPort()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := fmt.Sprintf("mongodb://%s:%s/admin?connect=direct&x-version-database=testMigration", ip, port)
+		p := &Mongo{}
+		d, err := p.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		if err := d.Lock(); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.Run(bytes.NewReader([]byte(`[{"createUser":"migrator","pwd":"secret","roles":[]}]`))); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.SetVersion(1, false); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.Unlock(); err != nil {
+			t.Fatal(err)
+		}
+
+		client := d.(*Mongo).client
+		var user bson.M
+		if err := client.Database("admin").Collection("system.users").FindOne(context.TODO(), bson.M{"user": "migrator"}).Decode(&user); err != nil {
+			t.Errorf("expected user to be created in the admin database: %v", err)
+		}
+		count, err := client.Database("testMigration").Collection(DefaultMigrationsCollection).CountDocuments(context.TODO(), bson.M{"version": 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Errorf("expected the version in the version database, got %v documents", count)
+		}
+		count, err = client.Database("admin").Collection(DefaultMigrationsCollection).CountDocuments(context.TODO(), bson.M{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Errorf("expected no version in the admin database, got %v documents", count)
+		}
+	})
+}
+
+func TestDropVersion(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.FirstPort()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := mongoConnectionString(ip, port)
+		p := &Mongo{}
+		d, err := p.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		if err := d.Run(bytes.NewReader([]byte(`[{"insert":"hello","documents":[{"wild":"world"}]}]`))); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.SetVersion(1, false); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.Lock(); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := d.(*Mongo).DropVersion(); err != nil {
+			t.Fatal(err)
+		}
+		if v, dirty, err := d.Version(); err != nil {
+			t.Fatal(err)
+		} else if v != database.NilVersion || dirty {
+			t.Errorf("expected no version, got %v (dirty %v)", v, dirty)
+		}
+
+		db := d.(*Mongo).db
+		names, err := db.ListCollectionNames(context.TODO(), bson.M{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range names {
+			if name == DefaultMigrationsCollection {
+				t.Errorf("expected migrations collection to be dropped")
+			}
+		}
+		count, err := db.Collection("hello").CountDocuments(context.TODO(), bson.M{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Errorf("expected user data to be kept, got %v documents", count)
+		}
+
+		// the lock is released, and locking works again
+		if err := d.Lock(); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.Unlock(); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestTimeSeries(t *testing.T) {
+	timeSeriesSpecs := []dktesting.ContainerSpec{
+		{ImageName: "mongo:5.0", Options: opts},
+	}
+	dktesting.ParallelTest(t, timeSeriesSpecs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.FirstPort()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := mongoConnectionString(ip, port)
+		p := &Mongo{}
+		d, err := p.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		dt.TestRun(t, d, bytes.NewReader([]byte(`[
+				{"create": "weather", "timeseries": {"timeField": "timestamp", "metaField": "sensor", "granularity": "hours"}},
+				{"insert": "weather", "documents": [
+					{"sensor": 1, "timestamp": {"$date": "2021-05-18T00:00:00Z"}, "temp": 12},
+					{"sensor": 1, "timestamp": {"$date": "2021-05-18T01:00:00Z"}, "temp": 11}
+				]}
+			]`)))
+
+		mc := d.(*Mongo)
+		names, err := mc.db.ListCollectionNames(context.TODO(), bson.M{"name": "weather", "type": "timeseries"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(names) != 1 {
+			t.Fatal("expected weather to be a time-series collection")
+		}
+		count, err := mc.db.Collection("weather").CountDocuments(context.TODO(), bson.M{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 2 {
+			t.Errorf("expected 2 measurements, got %v", count)
+		}
+
+		err = d.Run(bytes.NewReader([]byte(`[{"update": "weather", "updates": [{"q": {}, "u": {"$set": {"temp": 0}}, "multi": true}]}]`)))
+		if !errors.Is(err, ErrTimeSeries) {
+			t.Errorf("expected ErrTimeSeries, got %v", err)
+		}
+	})
+}
+
+func TestTimeSeriesTarget(t *testing.T) {
+	testcases := []struct {
+		name       string
+		cmd        bson.D
+		collection string
+		ok         bool
+	}{
+		{name: "update", cmd: bson.D{{Key: "update", Value: "weather"}}, collection: "weather", ok: true},
+		{name: "findAndModify", cmd: bson.D{{Key: "findAndModify", Value: "weather"}}, collection: "weather", ok: true},
+		{name: "insert", cmd: bson.D{{Key: "insert", Value: "weather"}}},
+		{name: "empty", cmd: bson.D{}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			collection, ok := timeSeriesTarget(tc.cmd)
+			if collection != tc.collection || ok != tc.ok {
+				t.Errorf("expected %q (%v), got %q (%v)", tc.collection, tc.ok, collection, ok)
+			}
+		})
+	}
+}
+
 func TestTransaction(t *testing.T) {
 	transactionSpecs := []dktesting.ContainerSpec{
 		{ImageName: "mongo:4", Options: dktest.Options{PortRequired: true, ReadyFunc: isReady,
@@ -376,6 +1157,82 @@ func TestTransaction(t *testing.T) {
 	})
 }
 
+func TestTransactionVersion(t *testing.T) {
+	transactionSpecs := []dktesting.ContainerSpec{
+		{ImageName: "mongo:4", Options: dktest.Options{PortRequired: true, ReadyFunc: isReady,
+			Cmd: []string{"mongod", "--bind_ip_all", "--replSet", "rs0"}}},
+	}
+	dktesting.ParallelTest(t, transactionSpecs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.FirstPort()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		client, err := mongo.Connect(context.TODO(), options.Client().ApplyURI(mongoConnectionString(ip, port)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = client.Database("admin").RunCommand(context.TODO(), bson.D{bson.E{Key: "replSetInitiate", Value: bson.D{}}}).Err()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = waitForReplicaInit(client)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := WithInstance(client, &Config{
+			DatabaseName:    "testMigration",
+			TransactionMode: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+		// collections can't be created within transactions
+		if err := client.Database("testMigration").RunCommand(context.TODO(), bson.D{{Key: "create", Value: "hello"}}).Err(); err != nil {
+			t.Fatal(err)
+		}
+
+		// the version is committed with the data of the migration
+		if err := d.SetVersion(1, true); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.Run(bytes.NewReader([]byte(`[{"insert":"hello","documents":[{"wild":"world"}]}]`))); err != nil {
+			t.Fatal(err)
+		}
+		if v, dirty, err := d.Version(); err != nil {
+			t.Fatal(err)
+		} else if v != 1 || dirty {
+			t.Errorf("expected clean version 1 after the transaction, got %v (dirty %v)", v, dirty)
+		}
+
+		// neither the version nor the data of a failing migration are committed
+		if err := d.SetVersion(2, true); err != nil {
+			t.Fatal(err)
+		}
+		err = d.Run(bytes.NewReader([]byte(`[{"insert":"hello","documents":[{"wild":"west"}]},{"unknownCommand":"hello"}]`)))
+		if err == nil {
+			t.Fatal("expected error for a failing migration")
+		}
+		if v, dirty, err := d.Version(); err != nil {
+			t.Fatal(err)
+		} else if v != 2 || !dirty {
+			t.Errorf("expected dirty version 2 after the aborted transaction, got %v (dirty %v)", v, dirty)
+		}
+		count, err := client.Database("testMigration").Collection("hello").CountDocuments(context.TODO(), bson.M{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Errorf("expected 1 document after the aborted transaction, got %d", count)
+		}
+	})
+}
+
 type isMaster struct {
 	IsMaster bool `bson:"ismaster"`
 }
@@ -414,3 +1271,136 @@ func waitForReplicaInit(client *mongo.Client) error {
 	}
 
 }
+
+func TestOplogPosition(t *testing.T) {
+	replicaSetSpecs := []dktesting.ContainerSpec{
+		{ImageName: "mongo:4", Options: dktest.Options{PortRequired: true, ReadyFunc: isReady,
+			Cmd: []string{"mongod", "--bind_ip_all", "--replSet", "rs0"}}},
+	}
+	dktesting.ParallelTest(t, replicaSetSpecs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.FirstPort()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		client, err := mongo.Connect(context.TODO(), options.Client().ApplyURI(mongoConnectionString(ip, port)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = client.Database("admin").RunCommand(context.TODO(), bson.D{bson.E{Key: "replSetInitiate", Value: bson.D{}}}).Err()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = waitForReplicaInit(client)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := WithInstance(client, &Config{DatabaseName: "testMigration"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		var before, after string
+		d.(*Mongo).SetOplogPositionFunc(func(b, a string) { before, after = b, a })
+		if err := d.Run(bytes.NewReader([]byte(`[{"insert":"hello","documents":[{"wild":"world"}]}]`))); err != nil {
+			t.Fatal(err)
+		}
+
+		var beforeT, beforeI, afterT, afterI uint32
+		if _, err := fmt.Sscanf(before, "Timestamp(%d, %d)", &beforeT, &beforeI); err != nil {
+			t.Fatalf("expected position before the migration, got %q: %v", before, err)
+		}
+		if _, err := fmt.Sscanf(after, "Timestamp(%d, %d)", &afterT, &afterI); err != nil {
+			t.Fatalf("expected position after the migration, got %q: %v", after, err)
+		}
+		if afterT < beforeT || (afterT == beforeT && afterI <= beforeI) {
+			t.Errorf("expected position after the migration to follow %v, got %v", before, after)
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	m := &Mongo{}
+
+	valid := `[
+		{"create": "users"},
+		{"createIndexes": "users", "indexes": [{"key": {"email": 1}, "name": "users_email"}]},
+		{"dropIndexKeys": {"collection": "users", "keys": {"email": 1}}},
+		{"bulkWrite": "users", "operations": [{"insertOne": {"document": {"name": "gopher"}}}]},
+		{"bulkWrite": 1, "ops": [], "nsInfo": []}
+	]`
+	if err := m.Validate(strings.NewReader(valid)); err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		name  string
+		migr  string
+		index int
+		field string
+	}{
+		{name: "unknown command", migr: `[{"create": "users"}, {"users": "create"}]`, index: 1, field: "users"},
+		{name: "empty command", migr: `[{}]`, index: 0},
+		{name: "unknown operation", migr: `[{"bulkWrite": "users", "operations": [{"upsertOne": {}}]}]`, index: 0, field: "operations"},
+		{name: "missing operations", migr: `[{"drop": "users"}, {"bulkWrite": "users"}]`, index: 1, field: "operations"},
+		{name: "invalid dropIndexKeys", migr: `[{"dropIndexKeys": "users"}]`, index: 0, field: "dropIndexKeys"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := m.Validate(strings.NewReader(tc.migr))
+			var cmdErr CommandError
+			if !errors.As(err, &cmdErr) {
+				t.Fatalf("expected CommandError, got %v", err)
+			}
+			if cmdErr.Index != tc.index || cmdErr.Field != tc.field {
+				t.Errorf("expected command %d field %q, got %v", tc.index, tc.field, err)
+			}
+		})
+	}
+
+	if err := m.Validate(strings.NewReader(`[{"create": "users"}, {"users": "create"}]`)); !errors.Is(err, ErrUnknownCommand) {
+		t.Errorf("expected ErrUnknownCommand, got %v", err)
+	}
+	if err := m.Validate(strings.NewReader(`{"create": "users"}`)); err == nil {
+		t.Error("expected error for a migration that isn't an array")
+	}
+}
+
+func TestReadPreference(t *testing.T) {
+	rp, err := readPreference("secondaryPreferred", 120*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rp.Mode() != readpref.SecondaryPreferredMode {
+		t.Errorf("expected mode secondaryPreferred, got %v", rp.Mode())
+	}
+	if maxStaleness, ok := rp.MaxStaleness(); !ok || maxStaleness != 120*time.Second {
+		t.Errorf("expected max staleness 2m0s, got %v (set: %v)", maxStaleness, ok)
+	}
+
+	if rp, err := readPreference("", 0); err != nil || rp != nil {
+		t.Errorf("expected no read preference, got %v (%v)", rp, err)
+	}
+
+	testcases := []struct {
+		name         string
+		mode         string
+		maxStaleness time.Duration
+	}{
+		{name: "below minimum", mode: "nearest", maxStaleness: 89 * time.Second},
+		{name: "primary", mode: "primary", maxStaleness: 90 * time.Second},
+		{name: "no mode", maxStaleness: 90 * time.Second},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := readPreference(tc.mode, tc.maxStaleness); !errors.Is(err, ErrInvalidMaxStaleness) {
+				t.Errorf("expected ErrInvalidMaxStaleness, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+// +build go1.9
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// alterTableRegex matches ALTER TABLE statements without leading comments.
+var alterTableRegex = regexp.MustCompile(`(?is)^ALTER\s+(?:ONLINE\s+|IGNORE\s+)?TABLE\s`)
+
+// AlterExecutor runs a single ALTER TABLE statement of a migration,
+// e.g. by shelling out to an online schema change tool like gh-ost or
+// pt-online-schema-change. See SetAlterExecutor.
+type AlterExecutor func(statement string) error
+
+// SetAlterExecutor delegates the ALTER TABLE statements of the following
+// migrations to fn instead of running them directly. The statements of
+// migrations containing ALTER TABLE are run one by one, in order, so the
+// ones before an ALTER TABLE have completed when fn is called. fn gets the
+// statement without leading comments and the trailing semicolon.
+// Migrations without ALTER TABLE are run as usual. A nil fn resets it.
+func (m *Mysql) SetAlterExecutor(fn AlterExecutor) {
+	m.alterExecutor = fn
+}
+
+// runDelegatingAlters runs statements on conn, one by one, except for
+// ALTER TABLE statements, which are passed to m.alterExecutor.
+func (m *Mysql) runDelegatingAlters(ctx context.Context, conn *sql.Conn, statements []string) error {
+	for _, statement := range statements {
+		if isAlterTable(statement) {
+			if err := m.alterExecutor(stripComments(statement)); err != nil {
+				return database.Error{OrigErr: err, Err: "alter executor failed", Query: []byte(statement)}
+			}
+			continue
+		}
+		if err := execDrained(ctx, conn, statement); err != nil {
+			return database.Error{OrigErr: wrapErr(err), Err: "migration failed", Query: []byte(statement)}
+		}
+	}
+	return nil
+}
+
+// isAlterTable reports whether statement is an ALTER TABLE statement.
+func isAlterTable(statement string) bool {
+	return alterTableRegex.MatchString(stripComments(statement))
+}
+
+// hasAlterTable reports whether one of statements is an ALTER TABLE statement.
+func hasAlterTable(statements []string) bool {
+	for _, s := range statements {
+		if isAlterTable(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitSQL splits migration into statements at semicolons outside of
+// quotes and comments. The statements are trimmed and don't include the
+// semicolon. Statements consisting of comments only are dropped.
+func splitSQL(migration string) []string {
+	var statements []string
+	start := 0
+	add := func(end int) {
+		if s := strings.TrimSpace(migration[start:end]); stripComments(s) != "" {
+			statements = append(statements, s)
+		}
+	}
+
+	for i := 0; i < len(migration); i++ {
+		switch c := migration[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			// skip to the closing quote, honoring backslash escapes
+			for i++; i < len(migration) && migration[i] != c; i++ {
+				if migration[i] == '\\' && c != '`' {
+					i++
+				}
+			}
+		case c == '#' || (strings.HasPrefix(migration[i:], "--") && (i+2 == len(migration) || isSpace(migration[i+2]))):
+			if j := strings.IndexByte(migration[i:], '\n'); j >= 0 {
+				i += j
+			} else {
+				i = len(migration)
+			}
+		case c == '/' && strings.HasPrefix(migration[i:], "/*"):
+			if j := strings.Index(migration[i+2:], "*/"); j >= 0 {
+				i += j + 3
+			} else {
+				i = len(migration)
+			}
+		case c == ';':
+			add(i)
+			start = i + 1
+		}
+	}
+	if start < len(migration) {
+		add(len(migration))
+	}
+	return statements
+}
@@ -0,0 +1,34 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitSQL(t *testing.T) {
+	testcases := []struct {
+		name       string
+		migration  string
+		statements []string
+	}{
+		{name: "single", migration: "ALTER TABLE t ADD COLUMN c int", statements: []string{"ALTER TABLE t ADD COLUMN c int"}},
+		{name: "multiple", migration: "CREATE TABLE t (a int);\nALTER TABLE t ADD COLUMN c int;\n", statements: []string{"CREATE TABLE t (a int)", "ALTER TABLE t ADD COLUMN c int"}},
+		{name: "quoted semicolons", migration: `INSERT INTO t VALUES ('a;b', "c;d", 'it\'s;'); UPDATE ` + "`a;b`" + ` SET c = 1`, statements: []string{`INSERT INTO t VALUES ('a;b', "c;d", 'it\'s;')`, "UPDATE `a;b` SET c = 1"}},
+		{name: "comments", migration: "-- first; not split\nCREATE TABLE t (a int); /* x; y */ DROP TABLE u; # trailing;", statements: []string{"-- first; not split\nCREATE TABLE t (a int)", "/* x; y */ DROP TABLE u"}},
+		{name: "empty", migration: " ;\n; ", statements: nil},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.statements, splitSQL(tc.migration))
+		})
+	}
+}
+
+func TestIsAlterTable(t *testing.T) {
+	assert.True(t, isAlterTable("ALTER TABLE t ADD COLUMN c int"))
+	assert.True(t, isAlterTable("-- widen\nalter  online table t MODIFY c bigint"))
+	assert.False(t, isAlterTable("ALTER DATABASE d CHARACTER SET utf8mb4"))
+	assert.False(t, isAlterTable("CREATE TABLE alter_table (a int)"))
+}
@@ -0,0 +1,44 @@
+// +build go1.9
+
+package mysql
+
+import (
+	"errors"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// ErrNotAtomic is returned for migrations mixing DDL and DML statements,
+// if RequireAtomic is set.
+var ErrNotAtomic = errors.New("migration mixes DDL and DML statements and is not atomic: DDL causes an implicit commit")
+
+// mixesDDLAndDML reports whether migration contains both DDL statements,
+// which cause an implicit commit, and DML statements. If a later statement
+// fails, the changes of the statements before the last implicit commit are
+// kept, even within an explicit transaction.
+func mixesDDLAndDML(migration string) bool {
+	var ddl, dml bool
+	for _, s := range splitStatements(migration) {
+		ddl = ddl || isDDL(s)
+		dml = dml || isDML(s)
+	}
+	return ddl && dml
+}
+
+// isDDL reports whether the upper-cased statement changes the schema.
+func isDDL(statement string) bool {
+	return hasKeyword(statement, "CREATE", "ALTER", "DROP", "RENAME", "TRUNCATE")
+}
+
+// checkAtomic logs a warning for a migration mixing DDL and DML statements,
+// or returns ErrNotAtomic for it if RequireAtomic is set.
+func (m *Mysql) checkAtomic(migr []byte) error {
+	if !mixesDDLAndDML(string(migr)) {
+		return nil
+	}
+	if m.config.RequireAtomic {
+		return database.Error{OrigErr: ErrNotAtomic, Err: "migration failed", Query: migr}
+	}
+	m.logPrintf("mysql: migration mixes DDL and DML statements and is not atomic, DDL causes an implicit commit")
+	return nil
+}
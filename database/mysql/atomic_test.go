@@ -0,0 +1,49 @@
+package mysql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMixesDDLAndDML(t *testing.T) {
+	testcases := []struct {
+		name      string
+		migration string
+		mixed     bool
+	}{
+		{name: "ddl", migration: "CREATE TABLE t (a int); ALTER TABLE t ADD b int;"},
+		{name: "dml", migration: "INSERT INTO t VALUES (1); UPDATE t SET a = 2;"},
+		{name: "mixed", migration: "ALTER TABLE t ADD b int;\nUPDATE t SET b = a;", mixed: true},
+		{name: "mixed in transaction", migration: "BEGIN; DELETE FROM t; TRUNCATE u; COMMIT;", mixed: true},
+		{name: "after comments", migration: "-- backfill\ninsert into t values (1);\n/* cleanup */ drop table u;", mixed: true},
+		{name: "column named update", migration: "CREATE TABLE t (updated int, deleted int);"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.mixed, mixesDDLAndDML(tc.migration))
+		})
+	}
+}
+
+func TestCheckAtomic(t *testing.T) {
+	migr := []byte("ALTER TABLE t ADD b int; UPDATE t SET b = a;")
+
+	logger := &recordingLogger{}
+	m := &Mysql{config: &Config{Logger: logger}}
+	assert.NoError(t, m.checkAtomic(migr))
+	if assert.Len(t, logger.messages, 1) {
+		assert.Contains(t, logger.messages[0], "not atomic")
+	}
+
+	logger.messages = nil
+	assert.NoError(t, m.checkAtomic([]byte("UPDATE t SET b = a;")))
+	assert.Empty(t, logger.messages)
+
+	m.config.RequireAtomic = true
+	err := m.checkAtomic(migr)
+	assert.True(t, errors.Is(err, ErrNotAtomic), "expected ErrNotAtomic, got %v", err)
+	assert.Empty(t, logger.messages)
+}
@@ -0,0 +1,87 @@
+// +build go1.9
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// baselineTableName is the name of the table recording the baseline
+// version. It's kept apart from the migrations table, which is truncated
+// whenever the version is written.
+func (m *Mysql) baselineTableName() string {
+	return m.config.MigrationsTable + "_baseline"
+}
+
+// baselineTable returns the quoted, database qualified name of the
+// table recording the baseline version.
+func (m *Mysql) baselineTable() string {
+	return "`" + m.config.DatabaseName + "`.`" + m.baselineTableName() + "`"
+}
+
+// SetBaseline implements database.Baseliner. The table recording the
+// baseline version is created on first use. With CaptureTo, the baseline
+// version is only kept in memory, like the version.
+func (m *Mysql) SetBaseline(version int) error {
+	if m.capture != nil {
+		m.capture.baseline = version
+		return nil
+	}
+
+	query := "CREATE TABLE IF NOT EXISTS " + m.baselineTable() + " (version bigint not null) ENGINE=InnoDB"
+	if _, err := m.conn.ExecContext(context.Background(), query); err != nil {
+		return &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+
+	tx, err := m.conn.BeginTx(context.Background(), &sql.TxOptions{})
+	if err != nil {
+		return &database.Error{OrigErr: err, Err: "transaction start failed"}
+	}
+
+	// DELETE instead of TRUNCATE, which causes an implicit commit
+	query = "DELETE FROM " + m.baselineTable()
+	if _, err := tx.ExecContext(context.Background(), query); err != nil {
+		if errRollback := tx.Rollback(); errRollback != nil {
+			err = multierror.Append(err, errRollback)
+		}
+		return &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+
+	query = "INSERT INTO " + m.baselineTable() + " (version) VALUES (?)"
+	if _, err := tx.ExecContext(context.Background(), query, version); err != nil {
+		if errRollback := tx.Rollback(); errRollback != nil {
+			err = multierror.Append(err, errRollback)
+		}
+		return &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return &database.Error{OrigErr: err, Err: "transaction commit failed"}
+	}
+	return nil
+}
+
+// Baseline implements database.Baseliner.
+func (m *Mysql) Baseline() (version int, err error) {
+	if m.capture != nil {
+		return m.capture.baseline, nil
+	}
+
+	query := "SELECT version FROM " + m.baselineTable() + " LIMIT 1"
+	err = m.conn.QueryRowContext(context.Background(), query).Scan(&version)
+	switch {
+	case err == sql.ErrNoRows:
+		return database.NilVersion, nil
+	case isNoSuchTable(err):
+		return database.NilVersion, nil
+	case err != nil:
+		return 0, &database.Error{OrigErr: err, Query: []byte(query)}
+	default:
+		return version, nil
+	}
+}
@@ -0,0 +1,44 @@
+// +build go1.9
+
+package mysql
+
+import (
+	"os"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// capture writes the migrations to a file instead of running them
+// and keeps the version in memory. See Config.CaptureTo.
+type capture struct {
+	file     *os.File
+	version  int
+	dirty    bool
+	baseline int
+}
+
+func newCapture(path string, version int, dirty bool) (*capture, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &capture{file: f, version: version, dirty: dirty, baseline: database.NilVersion}, nil
+}
+
+// write appends migration to the capture file, terminated by a newline.
+// migration is owned by the caller, so the newline is written separately
+// instead of being appended to it.
+func (c *capture) write(migration []byte) error {
+	if _, err := c.file.Write(migration); err != nil {
+		return err
+	}
+	if len(migration) > 0 && migration[len(migration)-1] != '\n' {
+		_, err := c.file.Write([]byte{'\n'})
+		return err
+	}
+	return nil
+}
+
+func (c *capture) close() error {
+	return c.file.Close()
+}
@@ -0,0 +1,50 @@
+package mysql
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+func TestCaptureWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mysql-capture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Error(err)
+		}
+	}()
+	captureFile := filepath.Join(dir, "capture.sql")
+
+	c, err := newCapture(captureFile, database.NilVersion, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the spare capacity of the migration must not be written to
+	buf := []byte("SELECT 1;X")
+	migration := buf[:len(buf)-1]
+	if err := c.write(migration); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.write([]byte("SELECT 2;\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.close(); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "SELECT 1;X", string(buf))
+
+	captured, err := ioutil.ReadFile(captureFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "SELECT 1;\nSELECT 2;\n", string(captured))
+}
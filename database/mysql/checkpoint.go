@@ -0,0 +1,92 @@
+// +build go1.9
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// ErrNoStatementCheckpoints is returned when recording statement checkpoints
+// without StatementCheckpoints being set.
+var ErrNoStatementCheckpoints = errors.New("statement checkpoints not enabled, see x-statement-checkpoints")
+
+// RunStatement implements database.StatementCheckpointStore. The statement
+// is run on its own and committed right away, unless the migration started
+// a transaction. Statements consisting of comments only are skipped.
+func (m *Mysql) RunStatement(statement []byte) error {
+	if m.capture != nil {
+		return m.capture.write(statement)
+	}
+
+	if stripComments(string(statement)) == "" {
+		return nil
+	}
+	if err := execDrained(context.Background(), m.conn, string(statement)); err != nil {
+		return database.Error{OrigErr: wrapErr(err), Err: "migration failed", Query: statement}
+	}
+	return nil
+}
+
+// SetCheckpoint implements database.StatementCheckpointStore.
+func (m *Mysql) SetCheckpoint(n int) error {
+	if m.capture != nil {
+		return nil
+	}
+	if !m.config.StatementCheckpoints {
+		return ErrNoStatementCheckpoints
+	}
+
+	query := "UPDATE " + m.migrationsTable() + " SET checkpoint = ?"
+	if _, err := m.conn.ExecContext(context.Background(), query, n); err != nil {
+		return &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+	return nil
+}
+
+// Checkpoint implements database.StatementCheckpointStore.
+func (m *Mysql) Checkpoint() (n int, err error) {
+	if m.capture != nil {
+		return 0, nil
+	}
+	if !m.config.StatementCheckpoints {
+		return 0, ErrNoStatementCheckpoints
+	}
+
+	query := "SELECT checkpoint FROM " + m.migrationsTable() + " LIMIT 1"
+	err = m.conn.QueryRowContext(context.Background(), query).Scan(&n)
+	switch {
+	case err == sql.ErrNoRows:
+		return 0, nil
+	case err != nil:
+		return 0, &database.Error{OrigErr: err, Query: []byte(query)}
+	default:
+		return n, nil
+	}
+}
+
+// checkpointColumn is the definition of the checkpoint column of the
+// migrations table.
+const checkpointColumn = "int not null default 0"
+
+// ensureColumn adds the column with the given definition to the migrations
+// table, if it doesn't exist yet.
+func (m *Mysql) ensureColumn(column, definition string) error {
+	var count int
+	query := `SELECT COUNT(*) FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND COLUMN_NAME = ?`
+	if err := m.conn.QueryRowContext(context.Background(), query, m.config.DatabaseName, m.config.MigrationsTable, column).Scan(&count); err != nil {
+		return &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+	if count > 0 {
+		return nil
+	}
+
+	query = "ALTER TABLE " + m.migrationsTable() + " ADD COLUMN " + column + " " + definition
+	if _, err := m.conn.ExecContext(context.Background(), query); err != nil {
+		return &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+	return nil
+}
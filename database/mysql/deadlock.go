@@ -0,0 +1,117 @@
+// +build go1.9
+
+package mysql
+
+import (
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// erLockDeadlock is the ER_LOCK_DEADLOCK error code
+// See: https://dev.mysql.com/doc/refman/8.0/en/server-error-reference.html
+const erLockDeadlock = 1213
+
+// isDeadlock reports whether err is caused by a deadlock,
+// which rolled back the transaction.
+func isDeadlock(err error) bool {
+	e, ok := err.(*mysql.MySQLError)
+	return ok && e.Number == erLockDeadlock
+}
+
+// retryDeadlock calls exec and calls it again, at most retries times, as long
+// as it fails with a deadlock and the migration can safely be run again.
+func retryDeadlock(retries int, migr []byte, exec func() error) error {
+	err := exec()
+	if retries <= 0 || !isDeadlock(err) || !isRetryable(string(migr)) {
+		return err
+	}
+
+	for i := 0; i < retries && isDeadlock(err); i++ {
+		err = exec()
+	}
+	return err
+}
+
+// isRetryable reports whether migration can be run again after a deadlock,
+// which only rolls back the transaction it occurred in. That's the case for a
+// single DML statement, or DML statements wrapped in a single transaction,
+// which may use savepoints. DDL is never retried, since it causes an
+// implicit commit.
+// Statements are split at semicolons, so a semicolon in a string makes the
+// migration not retryable, but never the other way around.
+func isRetryable(migration string) bool {
+	statements := splitStatements(migration)
+	switch {
+	case len(statements) == 1:
+		return isDML(statements[0])
+	case len(statements) > 2:
+		first, last := statements[0], statements[len(statements)-1]
+		if !(first == "BEGIN" || strings.HasPrefix(first, "START TRANSACTION")) || last != "COMMIT" {
+			return false
+		}
+		for _, s := range statements[1 : len(statements)-1] {
+			if !isDML(s) && !isSavepoint(s) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// splitStatements splits migration into upper-cased statements at
+// semicolons, without leading comments and white space.
+func splitStatements(migration string) []string {
+	var statements []string
+	for _, s := range strings.Split(migration, ";") {
+		if s = stripComments(s); s != "" {
+			statements = append(statements, strings.ToUpper(s))
+		}
+	}
+	return statements
+}
+
+// isDML reports whether the upper-cased statement modifies rows.
+func isDML(statement string) bool {
+	return hasKeyword(statement, "INSERT", "UPDATE", "DELETE", "REPLACE")
+}
+
+// hasKeyword reports whether the upper-cased statement starts with
+// one of keywords.
+func hasKeyword(statement string, keywords ...string) bool {
+	for _, keyword := range keywords {
+		if strings.HasPrefix(statement, keyword) && (len(statement) == len(keyword) || isSpace(statement[len(keyword)])) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripComments removes the leading comments and white space of statement.
+func stripComments(statement string) string {
+	for {
+		statement = strings.TrimSpace(statement)
+		switch {
+		case strings.HasPrefix(statement, "--"), strings.HasPrefix(statement, "#"):
+			i := strings.IndexByte(statement, '\n')
+			if i < 0 {
+				return ""
+			}
+			statement = statement[i+1:]
+		case strings.HasPrefix(statement, "/*"):
+			i := strings.Index(statement, "*/")
+			if i < 0 {
+				return ""
+			}
+			statement = statement[i+2:]
+		default:
+			return statement
+		}
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,83 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryDeadlock(t *testing.T) {
+	deadlock := &mysql.MySQLError{Number: erLockDeadlock, Message: "Deadlock found when trying to get lock"}
+
+	testcases := []struct {
+		name      string
+		migration string
+		retries   int
+		failures  int
+		calls     int
+		err       error
+	}{
+		{name: "dml retried", migration: "UPDATE t SET a = 1 WHERE b = 2", retries: 3, failures: 2, calls: 3},
+		{name: "dml retries exhausted", migration: "DELETE FROM t", retries: 2, failures: 5, calls: 3, err: deadlock},
+		{name: "retries disabled", migration: "UPDATE t SET a = 1", failures: 1, calls: 1, err: deadlock},
+		{name: "ddl not retried", migration: "ALTER TABLE t ADD COLUMN c int", retries: 3, failures: 1, calls: 1, err: deadlock},
+		{name: "mixed not retried", migration: "UPDATE t SET a = 1; CREATE INDEX i ON t (a);", retries: 3, failures: 1, calls: 1, err: deadlock},
+		{name: "transaction retried", migration: "START TRANSACTION; UPDATE t SET a = 1; DELETE FROM u; COMMIT;", retries: 3, failures: 1, calls: 2},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			err := retryDeadlock(tc.retries, []byte(tc.migration), func() error {
+				calls++
+				if calls <= tc.failures {
+					return deadlock
+				}
+				return nil
+			})
+			assert.Equal(t, tc.err, err)
+			assert.Equal(t, tc.calls, calls)
+		})
+	}
+}
+
+func TestRetryDeadlockOtherError(t *testing.T) {
+	calls := 0
+	other := errors.New("other")
+	err := retryDeadlock(3, []byte("UPDATE t SET a = 1"), func() error {
+		calls++
+		return other
+	})
+	assert.Equal(t, other, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestIsRetryable(t *testing.T) {
+	testcases := []struct {
+		migration string
+		retryable bool
+	}{
+		{migration: "INSERT INTO t VALUES (1);", retryable: true},
+		{migration: "-- backfill\n/* comment */ update t set a = 1", retryable: true},
+		{migration: "REPLACE INTO t VALUES (1)", retryable: true},
+		{migration: "BEGIN; INSERT INTO t VALUES (1); UPDATE t SET a = 2; COMMIT", retryable: true},
+		{migration: "BEGIN; SAVEPOINT s; INSERT INTO t VALUES (1); ROLLBACK TO SAVEPOINT s; RELEASE SAVEPOINT s; COMMIT", retryable: true},
+		{migration: "INSERT INTO t VALUES (1); INSERT INTO t VALUES (2)"},
+		{migration: "BEGIN; INSERT INTO t VALUES (1); DROP TABLE u; COMMIT"},
+		{migration: "BEGIN; INSERT INTO t VALUES (1)"},
+		{migration: "CREATE TABLE t (a int)"},
+		{migration: "UPDATEX t"},
+		{migration: "INSERT INTO t VALUES ('a;b')"},
+		{migration: ""},
+	}
+
+	for i, tc := range testcases {
+		t.Run("tc"+strconv.Itoa(i), func(t *testing.T) {
+			assert.Equal(t, tc.retryable, isRetryable(tc.migration), fmt.Sprintf("migration %q", tc.migration))
+		})
+	}
+}
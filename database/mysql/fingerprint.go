@@ -0,0 +1,79 @@
+// +build go1.9
+
+package mysql
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"hash"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// fingerprintQueries select the columns and indexes of the tables in the
+// database, excluding the migrations and baseline tables, in a stable order.
+var fingerprintQueries = []string{
+	`SELECT TABLE_NAME, COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT, EXTRA
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME NOT IN (?, ?)
+		ORDER BY TABLE_NAME, ORDINAL_POSITION`,
+	`SELECT TABLE_NAME, INDEX_NAME, NON_UNIQUE, SEQ_IN_INDEX, COLUMN_NAME
+		FROM information_schema.STATISTICS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME NOT IN (?, ?)
+		ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX`,
+}
+
+// SchemaFingerprint implements database.SchemaFingerprinter. It hashes the
+// columns and indexes listed in information_schema.
+func (m *Mysql) SchemaFingerprint() (string, error) {
+	h := sha256.New()
+	for _, query := range fingerprintQueries {
+		if err := m.hashRows(h, query); err != nil {
+			return "", &database.Error{OrigErr: err, Err: "schema fingerprint failed", Query: []byte(query)}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashRows writes each row returned by query to h, one line per row.
+func (m *Mysql) hashRows(h hash.Hash, query string) (err error) {
+	rows, err := m.conn.QueryContext(context.Background(), query, m.config.DatabaseName, m.config.MigrationsTable, m.baselineTableName())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			err = multierror.Append(err, errClose)
+		}
+	}()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		for _, v := range values {
+			if v.Valid {
+				fmt.Fprintf(h, "%q\t", v.String)
+			} else {
+				fmt.Fprint(h, "NULL\t")
+			}
+		}
+		fmt.Fprintln(h)
+	}
+	return rows.Err()
+}
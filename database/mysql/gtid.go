@@ -0,0 +1,36 @@
+// +build go1.9
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// ErrGTIDWaitTimeout is returned if the GTID set of WaitGTIDSet hasn't been
+// applied within WaitGTIDTimeout after a migration.
+var ErrGTIDWaitTimeout = errors.New("timed out waiting for GTID set to be executed")
+
+// queryRower is implemented by *sql.DB and *sql.Conn.
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// waitForGTIDSet waits for the server to have executed gtidSet, failing with
+// ErrGTIDWaitTimeout after timeout. A timeout of zero waits indefinitely.
+func waitForGTIDSet(ctx context.Context, q queryRower, gtidSet string, timeout time.Duration) error {
+	query := `SELECT WAIT_FOR_EXECUTED_GTID_SET(?, ?)`
+	var timedOut bool
+	if err := q.QueryRowContext(ctx, query, gtidSet, timeout.Seconds()).Scan(&timedOut); err != nil {
+		return &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+	if timedOut {
+		return fmt.Errorf("%w: %v after %v", ErrGTIDWaitTimeout, gtidSet, timeout)
+	}
+	return nil
+}
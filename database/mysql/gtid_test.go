@@ -0,0 +1,85 @@
+// +build go1.9
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gtidStub is a database/sql driver answering every query with a single
+// row holding its result, recording the arguments of the last query.
+type gtidStub struct {
+	result int64
+	args   []sqldriver.Value
+}
+
+func (s *gtidStub) Open(string) (sqldriver.Conn, error)    { return s, nil }
+func (s *gtidStub) Prepare(string) (sqldriver.Stmt, error) { return s, nil }
+func (s *gtidStub) Close() error                           { return nil }
+func (s *gtidStub) Begin() (sqldriver.Tx, error)           { return nil, errors.New("not supported") }
+func (s *gtidStub) NumInput() int                          { return -1 }
+func (s *gtidStub) Exec([]sqldriver.Value) (sqldriver.Result, error) {
+	return nil, errors.New("not supported")
+}
+func (s *gtidStub) Query(args []sqldriver.Value) (sqldriver.Rows, error) {
+	s.args = args
+	return &gtidRows{result: s.result}, nil
+}
+
+type gtidRows struct {
+	result int64
+	read   bool
+}
+
+func (r *gtidRows) Columns() []string { return []string{"result"} }
+func (r *gtidRows) Close() error      { return nil }
+func (r *gtidRows) Next(dest []sqldriver.Value) error {
+	if r.read {
+		return io.EOF
+	}
+	r.read = true
+	dest[0] = r.result
+	return nil
+}
+
+func TestWaitForGTIDSet(t *testing.T) {
+	const gtidSet = "3E11FA47-71CA-11E1-9E33-C80AA9429562:1-5"
+
+	testcases := []struct {
+		name   string
+		result int64
+		err    error
+	}{
+		{name: "executed", result: 0},
+		{name: "timeout", result: 1, err: ErrGTIDWaitTimeout},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			stub := &gtidStub{result: tc.result}
+			sql.Register("gtid-stub-"+tc.name, stub)
+			db, err := sql.Open("gtid-stub-"+tc.name, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				assert.NoError(t, db.Close())
+			}()
+
+			err = waitForGTIDSet(context.Background(), db, gtidSet, 2*time.Second)
+			if tc.err != nil {
+				assert.True(t, errors.Is(err, tc.err), "expected %v, got %v", tc.err, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, []sqldriver.Value{gtidSet, 2.0}, stub.args)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+// +build go1.9
+
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// ErrInvalidLocalAddr is returned for values of x-local-addr which aren't
+// an IP address, optionally with a port.
+var ErrInvalidLocalAddr = errors.New("invalid local address: must be an IP address, optionally with a port")
+
+// parseLocalAddr parses the value of x-local-addr, like `10.0.0.2` or
+// `10.0.0.2:3307`. Without a port, one is picked by the system.
+func parseLocalAddr(localAddr string) (*net.TCPAddr, error) {
+	host, port := localAddr, 0
+	if h, p, err := net.SplitHostPort(localAddr); err == nil {
+		host = h
+		if port, err = strconv.Atoi(p); err != nil || port < 0 || port > 65535 {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidLocalAddr, localAddr)
+		}
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLocalAddr, localAddr)
+	}
+	return &net.TCPAddr{IP: ip, Port: port}, nil
+}
+
+// localDialer returns a dial function connecting from laddr.
+func localDialer(laddr *net.TCPAddr, config *mysql.Config) mysql.DialContextFunc {
+	d := net.Dialer{LocalAddr: laddr, Timeout: config.Timeout}
+	return func(ctx context.Context, addr string) (net.Conn, error) {
+		return d.DialContext(ctx, "tcp", addr)
+	}
+}
+
+// bindLocalAddr makes the connections of config originate from localAddr,
+// registering a network dialing from it with mysql.RegisterDialContext.
+func bindLocalAddr(config *mysql.Config, localAddr string) error {
+	laddr, err := parseLocalAddr(localAddr)
+	if err != nil {
+		return err
+	}
+	if config.Net != "" && config.Net != "tcp" {
+		return fmt.Errorf("x-local-addr requires a tcp connection, got %v", config.Net)
+	}
+
+	// the network name is part of the DSN, so it can't contain
+	// the colons and brackets of IPv6 addresses
+	network := "tcp-local-" + strings.NewReplacer(":", "-", ".", "-").Replace(laddr.String())
+	network = strings.NewReplacer("[", "", "]", "").Replace(network)
+	mysql.RegisterDialContext(network, localDialer(laddr, config))
+	config.Net = network
+	return nil
+}
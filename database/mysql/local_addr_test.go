@@ -0,0 +1,115 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLocalAddr(t *testing.T) {
+	testcases := []struct {
+		localAddr string
+		expected  string
+		valid     bool
+	}{
+		{localAddr: "10.0.0.2", expected: "10.0.0.2:0", valid: true},
+		{localAddr: "10.0.0.2:3307", expected: "10.0.0.2:3307", valid: true},
+		{localAddr: "::1", expected: "[::1]:0", valid: true},
+		{localAddr: "[fe80::1]:3307", expected: "[fe80::1]:3307", valid: true},
+		{localAddr: "localhost"},
+		{localAddr: "10.0.0"},
+		{localAddr: "10.0.0.2:port"},
+		{localAddr: "10.0.0.2:70000"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.localAddr, func(t *testing.T) {
+			laddr, err := parseLocalAddr(tc.localAddr)
+			if !tc.valid {
+				assert.True(t, errors.Is(err, ErrInvalidLocalAddr), "expected ErrInvalidLocalAddr, got %v", err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, laddr.String())
+			}
+		})
+	}
+}
+
+func TestBindLocalAddr(t *testing.T) {
+	config, err := mysql.ParseDSN("user:password@tcp(127.0.0.1:3306)/db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, bindLocalAddr(config, "127.0.0.1"))
+	assert.Equal(t, "tcp-local-127-0-0-1-0", config.Net)
+
+	// the network survives the DSN round trip of Open
+	parsed, err := mysql.ParseDSN(config.FormatDSN())
+	if assert.NoError(t, err) {
+		assert.Equal(t, config.Net, parsed.Net)
+		assert.Equal(t, "127.0.0.1:3306", parsed.Addr)
+	}
+
+	config, err = mysql.ParseDSN("user:password@unix(/tmp/mysql.sock)/db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Error(t, bindLocalAddr(config, "127.0.0.1"))
+}
+
+func TestLocalDialer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := l.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	// reserve a free local port to dial from
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := free.Addr().(*net.TCPAddr).Port
+	if err := free.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	laddr, err := parseLocalAddr("127.0.0.1:" + strconv.Itoa(port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	accepted := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			accepted <- err.Error()
+			return
+		}
+		accepted <- conn.RemoteAddr().String()
+		_ = conn.Close()
+	}()
+
+	dial := localDialer(laddr, &mysql.Config{})
+	conn, err := dial(context.Background(), l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	assert.Equal(t, laddr.String(), conn.LocalAddr().String())
+	assert.Equal(t, laddr.String(), <-accepted)
+}
@@ -7,12 +7,17 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"log"
 	nurl "net/url"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 import (
@@ -36,12 +41,105 @@ var (
 	ErrNoDatabaseName   = fmt.Errorf("no database name")
 	ErrAppendPEM        = fmt.Errorf("failed to append PEM")
 	ErrTLSCertKeyConfig = fmt.Errorf("To use TLS client authentication, both x-tls-cert and x-tls-key must not be empty")
+	// ErrMigrationTooLarge is returned for migrations larger than
+	// MaxMigrationSize.
+	ErrMigrationTooLarge = fmt.Errorf("migration too large")
 )
 
+// erNoSuchTable is the ER_NO_SUCH_TABLE error code
+// See: https://dev.mysql.com/doc/refman/8.0/en/server-error-reference.html
+const erNoSuchTable = 1146
+
+// serverVersionRegex matches the major and minor version at the start
+// of the string returned by VERSION(), i.e. "8.0.21" or "10.4.13-MariaDB".
+var serverVersionRegex = regexp.MustCompile(`^(\d+)\.(\d+)`)
+
 type Config struct {
 	MigrationsTable string
 	DatabaseName    string
 	NoLock          bool
+	// SkipEnsureTable skips creating the migrations table, assuming it
+	// already exists. Useful for roles without DDL privileges.
+	SkipEnsureTable bool
+	// IsolateSessions runs each migration on a new connection, which is
+	// closed afterwards, so session variables set by a migration don't
+	// leak into the next one. The idle connections of the instance are
+	// disabled for this to work.
+	IsolateSessions bool
+	// CaptureTo is the path of a file the migrations are appended to instead
+	// of being run, for review before running them. The version is read from
+	// the database once and then only kept in memory, so the database is
+	// left untouched.
+	CaptureTo string
+	// DeadlockRetries is how often a migration failing with a deadlock is
+	// run again. Only migrations consisting of DML statements, in a single
+	// statement or transaction, are retried.
+	DeadlockRetries int
+	// RespectExplicitTx runs migrations controlling transactions themselves,
+	// e.g. with START TRANSACTION and COMMIT, on a connection of their own.
+	// A transaction left open is rolled back instead of being committed
+	// implicitly by the next statement. Migrations starting a transaction
+	// without ending it fail with ErrOpenTransaction. Rolling back to a
+	// savepoint doesn't end the transaction.
+	RespectExplicitTx bool
+	// NoUseDatabase is set if the connection doesn't select DatabaseName as
+	// its default database, so migrations must qualify the tables of each
+	// schema they reference, e.g. `billing`.`invoices`. The migrations table
+	// is still kept in DatabaseName, which must be set.
+	NoUseDatabase bool
+	// StatementCheckpoints adds a checkpoint column to the migrations table,
+	// recording the number of completed statements of a migration run
+	// statement by statement, see migrate.StatementCheckpoints.
+	StatementCheckpoints bool
+	// RequireAtomic makes migrations mixing DDL and DML statements fail with
+	// ErrNotAtomic. DDL causes an implicit commit, so such a migration isn't
+	// rolled back as a whole if it fails. Without it, a warning is logged
+	// to Logger.
+	RequireAtomic bool
+	// MaxMigrationSize is the max size of a migration in bytes. Larger
+	// migrations fail with ErrMigrationTooLarge before being run, reading
+	// no more than MaxMigrationSize+1 bytes into memory. Zero means no limit.
+	MaxMigrationSize int64
+	// AllowReadOnly skips checking that the server accepts writes. Without
+	// it, connecting to a server with read_only or super_read_only set,
+	// e.g. a replica by mistake, fails with ErrReadOnlyServer.
+	AllowReadOnly bool
+	// VersionColumnType is the type of the version column of a migrations
+	// table created by the driver. Defaults to database.VersionColumnBigint.
+	// Versions not fitting the column fail with
+	// database.ErrVersionOutOfRange.
+	VersionColumnType database.VersionColumnType
+	// RecordWriter adds writer and written_at columns to the migrations
+	// table, recording WriterID and the time with each version set, for
+	// auditing. See LastWriter.
+	RecordWriter bool
+	// WriterID identifies the process setting the version if RecordWriter
+	// is set. Defaults to the hostname and pid of the process.
+	WriterID string
+	// WaitGTIDSet is a GTID set the session waits for to be executed after
+	// each migration, with WAIT_FOR_EXECUTED_GTID_SET, before the migration
+	// is reported as successful, e.g. in GTID-based replication topologies.
+	WaitGTIDSet string
+	// WaitGTIDTimeout is how long to wait for WaitGTIDSet. The migration
+	// fails with ErrGTIDWaitTimeout once it has passed, leaving the version
+	// dirty. Zero waits indefinitely.
+	WaitGTIDTimeout time.Duration
+	// StreamStatements runs migrations statement by statement while reading
+	// them, instead of reading them into memory first, e.g. for large seed
+	// data. Statements are split by semicolons outside of strings and
+	// comments, so stored programs using semicolons can't be streamed.
+	// RespectExplicitTx, RequireAtomic, MaxMigrationSize, DeadlockRetries
+	// and the alter executor don't apply to streamed migrations.
+	StreamStatements bool
+	// StreamMaxStatementSize is the max size in bytes of a statement of a
+	// streamed migration. Larger statements fail with ErrStatementTooLarge.
+	// Defaults to DefaultStreamMaxStatementSize.
+	StreamMaxStatementSize int
+	// Logger receives the warnings of the driver, e.g. about migrations
+	// controlling transactions themselves or mixing DDL and DML
+	// statements. Defaults to the standard logger
+	// of package log.
+	Logger database.Logger
 }
 
 type Mysql struct {
@@ -51,6 +149,16 @@ type Mysql struct {
 	db       *sql.DB
 	isLocked bool
 
+	// capture is set if migrations are captured instead of run
+	capture *capture
+
+	// alterExecutor runs ALTER TABLE statements if set. See SetAlterExecutor.
+	alterExecutor AlterExecutor
+
+	// poolStatsSampler samples the stats of the pool while migrations run
+	// if set. See SetPoolStatsSampler.
+	poolStatsSampler *poolStatsSampler
+
 	config *Config
 }
 
@@ -64,6 +172,17 @@ func WithInstance(instance *sql.DB, config *Config) (database.Driver, error) {
 		return nil, err
 	}
 
+	if config.DatabaseName == "" && config.NoUseDatabase {
+		return nil, ErrNoDatabaseName
+	}
+
+	// migrations are only written to a file when capturing
+	if !config.AllowReadOnly && len(config.CaptureTo) == 0 {
+		if err := checkReadOnly(context.Background(), instance); err != nil {
+			return nil, err
+		}
+	}
+
 	if config.DatabaseName == "" {
 		query := `SELECT DATABASE()`
 		var databaseName sql.NullString
@@ -82,6 +201,23 @@ func WithInstance(instance *sql.DB, config *Config) (database.Driver, error) {
 		config.MigrationsTable = DefaultMigrationsTable
 	}
 
+	if len(config.VersionColumnType) == 0 {
+		config.VersionColumnType = database.VersionColumnBigint
+	}
+
+	if config.StreamMaxStatementSize <= 0 {
+		config.StreamMaxStatementSize = DefaultStreamMaxStatementSize
+	}
+
+	if config.RecordWriter && len(config.WriterID) == 0 {
+		config.WriterID = defaultWriterID()
+	}
+
+	if config.IsolateSessions {
+		// connections must be closed instead of being returned to the pool
+		instance.SetMaxIdleConns(0)
+	}
+
 	conn, err := instance.Conn(context.Background())
 	if err != nil {
 		return nil, err
@@ -93,8 +229,21 @@ func WithInstance(instance *sql.DB, config *Config) (database.Driver, error) {
 		config: config,
 	}
 
-	if err := mx.ensureVersionTable(); err != nil {
-		return nil, err
+	if len(config.CaptureTo) > 0 {
+		version, dirty, err := mx.Version()
+		if e, ok := err.(*database.Error); ok && isNoSuchTable(e.OrigErr) {
+			version, dirty, err = database.NilVersion, false, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if mx.capture, err = newCapture(config.CaptureTo, version, dirty); err != nil {
+			return nil, err
+		}
+	} else if !config.SkipEnsureTable {
+		if err := mx.ensureVersionTable(); err != nil {
+			return nil, err
+		}
 	}
 
 	return mx, nil
@@ -224,15 +373,159 @@ func (m *Mysql) Open(url string) (database.Driver, error) {
 		}
 	}
 
+	skipEnsureTableParam, skipEnsureTable := customParams["x-skip-ensure-table"], false
+	if skipEnsureTableParam != "" {
+		skipEnsureTable, err = strconv.ParseBool(skipEnsureTableParam)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse x-skip-ensure-table as bool: %w", err)
+		}
+	}
+
+	isolateSessionsParam, isolateSessions := customParams["x-isolate-sessions"], false
+	if isolateSessionsParam != "" {
+		isolateSessions, err = strconv.ParseBool(isolateSessionsParam)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse x-isolate-sessions as bool: %w", err)
+		}
+	}
+
+	deadlockRetriesParam, deadlockRetries := customParams["x-deadlock-retries"], 0
+	if deadlockRetriesParam != "" {
+		deadlockRetries, err = strconv.Atoi(deadlockRetriesParam)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse x-deadlock-retries as int: %w", err)
+		}
+	}
+
+	respectExplicitTxParam, respectExplicitTx := customParams["x-respect-explicit-tx"], false
+	if respectExplicitTxParam != "" {
+		respectExplicitTx, err = strconv.ParseBool(respectExplicitTxParam)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse x-respect-explicit-tx as bool: %w", err)
+		}
+	}
+
+	noUseDatabaseParam, noUseDatabase := customParams["x-no-use-database"], false
+	if noUseDatabaseParam != "" {
+		noUseDatabase, err = strconv.ParseBool(noUseDatabaseParam)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse x-no-use-database as bool: %w", err)
+		}
+	}
+
+	databaseName := config.DBName
+	if noUseDatabase {
+		if databaseName == "" {
+			return nil, ErrNoDatabaseName
+		}
+		config.DBName = ""
+	}
+
+	statementCheckpointsParam, statementCheckpoints := customParams["x-statement-checkpoints"], false
+	if statementCheckpointsParam != "" {
+		statementCheckpoints, err = strconv.ParseBool(statementCheckpointsParam)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse x-statement-checkpoints as bool: %w", err)
+		}
+	}
+
+	requireAtomicParam, requireAtomic := customParams["x-require-atomic"], false
+	if requireAtomicParam != "" {
+		requireAtomic, err = strconv.ParseBool(requireAtomicParam)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse x-require-atomic as bool: %w", err)
+		}
+	}
+
+	maxMigrationSizeParam, maxMigrationSize := customParams["x-max-migration-size"], int64(0)
+	if maxMigrationSizeParam != "" {
+		maxMigrationSize, err = strconv.ParseInt(maxMigrationSizeParam, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse x-max-migration-size as int: %w", err)
+		}
+	}
+
+	allowReadOnlyParam, allowReadOnly := customParams["x-allow-read-only"], false
+	if allowReadOnlyParam != "" {
+		allowReadOnly, err = strconv.ParseBool(allowReadOnlyParam)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse x-allow-read-only as bool: %w", err)
+		}
+	}
+
+	versionColumnType, err := database.ParseVersionColumnType(customParams["x-version-column-type"])
+	if err != nil {
+		return nil, err
+	}
+
+	recordWriterParam, recordWriter := customParams["x-record-writer"], false
+	if recordWriterParam != "" {
+		recordWriter, err = strconv.ParseBool(recordWriterParam)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse x-record-writer as bool: %w", err)
+		}
+	}
+	writerID := customParams["x-writer-id"]
+	if writerID != "" {
+		recordWriter = true
+	}
+
+	waitGTIDTimeoutParam, waitGTIDTimeout := customParams["x-wait-gtid-timeout"], time.Duration(0)
+	if waitGTIDTimeoutParam != "" {
+		waitGTIDTimeout, err = time.ParseDuration(waitGTIDTimeoutParam)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse x-wait-gtid-timeout as duration: %w", err)
+		}
+	}
+
+	streamStatementsParam, streamStatements := customParams["x-stream-statements"], false
+	if streamStatementsParam != "" {
+		streamStatements, err = strconv.ParseBool(streamStatementsParam)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse x-stream-statements as bool: %w", err)
+		}
+	}
+
+	streamMaxStatementSizeParam, streamMaxStatementSize := customParams["x-stream-max-statement-size"], 0
+	if streamMaxStatementSizeParam != "" {
+		streamMaxStatementSize, err = strconv.Atoi(streamMaxStatementSizeParam)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse x-stream-max-statement-size as int: %w", err)
+		}
+	}
+
+	if localAddr := customParams["x-local-addr"]; localAddr != "" {
+		if err := bindLocalAddr(config, localAddr); err != nil {
+			return nil, err
+		}
+	}
+
 	db, err := sql.Open("mysql", config.FormatDSN())
 	if err != nil {
 		return nil, err
 	}
 
 	mx, err := WithInstance(db, &Config{
-		DatabaseName:    config.DBName,
-		MigrationsTable: customParams["x-migrations-table"],
-		NoLock:          noLock,
+		DatabaseName:           databaseName,
+		MigrationsTable:        customParams["x-migrations-table"],
+		NoLock:                 noLock,
+		SkipEnsureTable:        skipEnsureTable,
+		IsolateSessions:        isolateSessions,
+		CaptureTo:              customParams["x-capture-to"],
+		DeadlockRetries:        deadlockRetries,
+		RespectExplicitTx:      respectExplicitTx,
+		NoUseDatabase:          noUseDatabase,
+		StatementCheckpoints:   statementCheckpoints,
+		RequireAtomic:          requireAtomic,
+		MaxMigrationSize:       maxMigrationSize,
+		AllowReadOnly:          allowReadOnly,
+		VersionColumnType:      versionColumnType,
+		RecordWriter:           recordWriter,
+		WriterID:               writerID,
+		WaitGTIDSet:            customParams["x-wait-gtid"],
+		WaitGTIDTimeout:        waitGTIDTimeout,
+		StreamStatements:       streamStatements,
+		StreamMaxStatementSize: streamMaxStatementSize,
 	})
 	if err != nil {
 		return nil, err
@@ -241,16 +534,31 @@ func (m *Mysql) Open(url string) (database.Driver, error) {
 	return mx, nil
 }
 
+// Ping implements database.Pinger.
+func (m *Mysql) Ping(ctx context.Context) error {
+	return m.conn.PingContext(ctx)
+}
+
 func (m *Mysql) Close() error {
 	connErr := m.conn.Close()
 	dbErr := m.db.Close()
-	if connErr != nil || dbErr != nil {
-		return fmt.Errorf("conn: %v, db: %v", connErr, dbErr)
+	var captureErr error
+	if m.capture != nil {
+		captureErr = m.capture.close()
+	}
+	if connErr != nil || dbErr != nil || captureErr != nil {
+		return fmt.Errorf("conn: %v, db: %v, capture: %v", connErr, dbErr, captureErr)
 	}
 	return nil
 }
 
 func (m *Mysql) Lock() error {
+	return m.LockContext(context.Background())
+}
+
+// LockContext implements database.ContextLocker. Waiting for the lock
+// is aborted once ctx is done.
+func (m *Mysql) LockContext(ctx context.Context) error {
 	if m.isLocked {
 		return database.ErrLocked
 	}
@@ -268,7 +576,7 @@ func (m *Mysql) Lock() error {
 
 	query := "SELECT GET_LOCK(?, 10)"
 	var success bool
-	if err := m.conn.QueryRowContext(context.Background(), query, aid).Scan(&success); err != nil {
+	if err := m.conn.QueryRowContext(ctx, query, aid).Scan(&success); err != nil {
 		return &database.Error{OrigErr: err, Err: "try lock failed", Query: []byte(query)}
 	}
 
@@ -281,6 +589,11 @@ func (m *Mysql) Lock() error {
 }
 
 func (m *Mysql) Unlock() error {
+	return m.UnlockContext(context.Background())
+}
+
+// UnlockContext implements database.ContextLocker.
+func (m *Mysql) UnlockContext(ctx context.Context) error {
 	if !m.isLocked {
 		return nil
 	}
@@ -297,7 +610,7 @@ func (m *Mysql) Unlock() error {
 	}
 
 	query := `SELECT RELEASE_LOCK(?)`
-	if _, err := m.conn.ExecContext(context.Background(), query, aid); err != nil {
+	if _, err := m.conn.ExecContext(ctx, query, aid); err != nil {
 		return &database.Error{OrigErr: err, Query: []byte(query)}
 	}
 
@@ -309,40 +622,231 @@ func (m *Mysql) Unlock() error {
 	return nil
 }
 
+// logPrintf logs a warning to the Logger of the config, or to the standard
+// logger if it isn't set.
+func (m *Mysql) logPrintf(format string, v ...interface{}) {
+	if m.config.Logger != nil {
+		m.config.Logger.Printf(format, v...)
+		return
+	}
+	log.Printf(format, v...)
+}
+
 func (m *Mysql) Run(migration io.Reader) error {
-	migr, err := ioutil.ReadAll(migration)
+	return m.RunContext(context.Background(), migration)
+}
+
+// RunContext implements database.ContextRunner. The migration is
+// aborted once ctx is done, leaving the version dirty.
+func (m *Mysql) RunContext(ctx context.Context, migration io.Reader) (err error) {
+	if m.config.StreamStatements && m.capture == nil {
+		return m.runStreaming(ctx, migration)
+	}
+
+	migr, err := m.readMigration(migration)
 	if err != nil {
 		return err
 	}
 
+	if m.capture != nil {
+		return m.capture.write(migr)
+	}
+
+	defer m.samplePoolStats()()
+
+	if err := m.checkAtomic(migr); err != nil {
+		return err
+	}
+
+	explicit, open := explicitTx(string(migr))
+	if explicit && !m.config.RespectExplicitTx {
+		m.logPrintf("mysql: migration controls transactions itself, consider x-respect-explicit-tx")
+	}
+	if explicit && open && m.config.RespectExplicitTx {
+		return database.Error{OrigErr: ErrOpenTransaction, Err: "migration failed", Query: migr}
+	}
+
+	conn := m.conn
+	if m.config.IsolateSessions || (explicit && m.config.RespectExplicitTx) {
+		if conn, err = m.db.Conn(ctx); err != nil {
+			return err
+		}
+		defer func() {
+			if errClose := conn.Close(); errClose != nil {
+				err = multierror.Append(err, errClose)
+			}
+		}()
+	}
+	if explicit && m.config.RespectExplicitTx {
+		// a transaction left open by a failing statement must not be
+		// committed by the next statement run on the pooled connection
+		defer func() {
+			if _, errRollback := conn.ExecContext(context.Background(), "ROLLBACK"); errRollback != nil {
+				err = multierror.Append(err, &database.Error{OrigErr: errRollback, Query: []byte("ROLLBACK")})
+			}
+		}()
+	}
+
+	if len(m.config.WaitGTIDSet) > 0 {
+		defer func() {
+			if err == nil {
+				err = waitForGTIDSet(ctx, conn, m.config.WaitGTIDSet, m.config.WaitGTIDTimeout)
+			}
+		}()
+	}
+
 	query := string(migr[:])
-	if _, err := m.conn.ExecContext(context.Background(), query); err != nil {
-		return database.Error{OrigErr: err, Err: "migration failed", Query: migr}
+	if m.alterExecutor != nil {
+		if statements := splitSQL(query); hasAlterTable(statements) {
+			return m.runDelegatingAlters(ctx, conn, statements)
+		}
+	}
+
+	if err := retryDeadlock(m.config.DeadlockRetries, migr, func() error {
+		return execDrained(ctx, conn, query)
+	}); err != nil {
+		return database.Error{OrigErr: wrapErr(err), Err: "migration failed", Query: migr}
 	}
 
 	return nil
 }
 
+// readMigration reads migration, failing with ErrMigrationTooLarge
+// once more than MaxMigrationSize bytes have been read.
+func (m *Mysql) readMigration(migration io.Reader) ([]byte, error) {
+	if m.config.MaxMigrationSize <= 0 {
+		return ioutil.ReadAll(migration)
+	}
+	migr, err := ioutil.ReadAll(io.LimitReader(migration, m.config.MaxMigrationSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(migr)) > m.config.MaxMigrationSize {
+		return nil, fmt.Errorf("%w: more than %v bytes", ErrMigrationTooLarge, m.config.MaxMigrationSize)
+	}
+	return migr, nil
+}
+
+// execDrained runs query on conn and reads all of its result sets, e.g. the
+// ones returned by stored procedures, so that the connection isn't left with
+// unread results causing "commands out of sync" errors for the next query.
+// Errors of statements following the first one are reported, too.
+func execDrained(ctx context.Context, conn *sql.Conn, query string) (err error) {
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if errClose := rows.Close(); errClose != nil && err == nil {
+			err = errClose
+		}
+	}()
+
+	for {
+		for rows.Next() {
+			// discard the rows of the result set
+		}
+		if !rows.NextResultSet() {
+			break
+		}
+	}
+	return rows.Err()
+}
+
+// Verify implements database.Verifier. query must return a single column,
+// which is truthy unless it is NULL, empty, 0 or false.
+func (m *Mysql) Verify(query string) (bool, error) {
+	var result sql.NullString
+	err := m.conn.QueryRowContext(context.Background(), query).Scan(&result)
+	switch {
+	case err == sql.ErrNoRows:
+		return false, nil
+	case err != nil:
+		return false, &database.Error{OrigErr: err, Err: "verification failed", Query: []byte(query)}
+	}
+
+	if !result.Valid {
+		return false, nil
+	}
+	switch strings.ToLower(result.String) {
+	case "", "0", "false":
+		return false, nil
+	}
+	return true, nil
+}
+
+// ServerVersion returns the major and minor version of the server,
+// so that migration tooling can branch on features that differ between
+// server versions, e.g. CHECK constraints only being enforced since MySQL 8.
+func (m *Mysql) ServerVersion() (major, minor int, err error) {
+	query := `SELECT VERSION()`
+	var version string
+	if err := m.conn.QueryRowContext(context.Background(), query).Scan(&version); err != nil {
+		return 0, 0, &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+	return parseServerVersion(version)
+}
+
+// parseServerVersion parses the major and minor version from the string
+// returned by VERSION().
+func parseServerVersion(version string) (major, minor int, err error) {
+	// MariaDB prefixes its version with "5.5.5-" for compatibility
+	// with replication clients, i.e. "5.5.5-10.4.13-MariaDB"
+	if strings.Contains(version, "MariaDB") {
+		version = strings.TrimPrefix(version, "5.5.5-")
+	}
+
+	match := serverVersionRegex.FindStringSubmatch(version)
+	if match == nil {
+		return 0, 0, fmt.Errorf("unable to parse server version %q", version)
+	}
+	if major, err = strconv.Atoi(match[1]); err != nil {
+		return 0, 0, err
+	}
+	if minor, err = strconv.Atoi(match[2]); err != nil {
+		return 0, 0, err
+	}
+	return major, minor, nil
+}
+
 func (m *Mysql) SetVersion(version int, dirty bool) error {
+	if err := m.config.VersionColumnType.Check(version); err != nil {
+		return err
+	}
+
+	if m.capture != nil {
+		m.capture.version, m.capture.dirty = version, dirty
+		return nil
+	}
+
 	tx, err := m.conn.BeginTx(context.Background(), &sql.TxOptions{})
 	if err != nil {
 		return &database.Error{OrigErr: err, Err: "transaction start failed"}
 	}
 
-	query := "TRUNCATE `" + m.config.MigrationsTable + "`"
+	query := "TRUNCATE " + m.migrationsTable()
 	if _, err := tx.ExecContext(context.Background(), query); err != nil {
+		msg := ""
+		if isNoSuchTable(err) && m.config.SkipEnsureTable {
+			msg = "migrations table doesn't exist and x-skip-ensure-table is set"
+		}
 		if errRollback := tx.Rollback(); errRollback != nil {
 			err = multierror.Append(err, errRollback)
 		}
-		return &database.Error{OrigErr: err, Query: []byte(query)}
+		return &database.Error{OrigErr: err, Err: msg, Query: []byte(query)}
 	}
 
 	// Also re-write the schema version for nil dirty versions to prevent
 	// empty schema version for failed down migration on the first migration
 	// See: https://github.com/golang-migrate/migrate/issues/330
 	if version >= 0 || (version == database.NilVersion && dirty) {
-		query := "INSERT INTO `" + m.config.MigrationsTable + "` (version, dirty) VALUES (?, ?)"
-		if _, err := tx.ExecContext(context.Background(), query, version, dirty); err != nil {
+		query := "INSERT INTO " + m.migrationsTable() + " (version, dirty) VALUES (?, ?)"
+		args := []interface{}{version, dirty}
+		if m.config.RecordWriter {
+			query = "INSERT INTO " + m.migrationsTable() + " (version, dirty, writer, written_at) VALUES (?, ?, ?, UTC_TIMESTAMP())"
+			args = append(args, m.config.WriterID)
+		}
+		if _, err := tx.ExecContext(context.Background(), query, args...); err != nil {
 			if errRollback := tx.Rollback(); errRollback != nil {
 				err = multierror.Append(err, errRollback)
 			}
@@ -358,7 +862,11 @@ func (m *Mysql) SetVersion(version int, dirty bool) error {
 }
 
 func (m *Mysql) Version() (version int, dirty bool, err error) {
-	query := "SELECT version, dirty FROM `" + m.config.MigrationsTable + "` LIMIT 1"
+	if m.capture != nil {
+		return m.capture.version, m.capture.dirty, nil
+	}
+
+	query := "SELECT version, dirty FROM " + m.migrationsTable() + " LIMIT 1"
 	err = m.conn.QueryRowContext(context.Background(), query).Scan(&version, &dirty)
 	switch {
 	case err == sql.ErrNoRows:
@@ -379,7 +887,7 @@ func (m *Mysql) Version() (version int, dirty bool, err error) {
 
 func (m *Mysql) Drop() (err error) {
 	// select all tables
-	query := `SHOW TABLES LIKE '%'`
+	query := "SHOW TABLES FROM `" + m.config.DatabaseName + "` LIKE '%'"
 	tables, err := m.conn.QueryContext(context.Background(), query)
 	if err != nil {
 		return &database.Error{OrigErr: err, Query: []byte(query)}
@@ -419,7 +927,7 @@ func (m *Mysql) Drop() (err error) {
 
 		// delete one by one ...
 		for _, t := range tableNames {
-			query = "DROP TABLE IF EXISTS `" + t + "`"
+			query = "DROP TABLE IF EXISTS `" + m.config.DatabaseName + "`.`" + t + "`"
 			if _, err := m.conn.ExecContext(context.Background(), query); err != nil {
 				return &database.Error{OrigErr: err, Query: []byte(query)}
 			}
@@ -449,23 +957,90 @@ func (m *Mysql) ensureVersionTable() (err error) {
 
 	// check if migration table exists
 	var result string
-	query := `SHOW TABLES LIKE "` + m.config.MigrationsTable + `"`
+	query := "SHOW TABLES FROM `" + m.config.DatabaseName + "` LIKE \"" + m.config.MigrationsTable + "\""
 	if err := m.conn.QueryRowContext(context.Background(), query).Scan(&result); err != nil {
 		if err != sql.ErrNoRows {
 			return &database.Error{OrigErr: err, Query: []byte(query)}
 		}
 	} else {
+		if m.config.StatementCheckpoints {
+			if err := m.ensureColumn("checkpoint", checkpointColumn); err != nil {
+				return err
+			}
+		}
+		if m.config.RecordWriter {
+			if err := m.ensureColumn("writer", writerColumn); err != nil {
+				return err
+			}
+			if err := m.ensureColumn("written_at", writtenAtColumn); err != nil {
+				return err
+			}
+		}
 		return nil
 	}
 
 	// if not, create the empty migration table
-	query = "CREATE TABLE `" + m.config.MigrationsTable + "` (version bigint not null primary key, dirty boolean not null)"
+	columns := "version " + versionColumnSQLType(m.config.VersionColumnType) + " not null primary key, dirty boolean not null"
+	if m.config.StatementCheckpoints {
+		columns += ", checkpoint " + checkpointColumn
+	}
+	if m.config.RecordWriter {
+		columns += ", writer " + writerColumn + ", written_at " + writtenAtColumn
+	}
+	query = "CREATE TABLE " + m.migrationsTable() + " (" + columns + ")"
 	if _, err := m.conn.ExecContext(context.Background(), query); err != nil {
 		return &database.Error{OrigErr: err, Query: []byte(query)}
 	}
 	return nil
 }
 
+// versionColumnSQLType returns the MySQL type of a version column of type t.
+func versionColumnSQLType(t database.VersionColumnType) string {
+	switch t {
+	case database.VersionColumnInt:
+		return "int"
+	case database.VersionColumnString:
+		return "varchar(255)"
+	default:
+		return "bigint"
+	}
+}
+
+// migrationsTable returns the name of the migrations table qualified with
+// the database, which the session's default database may differ from,
+// e.g. after a migration ran a USE statement or with NoUseDatabase.
+func (m *Mysql) migrationsTable() string {
+	return "`" + m.config.DatabaseName + "`.`" + m.config.MigrationsTable + "`"
+}
+
+// wrapErr wraps err into the error types shared by all database drivers,
+// based on its MySQL error number.
+// See: https://dev.mysql.com/doc/refman/8.0/en/server-error-reference.html
+func wrapErr(err error) error {
+	if errors.Is(err, sqldriver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) {
+		return &database.ConnectionError{Err: err}
+	}
+	if e, ok := err.(*mysql.MySQLError); ok {
+		switch e.Number {
+		case 1048, // ER_BAD_NULL_ERROR
+			1062,       // ER_DUP_ENTRY
+			1216, 1452, // ER_NO_REFERENCED_ROW(_2)
+			1217, 1451, // ER_ROW_IS_REFERENCED(_2)
+			3819: // ER_CHECK_CONSTRAINT_VIOLATED
+			return &database.ConstraintError{Err: err}
+		case 1064: // ER_PARSE_ERROR
+			return &database.SyntaxError{Err: err}
+		}
+	}
+	return err
+}
+
+// isNoSuchTable reports whether err is caused by a missing table.
+func isNoSuchTable(err error) bool {
+	e, ok := err.(*mysql.MySQLError)
+	return ok && e.Number == erNoSuchTable
+}
+
 // Returns the bool value of the input.
 // The 2nd return value indicates if the input was a valid bool value
 // See https://github.com/go-sql-driver/mysql/blob/a059889267dc7170331388008528b3b44479bffb/utils.go#L71
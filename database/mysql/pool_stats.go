@@ -0,0 +1,65 @@
+// +build go1.9
+
+package mysql
+
+import (
+	"database/sql"
+	"time"
+)
+
+// PoolStatsFunc receives the stats of the connection pool while a migration
+// runs. See SetPoolStatsSampler.
+type PoolStatsFunc func(stats sql.DBStats)
+
+// poolStatsSampler calls fn with the stats of the pool every interval.
+type poolStatsSampler struct {
+	interval time.Duration
+	fn       PoolStatsFunc
+}
+
+// PoolStats returns the stats of the connection pool of the driver, e.g. to
+// find migrations waiting for connections.
+func (m *Mysql) PoolStats() sql.DBStats {
+	return m.db.Stats()
+}
+
+// SetPoolStatsSampler calls fn with PoolStats every interval while the
+// following migrations run, e.g. to report them as metrics. fn is called
+// from another goroutine, and never after the migration has returned.
+// A nil fn or an interval of zero resets it.
+func (m *Mysql) SetPoolStatsSampler(interval time.Duration, fn PoolStatsFunc) {
+	if fn == nil || interval <= 0 {
+		m.poolStatsSampler = nil
+		return
+	}
+	m.poolStatsSampler = &poolStatsSampler{interval: interval, fn: fn}
+}
+
+// samplePoolStats starts sampling the stats of the pool, until stop is
+// called. It's a no-op without a sampler.
+func (m *Mysql) samplePoolStats() (stop func()) {
+	s := m.poolStatsSampler
+	if s == nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.fn(m.PoolStats())
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
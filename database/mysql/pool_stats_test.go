@@ -0,0 +1,57 @@
+package mysql
+
+import (
+	"database/sql"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolStats(t *testing.T) {
+	// sql.Open doesn't connect, so no server is needed
+	db, err := sql.Open("mysql", "root:root@tcp(127.0.0.1:1)/public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		assert.NoError(t, db.Close())
+	}()
+	db.SetMaxOpenConns(7)
+
+	m := &Mysql{db: db}
+	assert.Equal(t, 7, m.PoolStats().MaxOpenConnections)
+}
+
+func TestSamplePoolStats(t *testing.T) {
+	db, err := sql.Open("mysql", "root:root@tcp(127.0.0.1:1)/public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		assert.NoError(t, db.Close())
+	}()
+	db.SetMaxOpenConns(3)
+
+	m := &Mysql{db: db}
+	// without a sampler, stop is a no-op
+	m.samplePoolStats()()
+
+	var samples int32
+	m.SetPoolStatsSampler(time.Millisecond, func(stats sql.DBStats) {
+		assert.Equal(t, 3, stats.MaxOpenConnections)
+		atomic.AddInt32(&samples, 1)
+	})
+	stop := m.samplePoolStats()
+	time.Sleep(20 * time.Millisecond)
+	stop()
+
+	n := atomic.LoadInt32(&samples)
+	assert.True(t, n > 0, "expected pool stats to be sampled")
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, n, atomic.LoadInt32(&samples), "expected no samples after stop")
+
+	m.SetPoolStatsSampler(0, nil)
+	assert.Nil(t, m.poolStatsSampler)
+}
@@ -0,0 +1,52 @@
+// +build go1.9
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// ErrReadOnlyServer is returned when connecting to a server with read_only
+// or super_read_only set, e.g. a replica, unless AllowReadOnly is set.
+var ErrReadOnlyServer = errors.New("server is read-only (read_only or super_read_only is set), migrations would fail")
+
+// erUnknownSystemVariable is the ER_UNKNOWN_SYSTEM_VARIABLE error code,
+// returned for super_read_only by MariaDB and MySQL before 5.7.8.
+const erUnknownSystemVariable = 1193
+
+// checkReadOnly returns ErrReadOnlyServer if the server doesn't accept
+// writes.
+func checkReadOnly(ctx context.Context, db *sql.DB) error {
+	query := `SELECT @@read_only`
+	var readOnly bool
+	if err := db.QueryRowContext(ctx, query).Scan(&readOnly); err != nil {
+		return &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+	if readOnly {
+		return ErrReadOnlyServer
+	}
+
+	query = `SELECT @@super_read_only`
+	var superReadOnly bool
+	if err := db.QueryRowContext(ctx, query).Scan(&superReadOnly); err != nil {
+		if isUnknownSystemVariable(err) {
+			return nil
+		}
+		return &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+	if superReadOnly {
+		return ErrReadOnlyServer
+	}
+	return nil
+}
+
+func isUnknownSystemVariable(err error) bool {
+	var e *mysql.MySQLError
+	return errors.As(err, &e) && e.Number == erUnknownSystemVariable
+}
@@ -0,0 +1,191 @@
+// +build go1.9
+
+package mysql
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// DefaultStreamMaxStatementSize is the default max size of a statement of a
+// migration run with StreamStatements.
+const DefaultStreamMaxStatementSize = 10 * 1 << 20 // 10 MB
+
+// ErrStatementTooLarge is returned for statements larger than
+// StreamMaxStatementSize.
+var ErrStatementTooLarge = errors.New("statement too large")
+
+// runStreaming runs migration statement by statement as it's read, so only
+// a single statement is held in memory at a time.
+func (m *Mysql) runStreaming(ctx context.Context, migration io.Reader) (err error) {
+	defer m.samplePoolStats()()
+
+	conn := m.conn
+	if m.config.IsolateSessions {
+		if conn, err = m.db.Conn(ctx); err != nil {
+			return err
+		}
+		defer func() {
+			if errClose := conn.Close(); errClose != nil {
+				err = multierror.Append(err, errClose)
+			}
+		}()
+	}
+	if len(m.config.WaitGTIDSet) > 0 {
+		defer func() {
+			if err == nil {
+				err = waitForGTIDSet(ctx, conn, m.config.WaitGTIDSet, m.config.WaitGTIDTimeout)
+			}
+		}()
+	}
+
+	return scanStatements(migration, m.config.StreamMaxStatementSize, func(statement string) error {
+		if err := execDrained(ctx, conn, statement); err != nil {
+			return database.Error{OrigErr: wrapErr(err), Err: "migration failed", Query: []byte(statement)}
+		}
+		return nil
+	})
+}
+
+// scanStatements reads the statements of migration separated by semicolons,
+// like splitSQL, and calls fn with each of them as soon as it has been read.
+// Statements consisting of comments only are skipped. Statements larger than
+// maxSize fail with ErrStatementTooLarge, unless maxSize is zero.
+func scanStatements(migration io.Reader, maxSize int, fn func(statement string) error) error {
+	s := &statementScanner{r: bufio.NewReader(migration), maxSize: maxSize}
+	for {
+		statement, err := s.next()
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if statement = strings.TrimSpace(statement); stripComments(statement) != "" {
+			if errFn := fn(statement); errFn != nil {
+				return errFn
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// statementScanner splits a migration into statements while reading it.
+type statementScanner struct {
+	r         *bufio.Reader
+	maxSize   int
+	statement bytes.Buffer
+}
+
+// next returns the next statement, or the last one along with io.EOF.
+func (s *statementScanner) next() (string, error) {
+	s.statement.Reset()
+	for {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			return s.statement.String(), err
+		}
+
+		switch {
+		case c == ';':
+			return s.statement.String(), nil
+		case c == '\'' || c == '"' || c == '`':
+			// copy up to the closing quote, honoring backslash escapes
+			err = s.copyQuoted(c)
+		case c == '#' || (c == '-' && s.lineCommentFollows()):
+			err = s.copyUntil(string(c), "\n")
+		case c == '/' && s.peek() == '*':
+			_, _ = s.r.ReadByte()
+			err = s.copyUntil("/*", "*/")
+		default:
+			err = s.write(c)
+		}
+		if err != nil {
+			return s.statement.String(), err
+		}
+	}
+}
+
+// write appends c to the statement, failing if it gets too large.
+func (s *statementScanner) write(c byte) error {
+	s.statement.WriteByte(c)
+	if s.maxSize > 0 && s.statement.Len() > s.maxSize {
+		return fmt.Errorf("%w: more than %v bytes", ErrStatementTooLarge, s.maxSize)
+	}
+	return nil
+}
+
+// peek returns the next byte, or 0 at the end of the migration.
+func (s *statementScanner) peek() byte {
+	b, err := s.r.Peek(1)
+	if err != nil {
+		return 0
+	}
+	return b[0]
+}
+
+// lineCommentFollows reports whether a `-` read is followed by `-` and a
+// white space or the end of the migration, starting a comment.
+func (s *statementScanner) lineCommentFollows() bool {
+	b, _ := s.r.Peek(2)
+	return len(b) > 0 && b[0] == '-' && (len(b) == 1 || isSpace(b[1]))
+}
+
+// copyQuoted copies the string starting with quote to the statement. A
+// string left open is copied up to the end of the migration.
+func (s *statementScanner) copyQuoted(quote byte) error {
+	if err := s.write(quote); err != nil {
+		return err
+	}
+	for {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if err := s.write(c); err != nil {
+			return err
+		}
+		if c == '\\' && quote != '`' {
+			e, err := s.r.ReadByte()
+			if err != nil {
+				return err
+			}
+			if err := s.write(e); err != nil {
+				return err
+			}
+		} else if c == quote {
+			return nil
+		}
+	}
+}
+
+// copyUntil copies the comment starting with prefix, which has been read
+// already, to the statement, up to and including end.
+func (s *statementScanner) copyUntil(prefix string, end string) error {
+	for i := 0; i < len(prefix); i++ {
+		if err := s.write(prefix[i]); err != nil {
+			return err
+		}
+	}
+	start := s.statement.Len()
+	for {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if err := s.write(c); err != nil {
+			return err
+		}
+		if s.statement.Len()-start >= len(end) && bytes.HasSuffix(s.statement.Bytes(), []byte(end)) {
+			return nil
+		}
+	}
+}
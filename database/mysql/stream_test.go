@@ -0,0 +1,102 @@
+// +build go1.9
+
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanStatements(t *testing.T) {
+	testcases := []struct {
+		name      string
+		migration string
+	}{
+		{name: "single", migration: "ALTER TABLE t ADD COLUMN c int"},
+		{name: "multiple", migration: "CREATE TABLE t (a int);\nALTER TABLE t ADD COLUMN c int;\n"},
+		{name: "quoted semicolons", migration: `INSERT INTO t VALUES ('a;b', "c;d", 'it\'s;'); UPDATE ` + "`a;b`" + ` SET c = 1`},
+		{name: "comments", migration: "-- first; not split\nCREATE TABLE t (a int); /* x; y */ DROP TABLE u; # trailing;"},
+		{name: "short comments", migration: "#\nSELECT 1; /**/ SELECT 2; /*/ ; */ SELECT 3;--\nSELECT 4"},
+		{name: "minus", migration: "SELECT 1--1; SELECT 2"},
+		{name: "open string", migration: "SELECT 1; SELECT 'a;b"},
+		{name: "empty", migration: " ;\n; "},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var statements []string
+			err := scanStatements(strings.NewReader(tc.migration), 0, func(statement string) error {
+				statements = append(statements, statement)
+				return nil
+			})
+			if assert.NoError(t, err) {
+				assert.Equal(t, splitSQL(tc.migration), statements)
+			}
+		})
+	}
+}
+
+// seedReader generates n INSERT statements while being read, without
+// holding them in memory.
+type seedReader struct {
+	n, i    int
+	pending []byte
+}
+
+func (r *seedReader) Read(p []byte) (int, error) {
+	if len(r.pending) == 0 {
+		if r.i == r.n {
+			return 0, io.EOF
+		}
+		r.i++
+		r.pending = []byte(fmt.Sprintf("INSERT INTO seed VALUES (%d, 'row;%d');\n", r.i, r.i))
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+func TestScanStatementsLarge(t *testing.T) {
+	// ~4 MB of statements, each far below the max statement size
+	const n = 100000
+	r := &seedReader{n: n}
+
+	count := 0
+	err := scanStatements(r, 64, func(statement string) error {
+		count++
+		// statements are passed on while the migration is being read
+		assert.True(t, r.i-count <= 1, "read %v statements ahead", r.i-count)
+		return nil
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, n, count)
+	}
+}
+
+func TestScanStatementsTooLarge(t *testing.T) {
+	migration := "SELECT 1; SELECT '" + strings.Repeat("a", 100) + "'; SELECT 3"
+
+	var statements []string
+	err := scanStatements(strings.NewReader(migration), 64, func(statement string) error {
+		statements = append(statements, statement)
+		return nil
+	})
+	assert.True(t, errors.Is(err, ErrStatementTooLarge), "expected ErrStatementTooLarge, got %v", err)
+	assert.Equal(t, []string{"SELECT 1"}, statements)
+}
+
+func TestScanStatementsError(t *testing.T) {
+	failed := errors.New("failed")
+	calls := 0
+	err := scanStatements(strings.NewReader("SELECT 1; SELECT 2; SELECT 3"), 0, func(string) error {
+		calls++
+		return failed
+	})
+	assert.Equal(t, failed, err)
+	assert.Equal(t, 1, calls)
+}
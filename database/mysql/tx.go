@@ -0,0 +1,50 @@
+// +build go1.9
+
+package mysql
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrOpenTransaction is returned for migrations starting a transaction
+// without ending it, if RespectExplicitTx is set.
+var ErrOpenTransaction = errors.New("migration starts a transaction without ending it")
+
+// explicitTx reports whether migration controls transactions itself with
+// START TRANSACTION, BEGIN, COMMIT or ROLLBACK statements, and whether the
+// last transaction started is left open. Rolling back to a savepoint
+// doesn't end the transaction.
+func explicitTx(migration string) (explicit bool, open bool) {
+	for _, s := range splitStatements(migration) {
+		switch {
+		case hasKeyword(s, "BEGIN", "START TRANSACTION"):
+			explicit, open = true, true
+		case hasKeyword(s, "COMMIT"), hasKeyword(s, "ROLLBACK") && !isSavepoint(s):
+			explicit, open = true, false
+		}
+	}
+	return explicit, open
+}
+
+// isSavepoint reports whether the upper-cased statement sets, rolls back
+// to or releases a savepoint, i.e. SAVEPOINT s, ROLLBACK [WORK] TO s or
+// RELEASE SAVEPOINT s, leaving the transaction open.
+func isSavepoint(statement string) bool {
+	fields := strings.Fields(statement)
+	if len(fields) < 2 {
+		return false
+	}
+	switch fields[0] {
+	case "SAVEPOINT":
+		return true
+	case "RELEASE":
+		return fields[1] == "SAVEPOINT"
+	case "ROLLBACK":
+		if fields[1] == "WORK" {
+			fields = fields[1:]
+		}
+		return len(fields) > 1 && fields[1] == "TO"
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package mysql
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplicitTx(t *testing.T) {
+	testcases := []struct {
+		name      string
+		migration string
+		explicit  bool
+		open      bool
+	}{
+		{name: "no transaction", migration: "CREATE TABLE t (a int); INSERT INTO t VALUES (1);"},
+		{name: "start transaction", migration: "START TRANSACTION; INSERT INTO t VALUES (1); COMMIT;", explicit: true},
+		{name: "begin", migration: "begin;\nUPDATE t SET a = 2;\nrollback;", explicit: true},
+		{name: "after comments", migration: "-- move rows\nBEGIN; DELETE FROM t; /* done */ COMMIT", explicit: true},
+		{name: "left open", migration: "START TRANSACTION; INSERT INTO t VALUES (1);", explicit: true, open: true},
+		{name: "reopened", migration: "BEGIN; DELETE FROM t; COMMIT; BEGIN; DELETE FROM u;", explicit: true, open: true},
+		{name: "savepoint", migration: "BEGIN; SAVEPOINT s; DELETE FROM t; ROLLBACK TO s;", explicit: true, open: true},
+		{name: "savepoint committed", migration: "BEGIN; SAVEPOINT s; DELETE FROM t; ROLLBACK TO SAVEPOINT s; COMMIT;", explicit: true},
+		{name: "rollback work to savepoint", migration: "BEGIN; SAVEPOINT s; DELETE FROM t; rollback work\n  to s;", explicit: true, open: true},
+		{name: "release savepoint", migration: "START TRANSACTION; SAVEPOINT s; DELETE FROM t; RELEASE SAVEPOINT s;", explicit: true, open: true},
+		{name: "rollback work", migration: "BEGIN; DELETE FROM t; ROLLBACK WORK;", explicit: true},
+		{name: "column named begin", migration: "CREATE TABLE t (beginning int, committed int);"},
+		{name: "procedure", migration: "CREATE PROCEDURE p() BEGIN SELECT 1; END"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			explicit, open := explicitTx(tc.migration)
+			assert.Equal(t, tc.explicit, explicit)
+			assert.Equal(t, tc.open, open)
+		})
+	}
+}
+
+// recordingLogger is a database.Logger recording the logged messages.
+type recordingLogger struct {
+	messages []string
+}
+
+func (l *recordingLogger) Printf(format string, v ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, v...))
+}
+
+func TestLogPrintf(t *testing.T) {
+	logger := &recordingLogger{}
+	m := &Mysql{config: &Config{Logger: logger}}
+	m.logPrintf("mysql: %v", "warning")
+	assert.Equal(t, []string{"mysql: warning"}, logger.messages)
+}
@@ -0,0 +1,57 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// ErrNoRecordWriter is returned when reading the last writer of the
+// migrations table without RecordWriter being set.
+var ErrNoRecordWriter = errors.New("recording writers not enabled, see x-record-writer")
+
+// Definitions of the columns recording the writer of the migrations table.
+const (
+	writerColumn    = "varchar(255) not null default ''"
+	writtenAtColumn = "datetime null"
+)
+
+// LastWriter returns the writer id of the process which last set the
+// version, and the time it did so in UTC. The writer is empty if no
+// version has been set yet.
+func (m *Mysql) LastWriter() (writer string, at time.Time, err error) {
+	if !m.config.RecordWriter {
+		return "", time.Time{}, ErrNoRecordWriter
+	}
+	if m.capture != nil {
+		return "", time.Time{}, nil
+	}
+
+	var writtenAt mysql.NullTime
+	query := "SELECT writer, written_at FROM " + m.migrationsTable() + " LIMIT 1"
+	err = m.conn.QueryRowContext(context.Background(), query).Scan(&writer, &writtenAt)
+	switch {
+	case err == sql.ErrNoRows:
+		return "", time.Time{}, nil
+	case err != nil:
+		return "", time.Time{}, &database.Error{OrigErr: err, Query: []byte(query)}
+	default:
+		return writer, writtenAt.Time, nil
+	}
+}
+
+// defaultWriterID identifies the running process by hostname and pid.
+func defaultWriterID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%v:%v", hostname, os.Getpid())
+}
@@ -0,0 +1,20 @@
+package database
+
+import (
+	"github.com/golang-migrate/migrate/v4/internal/plugin"
+)
+
+// ErrPluginVersionMismatch is returned by LoadPlugin if the plugin was built
+// with another version of Go or migrate than the program loading it.
+var ErrPluginVersionMismatch = plugin.ErrVersionMismatch
+
+// LoadPlugin loads a database driver built as a Go plugin, e.g. with
+// `go build -buildmode=plugin`, so closed-source drivers don't need to be
+// compiled in. The plugin must export a `func Register()` registering the
+// driver with Register, making its scheme available to Open.
+// Plugins are only supported on Linux, macOS and FreeBSD with cgo enabled,
+// and must be built with the same Go version and migrate version as the
+// program.
+func LoadPlugin(path string) error {
+	return plugin.Load(path)
+}
@@ -0,0 +1,75 @@
+// +build go1.9
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/lib/pq"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// baselineTable returns the quoted name of the table recording the
+// baseline version. It's kept apart from the migrations table, which is
+// truncated whenever the version is written.
+func (p *Postgres) baselineTable() string {
+	return pq.QuoteIdentifier(p.config.MigrationsTable + "_baseline")
+}
+
+// SetBaseline implements database.Baseliner. The table recording the
+// baseline version is created on first use.
+func (p *Postgres) SetBaseline(version int) error {
+	tx, err := p.conn.BeginTx(context.Background(), &sql.TxOptions{})
+	if err != nil {
+		return &database.Error{OrigErr: err, Err: "transaction start failed"}
+	}
+
+	for _, query := range []string{
+		`CREATE TABLE IF NOT EXISTS ` + p.baselineTable() + ` (version bigint not null)`,
+		`TRUNCATE ` + p.baselineTable(),
+	} {
+		if _, err := tx.Exec(query); err != nil {
+			if errRollback := tx.Rollback(); errRollback != nil {
+				err = multierror.Append(err, errRollback)
+			}
+			return &database.Error{OrigErr: err, Query: []byte(query)}
+		}
+	}
+
+	query := `INSERT INTO ` + p.baselineTable() + ` (version) VALUES ($1)`
+	if _, err := tx.Exec(query, version); err != nil {
+		if errRollback := tx.Rollback(); errRollback != nil {
+			err = multierror.Append(err, errRollback)
+		}
+		return &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return &database.Error{OrigErr: err, Err: "transaction commit failed"}
+	}
+	return nil
+}
+
+// Baseline implements database.Baseliner.
+func (p *Postgres) Baseline() (version int, err error) {
+	query := `SELECT version FROM ` + p.baselineTable() + ` LIMIT 1`
+	err = p.conn.QueryRowContext(context.Background(), query).Scan(&version)
+	switch {
+	case err == sql.ErrNoRows:
+		return database.NilVersion, nil
+
+	case err != nil:
+		if e, ok := err.(*pq.Error); ok {
+			if e.Code.Name() == "undefined_table" {
+				return database.NilVersion, nil
+			}
+		}
+		return 0, &database.Error{OrigErr: err, Query: []byte(query)}
+
+	default:
+		return version, nil
+	}
+}
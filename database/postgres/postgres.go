@@ -5,6 +5,8 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -47,6 +49,9 @@ type Postgres struct {
 	db       *sql.DB
 	isLocked bool
 
+	// tx is the transaction started by Begin, if any
+	tx *sql.Tx
+
 	// Open and WithInstance need to guarantee that config is never nil
 	config *Config
 }
@@ -145,6 +150,11 @@ func (p *Postgres) Open(url string) (database.Driver, error) {
 	return px, nil
 }
 
+// Ping implements database.Pinger.
+func (p *Postgres) Ping(ctx context.Context) error {
+	return p.conn.PingContext(ctx)
+}
+
 func (p *Postgres) Close() error {
 	connErr := p.conn.Close()
 	dbErr := p.db.Close()
@@ -194,19 +204,24 @@ func (p *Postgres) Unlock() error {
 }
 
 func (p *Postgres) Run(migration io.Reader) error {
+	return p.RunContext(context.Background(), migration)
+}
+
+// RunContext runs the migration, aborting it once ctx is done.
+// StatementTimeout only applies if ctx has no deadline.
+func (p *Postgres) RunContext(ctx context.Context, migration io.Reader) error {
 	migr, err := ioutil.ReadAll(migration)
 	if err != nil {
 		return err
 	}
-	ctx := context.Background()
-	if p.config.StatementTimeout != 0 {
+	if _, ok := ctx.Deadline(); !ok && p.config.StatementTimeout != 0 {
 		var cancel context.CancelFunc
 		ctx, cancel = context.WithTimeout(ctx, p.config.StatementTimeout)
 		defer cancel()
 	}
 	// run migration
 	query := string(migr[:])
-	if _, err := p.conn.ExecContext(ctx, query); err != nil {
+	if _, err := p.querier().ExecContext(ctx, query); err != nil {
 		if pgErr, ok := err.(*pq.Error); ok {
 			var line uint
 			var col uint
@@ -223,14 +238,34 @@ func (p *Postgres) Run(migration io.Reader) error {
 			if pgErr.Detail != "" {
 				message = fmt.Sprintf("%s, %s", message, pgErr.Detail)
 			}
-			return database.Error{OrigErr: err, Err: message, Query: migr, Line: line}
+			return database.Error{OrigErr: wrapErr(err), Err: message, Query: migr, Line: line}
 		}
-		return database.Error{OrigErr: err, Err: "migration failed", Query: migr}
+		return database.Error{OrigErr: wrapErr(err), Err: "migration failed", Query: migr}
 	}
 
 	return nil
 }
 
+// wrapErr wraps err into the error types shared by all database drivers,
+// based on the class of its SQLSTATE code.
+// See: https://www.postgresql.org/docs/current/errcodes-appendix.html
+func wrapErr(err error) error {
+	if errors.Is(err, sqldriver.ErrBadConn) {
+		return &database.ConnectionError{Err: err}
+	}
+	if e, ok := err.(*pq.Error); ok {
+		switch {
+		case e.Code.Class() == "23":
+			return &database.ConstraintError{Err: err}
+		case e.Code.Class() == "08":
+			return &database.ConnectionError{Err: err}
+		case e.Code == "42601":
+			return &database.SyntaxError{Err: err}
+		}
+	}
+	return err
+}
+
 func computeLineFromPos(s string, pos int) (line uint, col uint, ok bool) {
 	// replace crlf with lf
 	s = strings.Replace(s, "\r\n", "\n", -1)
@@ -266,17 +301,36 @@ func runesLastIndex(input []rune, target rune) int {
 	return -1
 }
 
+// SetVersion writes the version in a transaction of its own, or in the
+// transaction started by Begin.
 func (p *Postgres) SetVersion(version int, dirty bool) error {
+	if p.tx != nil {
+		return p.setVersion(p.tx, version, dirty)
+	}
+
 	tx, err := p.conn.BeginTx(context.Background(), &sql.TxOptions{})
 	if err != nil {
 		return &database.Error{OrigErr: err, Err: "transaction start failed"}
 	}
 
-	query := `TRUNCATE ` + pq.QuoteIdentifier(p.config.MigrationsTable)
-	if _, err := tx.Exec(query); err != nil {
+	if err := p.setVersion(tx, version, dirty); err != nil {
 		if errRollback := tx.Rollback(); errRollback != nil {
 			err = multierror.Append(err, errRollback)
 		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return &database.Error{OrigErr: err, Err: "transaction commit failed"}
+	}
+
+	return nil
+}
+
+// setVersion replaces the version in the migrations table within tx.
+func (p *Postgres) setVersion(tx *sql.Tx, version int, dirty bool) error {
+	query := `TRUNCATE ` + pq.QuoteIdentifier(p.config.MigrationsTable)
+	if _, err := tx.Exec(query); err != nil {
 		return &database.Error{OrigErr: err, Query: []byte(query)}
 	}
 
@@ -287,23 +341,16 @@ func (p *Postgres) SetVersion(version int, dirty bool) error {
 		query = `INSERT INTO ` + pq.QuoteIdentifier(p.config.MigrationsTable) +
 			` (version, dirty) VALUES ($1, $2)`
 		if _, err := tx.Exec(query, version, dirty); err != nil {
-			if errRollback := tx.Rollback(); errRollback != nil {
-				err = multierror.Append(err, errRollback)
-			}
 			return &database.Error{OrigErr: err, Query: []byte(query)}
 		}
 	}
 
-	if err := tx.Commit(); err != nil {
-		return &database.Error{OrigErr: err, Err: "transaction commit failed"}
-	}
-
 	return nil
 }
 
 func (p *Postgres) Version() (version int, dirty bool, err error) {
 	query := `SELECT version, dirty FROM ` + pq.QuoteIdentifier(p.config.MigrationsTable) + ` LIMIT 1`
-	err = p.conn.QueryRowContext(context.Background(), query).Scan(&version, &dirty)
+	err = p.querier().QueryRowContext(context.Background(), query).Scan(&version, &dirty)
 	switch {
 	case err == sql.ErrNoRows:
 		return database.NilVersion, false, nil
@@ -321,6 +368,30 @@ func (p *Postgres) Version() (version int, dirty bool, err error) {
 	}
 }
 
+// Verify implements database.Verifier. query must return a single column,
+// which is truthy unless it is NULL, empty, 0 or false. It runs in the
+// transaction started by Begin, if any, so Migrate rolls back a migration
+// failing its verification.
+func (p *Postgres) Verify(query string) (bool, error) {
+	var result sql.NullString
+	err := p.querier().QueryRowContext(context.Background(), query).Scan(&result)
+	switch {
+	case err == sql.ErrNoRows:
+		return false, nil
+	case err != nil:
+		return false, &database.Error{OrigErr: err, Err: "verification failed", Query: []byte(query)}
+	}
+
+	if !result.Valid {
+		return false, nil
+	}
+	switch strings.ToLower(result.String) {
+	case "", "0", "false", "f":
+		return false, nil
+	}
+	return true, nil
+}
+
 func (p *Postgres) Drop() (err error) {
 	// select all tables in current schema
 	query := `SELECT table_name FROM information_schema.tables WHERE table_schema=(SELECT current_schema()) AND table_type='BASE TABLE'`
@@ -6,6 +6,7 @@ import (
 	"context"
 	"database/sql"
 	sqldriver "database/sql/driver"
+	"errors"
 	"fmt"
 	"log"
 
@@ -17,11 +18,14 @@ import (
 	"testing"
 
 	"github.com/dhui/dktest"
+	"github.com/lib/pq"
 
 	"github.com/golang-migrate/migrate/v4/database"
 	dt "github.com/golang-migrate/migrate/v4/database/testing"
 	"github.com/golang-migrate/migrate/v4/dktesting"
+	"github.com/golang-migrate/migrate/v4/source"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
 )
 
 const (
@@ -155,6 +159,272 @@ func TestMultiStatement(t *testing.T) {
 	})
 }
 
+func TestTransactioner(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.FirstPort()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := pgConnectionString(ip, port)
+		p := &Postgres{}
+		d, err := p.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+		dt.TestTransactioner(t, d)
+
+		// DDL is rolled back along with the version
+		tx := d.(database.Transactioner)
+		if err := tx.Begin(); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.Run(strings.NewReader("CREATE TABLE foo (foo text)")); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Rollback(); err != nil {
+			t.Fatal(err)
+		}
+		if tableExists(t, d, "foo") {
+			t.Fatalf("expected table foo to be rolled back")
+		}
+	})
+}
+
+func TestUpPhase(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.FirstPort()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := pgConnectionString(ip, port)
+		p := &Postgres{}
+		d, err := p.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+		m := newStubMigrate(t, d,
+			"-- migrate:phase expand\nCREATE TABLE foo (foo text)",
+			"-- migrate:phase expand\nCREATE TABLE bar (bar text); SELECT * FROM missing",
+		)
+		if err := m.UpPhase("expand"); err == nil {
+			t.Fatal("expected the phase to fail")
+		}
+		version, dirty, err := m.Version()
+		if !errors.Is(err, migrate.ErrNilVersion) {
+			t.Fatalf("expected no version, got %v (dirty: %v, err: %v)", version, dirty, err)
+		}
+		if tableExists(t, d, "foo") {
+			t.Fatalf("expected table foo of the failed phase to be rolled back")
+		}
+	})
+}
+
+func TestDryRun(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.FirstPort()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := pgConnectionString(ip, port)
+		p := &Postgres{}
+		d, err := p.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+		m := newStubMigrate(t, d, "CREATE TABLE foo (foo text)", "CREATE TABLE bar (bar text)")
+		if err := m.DryRun(); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := m.Version(); !errors.Is(err, migrate.ErrNilVersion) {
+			t.Fatalf("expected no version after a dry run, got %v", err)
+		}
+		if tableExists(t, d, "foo") {
+			t.Fatalf("expected table foo of the dry run to be rolled back")
+		}
+
+		m = newStubMigrate(t, d, "CREATE TABLE foo (foo text)", "SELECT * FROM missing")
+		if err := m.DryRun(); err == nil {
+			t.Fatal("expected the dry run to fail with the error of the migration")
+		}
+		if _, _, err := m.Version(); !errors.Is(err, migrate.ErrNilVersion) {
+			t.Fatalf("expected no version after a failed dry run, got %v", err)
+		}
+	})
+}
+
+func TestUpAtomic(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.FirstPort()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := pgConnectionString(ip, port)
+		p := &Postgres{}
+		d, err := p.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+		m := newStubMigrate(t, d,
+			"CREATE TABLE foo (foo text)",
+			"CREATE TABLE bar (bar text)",
+			"CREATE TABLE baz (baz text)",
+			"SELECT * FROM missing",
+		)
+		if err := m.Steps(1); err != nil {
+			t.Fatal(err)
+		}
+
+		// the last migration fails, rolling back the ones before it
+		if err := m.UpAtomic(); err == nil {
+			t.Fatal("expected UpAtomic to fail")
+		}
+		version, dirty, err := m.Version()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != 1 || dirty {
+			t.Fatalf("expected clean version 1, got %v (dirty: %v)", version, dirty)
+		}
+		if tableExists(t, d, "bar") || tableExists(t, d, "baz") {
+			t.Fatalf("expected tables bar and baz to be rolled back")
+		}
+		if !tableExists(t, d, "foo") {
+			t.Fatalf("expected table foo applied before UpAtomic to be kept")
+		}
+
+		m = newStubMigrate(t, d,
+			"CREATE TABLE foo (foo text)",
+			"CREATE TABLE bar (bar text)",
+			"CREATE TABLE baz (baz text)",
+		)
+		if err := m.UpAtomic(); err != nil {
+			t.Fatal(err)
+		}
+		version, dirty, err = m.Version()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != 3 || dirty {
+			t.Fatalf("expected clean version 3, got %v (dirty: %v)", version, dirty)
+		}
+		if !tableExists(t, d, "baz") {
+			t.Fatalf("expected table baz to be created")
+		}
+	})
+}
+
+func TestVerify(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.FirstPort()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := pgConnectionString(ip, port)
+		p := &Postgres{}
+		d, err := p.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+		m := newStubMigrate(t, d,
+			"CREATE TABLE foo (foo text)",
+			"-- migrate:verify SELECT COUNT(*) FROM bar\nCREATE TABLE bar (bar text)",
+		)
+
+		// the failed verification rolls back the migration
+		err = m.Up()
+		if !errors.As(err, &migrate.ErrVerificationFailed{}) {
+			t.Fatalf("expected ErrVerificationFailed, got %v", err)
+		}
+		version, dirty, err := m.Version()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != 1 || dirty {
+			t.Fatalf("expected clean version 1, got %v (dirty: %v)", version, dirty)
+		}
+		if tableExists(t, d, "bar") {
+			t.Fatalf("expected table bar to be rolled back")
+		}
+	})
+}
+
+// newStubMigrate returns a Migrate applying the given up migrations as
+// versions 1, 2, ... to d.
+func newStubMigrate(t *testing.T, d database.Driver, migrations ...string) *migrate.Migrate {
+	src, err := sStub.WithInstance(nil, &sStub.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, migration := range migrations {
+		src.(*sStub.Stub).Migrations.Append(&source.Migration{Version: uint(i + 1), Direction: source.Up, Identifier: migration})
+	}
+	m, err := migrate.NewWithInstance("stub", src, "postgres", d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func tableExists(t *testing.T, d database.Driver, table string) bool {
+	var exists bool
+	if err := d.(*Postgres).conn.QueryRowContext(context.Background(), "SELECT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_name = $1 AND table_schema = (SELECT current_schema()))", table).Scan(&exists); err != nil {
+		t.Fatal(err)
+	}
+	return exists
+}
+
+func TestBaseliner(t *testing.T) {
+	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
+		ip, port, err := c.FirstPort()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr := pgConnectionString(ip, port)
+		p := &Postgres{}
+		d, err := p.Open(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := d.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+		dt.TestBaseliner(t, d)
+	})
+}
+
 func TestErrorParsing(t *testing.T) {
 	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
 		ip, port, err := c.FirstPort()
@@ -180,10 +450,41 @@ func TestErrorParsing(t *testing.T) {
 			t.Fatal("expected err but got nil")
 		} else if err.Error() != wantErr {
 			t.Fatalf("expected '%s' but got '%s'", wantErr, err.Error())
+		} else if serr := (*database.SyntaxError)(nil); !errors.As(err, &serr) {
+			t.Fatalf("expected *database.SyntaxError but got %T", err)
 		}
 	})
 }
 
+func Test_wrapErr(t *testing.T) {
+	testcases := []struct {
+		err  error
+		want error
+	}{
+		{&pq.Error{Code: "23505"}, database.ErrConstraint}, // unique_violation
+		{&pq.Error{Code: "23503"}, database.ErrConstraint}, // foreign_key_violation
+		{&pq.Error{Code: "08006"}, database.ErrConnection}, // connection_failure
+		{sqldriver.ErrBadConn, database.ErrConnection},
+		{&pq.Error{Code: "42601"}, database.ErrSyntax}, // syntax_error
+	}
+	for i, tc := range testcases {
+		t.Run("tc"+strconv.Itoa(i), func(t *testing.T) {
+			err := error(database.Error{OrigErr: wrapErr(tc.err), Err: "migration failed"})
+			if !errors.Is(err, tc.want) {
+				t.Errorf("expected %v to match %v", err, tc.want)
+			}
+			if !errors.Is(err, tc.err) {
+				t.Errorf("expected %v to unwrap to %v", err, tc.err)
+			}
+		})
+	}
+
+	// undefined_table
+	if err := wrapErr(&pq.Error{Code: "42P01"}); errors.Is(err, database.ErrSyntax) {
+		t.Errorf("expected undefined_table not to be a syntax error, got %T", err)
+	}
+}
+
 func TestFilterCustomQuery(t *testing.T) {
 	dktesting.ParallelTest(t, specs, func(t *testing.T, c dktest.ContainerInfo) {
 		ip, port, err := c.FirstPort()
@@ -0,0 +1,70 @@
+// +build go1.9
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// querier is implemented by *sql.Conn and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// querier returns the transaction started by Begin, if any, or else the
+// connection of p.
+func (p *Postgres) querier() querier {
+	if p.tx != nil {
+		return p.tx
+	}
+	return p.conn
+}
+
+// Begin implements database.Transactioner. The transaction runs on the
+// connection holding the lock, and spans Run, SetVersion and Version until
+// Commit or Rollback is called. Migrations run in it must not contain BEGIN
+// or COMMIT statements of their own.
+func (p *Postgres) Begin() error {
+	if p.tx != nil {
+		return errors.New("transaction already started")
+	}
+	tx, err := p.conn.BeginTx(context.Background(), &sql.TxOptions{})
+	if err != nil {
+		return &database.Error{OrigErr: err, Err: "transaction start failed"}
+	}
+	p.tx = tx
+	return nil
+}
+
+// Commit implements database.Transactioner. If it fails, the transaction
+// is still ended by Rollback.
+func (p *Postgres) Commit() error {
+	if p.tx == nil {
+		return errors.New("no transaction started")
+	}
+	if err := p.tx.Commit(); err != nil {
+		return &database.Error{OrigErr: err, Err: "transaction commit failed"}
+	}
+	p.tx = nil
+	return nil
+}
+
+// Rollback implements database.Transactioner.
+func (p *Postgres) Rollback() error {
+	if p.tx == nil {
+		return errors.New("no transaction started")
+	}
+	tx := p.tx
+	p.tx = nil
+	// a failed Commit already ended the transaction
+	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		return &database.Error{OrigErr: err, Err: "transaction rollback failed"}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package sqlite3
+
+import (
+	"database/sql"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// baselineTable returns the name of the table recording the baseline
+// version. It's kept apart from the migrations table, whose rows are
+// replaced whenever the version is written.
+func (m *Sqlite) baselineTable() string {
+	return m.config.MigrationsTable + "_baseline"
+}
+
+// SetBaseline implements database.Baseliner. The table recording the
+// baseline version is created on first use.
+func (m *Sqlite) SetBaseline(version int) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return &database.Error{OrigErr: err, Err: "transaction start failed"}
+	}
+
+	for _, query := range []string{
+		"CREATE TABLE IF NOT EXISTS " + m.baselineTable() + " (version uint64 NOT NULL)",
+		"DELETE FROM " + m.baselineTable(),
+	} {
+		if _, err := tx.Exec(query); err != nil {
+			if errRollback := tx.Rollback(); errRollback != nil {
+				err = multierror.Append(err, errRollback)
+			}
+			return &database.Error{OrigErr: err, Query: []byte(query)}
+		}
+	}
+
+	query := "INSERT INTO " + m.baselineTable() + " (version) VALUES (?)"
+	if _, err := tx.Exec(query, version); err != nil {
+		if errRollback := tx.Rollback(); errRollback != nil {
+			err = multierror.Append(err, errRollback)
+		}
+		return &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return &database.Error{OrigErr: err, Err: "transaction commit failed"}
+	}
+	return nil
+}
+
+// Baseline implements database.Baseliner.
+func (m *Sqlite) Baseline() (version int, err error) {
+	var count int
+	query := `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`
+	if err := m.db.QueryRow(query, m.baselineTable()).Scan(&count); err != nil {
+		return 0, &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+	if count == 0 {
+		return database.NilVersion, nil
+	}
+
+	query = "SELECT version FROM " + m.baselineTable() + " LIMIT 1"
+	switch err := m.db.QueryRow(query).Scan(&version); {
+	case err == sql.ErrNoRows:
+		return database.NilVersion, nil
+	case err != nil:
+		return 0, &database.Error{OrigErr: err, Query: []byte(query)}
+	default:
+		return version, nil
+	}
+}
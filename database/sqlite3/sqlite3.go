@@ -30,6 +30,10 @@ type Config struct {
 	MigrationsTable string
 	DatabaseName    string
 	NoTxWrap        bool
+	// OptimisticConcurrency stores an epoch with the version, so writing
+	// a version read before a concurrent write fails with
+	// database.ErrConcurrentModification.
+	OptimisticConcurrency bool
 }
 
 type Sqlite struct {
@@ -88,6 +92,29 @@ func (m *Sqlite) ensureVersionTable() (err error) {
 	if _, err := m.db.Exec(query); err != nil {
 		return err
 	}
+
+	if m.config.OptimisticConcurrency {
+		return m.ensureEpochColumn()
+	}
+	return nil
+}
+
+// ensureEpochColumn adds the epoch column to the migrations table,
+// if it doesn't exist yet.
+func (m *Sqlite) ensureEpochColumn() error {
+	var count int
+	query := `SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = 'epoch'`
+	if err := m.db.QueryRow(query, m.config.MigrationsTable).Scan(&count); err != nil {
+		return &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+	if count > 0 {
+		return nil
+	}
+
+	query = "ALTER TABLE " + m.config.MigrationsTable + " ADD COLUMN epoch integer NOT NULL DEFAULT 0"
+	if _, err := m.db.Exec(query); err != nil {
+		return &database.Error{OrigErr: err, Query: []byte(query)}
+	}
 	return nil
 }
 
@@ -117,10 +144,19 @@ func (m *Sqlite) Open(url string) (database.Driver, error) {
 		}
 	}
 
+	optimisticConcurrency := false
+	if v := qv.Get("x-optimistic-concurrency"); v != "" {
+		optimisticConcurrency, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("x-optimistic-concurrency: %s", err)
+		}
+	}
+
 	mx, err := WithInstance(db, &Config{
-		DatabaseName:    purl.Path,
-		MigrationsTable: migrationsTable,
-		NoTxWrap:        noTxWrap,
+		DatabaseName:          purl.Path,
+		MigrationsTable:       migrationsTable,
+		NoTxWrap:              noTxWrap,
+		OptimisticConcurrency: optimisticConcurrency,
 	})
 	if err != nil {
 		return nil, err
@@ -230,11 +266,42 @@ func (m *Sqlite) executeQueryNoTx(query string) error {
 }
 
 func (m *Sqlite) SetVersion(version int, dirty bool) error {
+	return m.setVersion(version, dirty, nil)
+}
+
+// SetVersionEpoch implements database.EpochVersioner. epoch is ignored
+// unless OptimisticConcurrency is set.
+func (m *Sqlite) SetVersionEpoch(version int, dirty bool, epoch uint64) error {
+	return m.setVersion(version, dirty, &epoch)
+}
+
+// setVersion writes the version. If OptimisticConcurrency is set, the
+// epoch is increased and, if epoch isn't nil, must match the current one.
+func (m *Sqlite) setVersion(version int, dirty bool, epoch *uint64) error {
 	tx, err := m.db.Begin()
 	if err != nil {
 		return &database.Error{OrigErr: err, Err: "transaction start failed"}
 	}
 
+	var next uint64
+	if m.config.OptimisticConcurrency {
+		var current uint64
+		query := "SELECT epoch FROM " + m.config.MigrationsTable + " LIMIT 1"
+		if err := tx.QueryRow(query).Scan(&current); err != nil && err != sql.ErrNoRows {
+			if errRollback := tx.Rollback(); errRollback != nil {
+				err = multierror.Append(err, errRollback)
+			}
+			return &database.Error{OrigErr: err, Query: []byte(query)}
+		}
+		if epoch != nil && *epoch != current {
+			if errRollback := tx.Rollback(); errRollback != nil {
+				return multierror.Append(database.ErrConcurrentModification, errRollback)
+			}
+			return database.ErrConcurrentModification
+		}
+		next = current + 1
+	}
+
 	query := "DELETE FROM " + m.config.MigrationsTable
 	if _, err := tx.Exec(query); err != nil {
 		return &database.Error{OrigErr: err, Query: []byte(query)}
@@ -243,9 +310,15 @@ func (m *Sqlite) SetVersion(version int, dirty bool) error {
 	// Also re-write the schema version for nil dirty versions to prevent
 	// empty schema version for failed down migration on the first migration
 	// See: https://github.com/golang-migrate/migrate/issues/330
-	if version >= 0 || (version == database.NilVersion && dirty) {
+	// The nil version is written as well to keep the epoch.
+	if version >= 0 || (version == database.NilVersion && dirty) || m.config.OptimisticConcurrency {
 		query := fmt.Sprintf(`INSERT INTO %s (version, dirty) VALUES (?, ?)`, m.config.MigrationsTable)
-		if _, err := tx.Exec(query, version, dirty); err != nil {
+		args := []interface{}{version, dirty}
+		if m.config.OptimisticConcurrency {
+			query = fmt.Sprintf(`INSERT INTO %s (version, dirty, epoch) VALUES (?, ?, ?)`, m.config.MigrationsTable)
+			args = append(args, next)
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
 			if errRollback := tx.Rollback(); errRollback != nil {
 				err = multierror.Append(err, errRollback)
 			}
@@ -268,3 +341,23 @@ func (m *Sqlite) Version() (version int, dirty bool, err error) {
 	}
 	return version, dirty, nil
 }
+
+// VersionEpoch implements database.EpochVersioner. The epoch is
+// always 0 unless OptimisticConcurrency is set.
+func (m *Sqlite) VersionEpoch() (version int, dirty bool, epoch uint64, err error) {
+	if !m.config.OptimisticConcurrency {
+		version, dirty, err = m.Version()
+		return version, dirty, 0, err
+	}
+
+	query := "SELECT version, dirty, epoch FROM " + m.config.MigrationsTable + " LIMIT 1"
+	err = m.db.QueryRow(query).Scan(&version, &dirty, &epoch)
+	switch {
+	case err == sql.ErrNoRows:
+		return database.NilVersion, false, 0, nil
+	case err != nil:
+		return 0, false, 0, &database.Error{OrigErr: err, Query: []byte(query)}
+	default:
+		return version, dirty, epoch, nil
+	}
+}
@@ -2,6 +2,7 @@ package sqlite3
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -11,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
 	dt "github.com/golang-migrate/migrate/v4/database/testing"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/mattn/go-sqlite3"
@@ -160,3 +162,138 @@ func TestNoTxWrapInvalidValue(t *testing.T) {
 		assert.Contains(t, err.Error(), "invalid syntax")
 	}
 }
+
+func TestOptimisticConcurrency(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqlite3-driver-test")
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Error(err)
+		}
+	}()
+	t.Logf("DB path : %s\n", filepath.Join(dir, "sqlite3.db"))
+	addr := fmt.Sprintf("sqlite3://%s?x-optimistic-concurrency=true", filepath.Join(dir, "sqlite3.db"))
+	p := &Sqlite{}
+	d, err := p.Open(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dt.Test(t, d, []byte("CREATE TABLE t (Qty int, Name string);"))
+
+	stale, err := p.Open(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := stale.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+	_, _, epoch, err := stale.(*Sqlite).VersionEpoch()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := p.Open(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := other.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+	if err := other.(*Sqlite).SetVersionEpoch(1, false, epoch); err != nil {
+		t.Fatal(err)
+	}
+
+	err = stale.(*Sqlite).SetVersionEpoch(2, false, epoch)
+	assert.True(t, errors.Is(err, database.ErrConcurrentModification), "unexpected error: %v", err)
+
+	version, dirty, current, err := stale.(*Sqlite).VersionEpoch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, version)
+	assert.False(t, dirty)
+	assert.Equal(t, epoch+1, current)
+}
+
+func TestOptimisticConcurrencyExistingTable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqlite3-driver-test")
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Error(err)
+		}
+	}()
+	t.Logf("DB path : %s\n", filepath.Join(dir, "sqlite3.db"))
+	p := &Sqlite{}
+	d, err := p.Open(fmt.Sprintf("sqlite3://%s", filepath.Join(dir, "sqlite3.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.SetVersion(3, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the epoch column is added to the existing migrations table
+	d, err = p.Open(fmt.Sprintf("sqlite3://%s?x-optimistic-concurrency=true", filepath.Join(dir, "sqlite3.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := d.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+	version, dirty, epoch, err := d.(*Sqlite).VersionEpoch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, version)
+	assert.False(t, dirty)
+	assert.Equal(t, uint64(0), epoch)
+}
+
+func TestBaseliner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqlite3-driver-test")
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Error(err)
+		}
+	}()
+	t.Logf("DB path : %s\n", filepath.Join(dir, "sqlite3.db"))
+	p := &Sqlite{}
+	addr := fmt.Sprintf("sqlite3://%s", filepath.Join(dir, "sqlite3.db"))
+	d, err := p.Open(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := d.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+	dt.TestBaseliner(t, d)
+
+	// the baseline table is dropped along with the other tables
+	if err := d.Drop(); err != nil {
+		t.Fatal(err)
+	}
+	baseline, err := d.(*Sqlite).Baseline()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, database.NilVersion, baseline)
+}
@@ -1,9 +1,12 @@
 package stub
 
 import (
+	"context"
+	"errors"
 	"io"
 	"io/ioutil"
 	"reflect"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4/database"
 )
@@ -21,6 +24,31 @@ type Stub struct {
 	IsDirty           bool
 	IsLocked          bool
 
+	// VerifyResults holds the results returned by Verify for a query.
+	// Queries without a result are not truthy.
+	VerifyResults map[string]bool
+
+	// BaselineVersion is the version recorded with SetBaseline.
+	BaselineVersion int
+
+	// Epoch is increased by every write of the version.
+	Epoch uint64
+
+	// CompletedStatements is the statement checkpoint recorded with
+	// SetCheckpoint. It's reset by SetVersion.
+	CompletedStatements int
+
+	// Fingerprints holds the migration fingerprints recorded with
+	// SetMigrationFingerprint by version.
+	Fingerprints map[int]string
+
+	// RunDelay makes RunContext wait before running a migration,
+	// unless the context is done before.
+	RunDelay time.Duration
+
+	// tx holds the state to restore on Rollback while in a transaction.
+	tx *Stub
+
 	Config *Config
 }
 
@@ -29,6 +57,7 @@ func (s *Stub) Open(url string) (database.Driver, error) {
 		Url:               url,
 		CurrentVersion:    database.NilVersion,
 		MigrationSequence: make([]string, 0),
+		BaselineVersion:   database.NilVersion,
 		Config:            &Config{},
 	}, nil
 }
@@ -40,6 +69,7 @@ func WithInstance(instance interface{}, config *Config) (database.Driver, error)
 		Instance:          instance,
 		CurrentVersion:    database.NilVersion,
 		MigrationSequence: make([]string, 0),
+		BaselineVersion:   database.NilVersion,
 		Config:            config,
 	}, nil
 }
@@ -48,6 +78,10 @@ func (s *Stub) Close() error {
 	return nil
 }
 
+func (s *Stub) Ping(ctx context.Context) error {
+	return nil
+}
+
 func (s *Stub) Lock() error {
 	if s.IsLocked {
 		return database.ErrLocked
@@ -61,6 +95,20 @@ func (s *Stub) Unlock() error {
 	return nil
 }
 
+func (s *Stub) LockContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.Lock()
+}
+
+func (s *Stub) UnlockContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.Unlock()
+}
+
 func (s *Stub) Run(migration io.Reader) error {
 	m, err := ioutil.ReadAll(migration)
 	if err != nil {
@@ -71,9 +119,80 @@ func (s *Stub) Run(migration io.Reader) error {
 	return nil
 }
 
+// RunContext is like Run, but waits for RunDelay first and returns
+// the error of ctx if it's done before.
+func (s *Stub) RunContext(ctx context.Context, migration io.Reader) error {
+	select {
+	case <-time.After(s.RunDelay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return s.Run(migration)
+}
+
 func (s *Stub) SetVersion(version int, state bool) error {
 	s.CurrentVersion = version
 	s.IsDirty = state
+	s.Epoch++
+	s.CompletedStatements = 0
+	return nil
+}
+
+// RunStatement appends statement to MigrationSequence.
+func (s *Stub) RunStatement(statement []byte) error {
+	s.LastRunMigration = statement
+	s.MigrationSequence = append(s.MigrationSequence, string(statement))
+	return nil
+}
+
+func (s *Stub) SetCheckpoint(n int) error {
+	s.CompletedStatements = n
+	return nil
+}
+
+func (s *Stub) Checkpoint() (n int, err error) {
+	return s.CompletedStatements, nil
+}
+
+func (s *Stub) VersionEpoch() (version int, dirty bool, epoch uint64, err error) {
+	return s.CurrentVersion, s.IsDirty, s.Epoch, nil
+}
+
+func (s *Stub) SetVersionEpoch(version int, state bool, epoch uint64) error {
+	if epoch != s.Epoch {
+		return database.ErrConcurrentModification
+	}
+	return s.SetVersion(version, state)
+}
+
+func (s *Stub) Begin() error {
+	if s.tx != nil {
+		return errors.New("transaction already started")
+	}
+	tx := *s
+	tx.MigrationSequence = append(make([]string, 0, len(s.MigrationSequence)), s.MigrationSequence...)
+	s.tx = &tx
+	return nil
+}
+
+func (s *Stub) Commit() error {
+	if s.tx == nil {
+		return errors.New("no transaction started")
+	}
+	s.tx = nil
+	return nil
+}
+
+func (s *Stub) Rollback() error {
+	if s.tx == nil {
+		return errors.New("no transaction started")
+	}
+	s.CurrentVersion = s.tx.CurrentVersion
+	s.IsDirty = s.tx.IsDirty
+	s.Epoch = s.tx.Epoch
+	s.MigrationSequence = s.tx.MigrationSequence
+	s.LastRunMigration = s.tx.LastRunMigration
+	s.tx = nil
 	return nil
 }
 
@@ -81,6 +200,31 @@ func (s *Stub) Version() (version int, dirty bool, err error) {
 	return s.CurrentVersion, s.IsDirty, nil
 }
 
+func (s *Stub) Verify(query string) (bool, error) {
+	return s.VerifyResults[query], nil
+}
+
+func (s *Stub) SetBaseline(version int) error {
+	s.BaselineVersion = version
+	return nil
+}
+
+func (s *Stub) Baseline() (version int, err error) {
+	return s.BaselineVersion, nil
+}
+
+func (s *Stub) SetMigrationFingerprint(version int, fingerprint string) error {
+	if s.Fingerprints == nil {
+		s.Fingerprints = make(map[int]string)
+	}
+	s.Fingerprints[version] = fingerprint
+	return nil
+}
+
+func (s *Stub) MigrationFingerprint(version int) (fingerprint string, err error) {
+	return s.Fingerprints[version], nil
+}
+
 const DROP = "DROP"
 
 func (s *Stub) Drop() error {
@@ -1,10 +1,14 @@
 package stub
 
 import (
+	"context"
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
 	"github.com/golang-migrate/migrate/v4/source"
 	"github.com/golang-migrate/migrate/v4/source/stub"
+	"reflect"
 	"testing"
+	"time"
 
 	dt "github.com/golang-migrate/migrate/v4/database/testing"
 )
@@ -18,6 +22,15 @@ func Test(t *testing.T) {
 	dt.Test(t, d, []byte("/* foobar migration */"))
 }
 
+func TestTransactioner(t *testing.T) {
+	s := &Stub{}
+	d, err := s.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dt.TestTransactioner(t, d)
+}
+
 func TestMigrate(t *testing.T) {
 	s := &Stub{}
 	d, err := s.Open("")
@@ -41,3 +54,36 @@ func TestMigrate(t *testing.T) {
 
 	dt.TestMigrate(t, m)
 }
+
+func TestWaitFor(t *testing.T) {
+	if err := database.WaitFor(context.Background(), "stub://", time.Millisecond, time.Second); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	expected := []string{
+		database.CapabilityVerifier,
+		database.CapabilityContextRunner,
+		database.CapabilityContextLocker,
+		database.CapabilityPinger,
+		database.CapabilityTransactioner,
+		database.CapabilityEpochVersioner,
+		database.CapabilityBaseliner,
+		database.CapabilityStatementCheckpointStore,
+		database.CapabilityMigrationFingerprintStore,
+	}
+	if capabilities := database.Capabilities(&Stub{}); !reflect.DeepEqual(capabilities, expected) {
+		t.Errorf("expected capabilities %v, got %v", expected, capabilities)
+	}
+
+	for _, info := range database.Registered() {
+		if info.Name == "stub" {
+			if !reflect.DeepEqual(info.Capabilities, expected) {
+				t.Errorf("expected registered capabilities %v, got %v", expected, info.Capabilities)
+			}
+			return
+		}
+	}
+	t.Error("expected stub to be registered")
+}
@@ -153,3 +153,101 @@ func TestSetVersion(t *testing.T, d database.Driver) {
 		})
 	}
 }
+
+// TestTransactioner tests that versions written in a transaction are only
+// kept once it's committed. The database driver must implement
+// database.Transactioner.
+func TestTransactioner(t *testing.T, d database.Driver) {
+	tx, ok := d.(database.Transactioner)
+	if !ok {
+		t.Fatalf("expected %T to implement database.Transactioner", d)
+	}
+
+	if err := d.SetVersion(1, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tx.Begin(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Begin(); err == nil {
+		t.Error("expected starting a second transaction to fail")
+	}
+	if err := d.SetVersion(2, true); err != nil {
+		t.Fatal(err)
+	}
+	expectVersion(t, d, 2, true)
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	expectVersion(t, d, 1, false)
+
+	if err := tx.Begin(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.SetVersion(3, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	expectVersion(t, d, 3, false)
+
+	if err := tx.Commit(); err == nil {
+		t.Error("expected committing without a transaction to fail")
+	}
+	if err := tx.Rollback(); err == nil {
+		t.Error("expected rolling back without a transaction to fail")
+	}
+}
+
+func expectVersion(t *testing.T, d database.Driver, version int, dirty bool) {
+	t.Helper()
+	v, isDirty, err := d.Version()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != version || isDirty != dirty {
+		t.Fatalf("expected version %v (dirty: %v), got %v (dirty: %v)", version, dirty, v, isDirty)
+	}
+}
+
+// TestBaseliner tests that the baseline version is recorded independently
+// of the version. The database driver must implement database.Baseliner.
+func TestBaseliner(t *testing.T, d database.Driver) {
+	b, ok := d.(database.Baseliner)
+	if !ok {
+		t.Fatalf("expected %T to implement database.Baseliner", d)
+	}
+
+	expectBaseline(t, b, database.NilVersion)
+
+	if err := b.SetBaseline(5); err != nil {
+		t.Fatal(err)
+	}
+	expectBaseline(t, b, 5)
+
+	// writing the version keeps the baseline
+	if err := d.SetVersion(6, false); err != nil {
+		t.Fatal(err)
+	}
+	expectBaseline(t, b, 5)
+	expectVersion(t, d, 6, false)
+
+	if err := b.SetBaseline(7); err != nil {
+		t.Fatal(err)
+	}
+	expectBaseline(t, b, 7)
+	expectVersion(t, d, 6, false)
+}
+
+func expectBaseline(t *testing.T, b database.Baseliner, version int) {
+	t.Helper()
+	v, err := b.Baseline()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != version {
+		t.Fatalf("expected baseline version %v, got %v", version, v)
+	}
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// VersionColumnType is the type of the version column of the migrations
+// table created by drivers supporting it. Each driver maps it to a type of
+// its database. The type of an existing migrations table isn't changed.
+type VersionColumnType string
+
+const (
+	// VersionColumnInt is a 32-bit integer column, too small for
+	// timestamp versions like 20230101120000.
+	VersionColumnInt VersionColumnType = "int"
+
+	// VersionColumnBigint is a 64-bit integer column. It's the default.
+	VersionColumnBigint VersionColumnType = "bigint"
+
+	// VersionColumnString is a string column, e.g. for tables shared
+	// with other tools storing versions as strings.
+	VersionColumnString VersionColumnType = "string"
+)
+
+// ErrVersionOutOfRange is returned when setting a version which doesn't fit
+// the version column.
+var ErrVersionOutOfRange = errors.New("version out of range of the version column")
+
+// ParseVersionColumnType parses the value of the x-version-column-type
+// parameter. An empty value is VersionColumnBigint.
+func ParseVersionColumnType(s string) (VersionColumnType, error) {
+	switch t := VersionColumnType(s); t {
+	case "":
+		return VersionColumnBigint, nil
+	case VersionColumnInt, VersionColumnBigint, VersionColumnString:
+		return t, nil
+	default:
+		return "", fmt.Errorf("unknown version column type %q, must be int, bigint or string", s)
+	}
+}
+
+// Check returns ErrVersionOutOfRange if version doesn't fit a column of
+// type t.
+func (t VersionColumnType) Check(version int) error {
+	if t == VersionColumnInt && (int64(version) > math.MaxInt32 || int64(version) < math.MinInt32) {
+		return fmt.Errorf("%w: %v doesn't fit a 32-bit %v column", ErrVersionOutOfRange, version, t)
+	}
+	return nil
+}
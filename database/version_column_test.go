@@ -0,0 +1,49 @@
+package database
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseVersionColumnType(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected VersionColumnType
+		err      bool
+	}{
+		{value: "", expected: VersionColumnBigint},
+		{value: "int", expected: VersionColumnInt},
+		{value: "bigint", expected: VersionColumnBigint},
+		{value: "string", expected: VersionColumnString},
+		{value: "varchar", err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			typ, err := ParseVersionColumnType(tc.value)
+			if (err != nil) != tc.err {
+				t.Fatalf("expected error: %v, got %v", tc.err, err)
+			}
+			if typ != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, typ)
+			}
+		})
+	}
+}
+
+func TestVersionColumnTypeCheck(t *testing.T) {
+	const timestamp = 20230101120000
+
+	if err := VersionColumnInt.Check(timestamp); !errors.Is(err, ErrVersionOutOfRange) {
+		t.Errorf("expected ErrVersionOutOfRange for int, got %v", err)
+	}
+	if err := VersionColumnInt.Check(NilVersion); err != nil {
+		t.Errorf("expected NilVersion to fit int, got %v", err)
+	}
+	if err := VersionColumnBigint.Check(timestamp); err != nil {
+		t.Errorf("expected timestamp to fit bigint, got %v", err)
+	}
+	if err := VersionColumnString.Check(timestamp); err != nil {
+		t.Errorf("expected timestamp to fit string, got %v", err)
+	}
+}
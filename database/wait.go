@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// maxWaitInterval caps the interval between attempts of WaitFor,
+// unless the given interval is larger.
+const maxWaitInterval = 5 * time.Second
+
+// WaitFor opens the database driver for url and pings it, if the driver
+// implements Pinger, until it succeeds, ctx is done or timeout has passed.
+// The interval between attempts starts at interval and is doubled after
+// every attempt. A timeout <= 0 only waits for ctx.
+// It's meant to be called before opening the database for migrating, when
+// the database may not be ready yet, e.g. right after starting a container.
+func WaitFor(ctx context.Context, url string, interval, timeout time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("invalid interval %v", interval)
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	maxInterval := maxWaitInterval
+	if interval > maxInterval {
+		maxInterval = interval
+	}
+
+	for {
+		err := ping(ctx, url)
+		if err == nil {
+			return nil
+		}
+
+		t := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return fmt.Errorf("waiting for database: %w (last error: %v)", ctx.Err(), err)
+		case <-t.C:
+		}
+
+		if interval *= 2; interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
+
+// ping opens the database driver for url, pings it if supported
+// and closes it again.
+func ping(ctx context.Context, url string) error {
+	d, err := Open(url)
+	if err != nil {
+		return err
+	}
+
+	if p, ok := d.(Pinger); ok {
+		err = p.Ping(ctx)
+	}
+	if errClose := d.Close(); err == nil {
+		err = errClose
+	}
+	return err
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// flakyDriver is a database driver that becomes ready after failing
+// readyAfter pings.
+type flakyDriver struct {
+	mockDriver
+	attempts   *int
+	readyAfter int
+}
+
+func (f *flakyDriver) Open(url string) (Driver, error) {
+	return f, nil
+}
+
+func (f *flakyDriver) Ping(ctx context.Context) error {
+	*f.attempts++
+	if *f.attempts <= f.readyAfter {
+		return errors.New("not ready")
+	}
+	return nil
+}
+
+func TestWaitFor(t *testing.T) {
+	attempts := 0
+	Register("flaky", &flakyDriver{attempts: &attempts, readyAfter: 3})
+
+	if err := WaitFor(context.Background(), "flaky://", time.Millisecond, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 4 {
+		t.Errorf("expected 4 attempts, got %v", attempts)
+	}
+}
+
+func TestWaitForTimeout(t *testing.T) {
+	attempts := 0
+	Register("neverready", &flakyDriver{attempts: &attempts, readyAfter: 1 << 30})
+
+	err := WaitFor(context.Background(), "neverready://", time.Millisecond, 20*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if attempts == 0 {
+		t.Error("expected the driver to be pinged")
+	}
+}
+
+func TestWaitForUnknownDriver(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := WaitFor(ctx, "unknown://", time.Millisecond, 0); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+}
@@ -0,0 +1,47 @@
+package migrate
+
+import (
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// ErrDrift is returned by DriftCheck if the schema of the database
+// doesn't match the expected fingerprint.
+type ErrDrift struct {
+	Expected string
+	Actual   string
+}
+
+// Error implements the error interface.
+func (e ErrDrift) Error() string {
+	return fmt.Sprintf("schema drift: expected fingerprint %v, got %v", e.Expected, e.Actual)
+}
+
+// SchemaFingerprint returns a hash of the current schema of the database,
+// to be recorded after migrating and passed to DriftCheck later on.
+// See Fingerprint for the hash of a migration.
+// It returns ErrNotSupported if the database driver doesn't implement
+// database.SchemaFingerprinter.
+func (m *Migrate) SchemaFingerprint() (string, error) {
+	f, ok := m.databaseDrv.(database.SchemaFingerprinter)
+	if !ok {
+		return "", ErrNotSupported
+	}
+	return f.SchemaFingerprint()
+}
+
+// DriftCheck compares the current schema of the database against the
+// expected fingerprint, usually returned by SchemaFingerprint after the
+// last migration, and returns ErrDrift if the schema has been changed
+// since, e.g. by an ALTER statement run outside of migrations.
+func (m *Migrate) DriftCheck(expected string) error {
+	actual, err := m.SchemaFingerprint()
+	if err != nil {
+		return err
+	}
+	if actual != expected {
+		return ErrDrift{Expected: expected, Actual: actual}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package migrate
+
+import (
+	"errors"
+	"testing"
+
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+)
+
+// schemaStub is a stub database driver with a schema fingerprint.
+type schemaStub struct {
+	*dStub.Stub
+	schema string
+}
+
+func (s *schemaStub) SchemaFingerprint() (string, error) {
+	return s.schema, nil
+}
+
+func TestDriftCheck(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	dbDrv := &schemaStub{Stub: m.databaseDrv.(*dStub.Stub), schema: "a"}
+	m.databaseDrv = dbDrv
+
+	expected, err := m.SchemaFingerprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.DriftCheck(expected); err != nil {
+		t.Fatalf("expected no drift, got %v", err)
+	}
+
+	// out-of-band change of the schema
+	dbDrv.schema = "b"
+	err = m.DriftCheck(expected)
+	var drift ErrDrift
+	if !errors.As(err, &drift) {
+		t.Fatalf("expected ErrDrift, got %v", err)
+	}
+	if drift.Expected != "a" || drift.Actual != "b" {
+		t.Errorf("expected drift from a to b, got %+v", drift)
+	}
+}
+
+func TestDriftCheckNotSupported(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	if err := m.DriftCheck("a"); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported, got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package migrate
+
+import (
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// DryRun applies all pending up migrations and the version updates in a
+// single transaction, which is rolled back afterwards, so real errors of
+// the migrations are caught without persisting anything. It returns the
+// first error of the migrations, or ErrNoChange if none are pending.
+// The database driver must implement database.Transactioner, and the
+// database must support transactional DDL for the result to be reliable.
+// Otherwise DryRun returns ErrNotSupported.
+func (m *Migrate) DryRun() error {
+	tx, ok := m.databaseDrv.(database.Transactioner)
+	if !ok {
+		return fmt.Errorf("dry run: %w", ErrNotSupported)
+	}
+
+	if err := m.lock(); err != nil {
+		return err
+	}
+
+	curVersion, dirty, err := m.versions().GetVersion()
+	if err != nil {
+		return m.unlockErr(err)
+	}
+
+	if dirty {
+		return m.unlockErr(ErrDirty{curVersion})
+	}
+
+	if err := tx.Begin(); err != nil {
+		return m.unlockErr(err)
+	}
+
+	m.inTx = true
+	defer func() { m.inTx = false }()
+
+	ret := make(chan interface{}, m.PrefetchMigrations)
+	go m.readUp(curVersion, -1, ret)
+	err = m.runMigrations(ret)
+	if err == nil {
+		m.logPrintf("Dry run succeeded, rolling back\n")
+	}
+	return m.unlockErr(m.rollbackTx(tx, curVersion, err))
+}
@@ -0,0 +1,64 @@
+package migrate
+
+import (
+	"errors"
+	"testing"
+
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+func TestDryRun(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+
+	if err := m.DryRun(); err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing is persisted
+	if dbDrv.CurrentVersion != -1 || dbDrv.IsDirty {
+		t.Errorf("expected nil version (dirty: false), got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+	equalDbSeq(t, 0, migrationSequence{}, dbDrv)
+
+	// the migrations can still be applied
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.DryRun(); !errors.Is(err, ErrNoChange) {
+		t.Errorf("expected ErrNoChange, got %v", err)
+	}
+}
+
+func TestDryRunBrokenMigration(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+	m.databaseDrv = &failingStub{Stub: dbDrv, failOn: "CREATE 4"}
+
+	if err := m.Steps(1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.DryRun(); err == nil {
+		t.Fatal("expected error of the broken migration")
+	}
+
+	// the migrations preceding the broken one are rolled back, too
+	if dbDrv.CurrentVersion != 1 || dbDrv.IsDirty {
+		t.Errorf("expected version 1 (dirty: false), got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+	equalDbSeq(t, 0, migrationSequence{mr("CREATE 1")}, dbDrv)
+}
+
+func TestDryRunNotSupported(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	m.databaseDrv = nonTxStub{m.databaseDrv}
+
+	if err := m.DryRun(); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported, got %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package migrate
+
+import (
+	"sync"
+	"time"
+)
+
+// UnknownETA is returned by Progress as long as no migration of the
+// current run has completed, or if the number of migrations is unknown,
+// i.e. unless EstimateProgress is set.
+const UnknownETA time.Duration = -1
+
+// runProgress tracks the migrations completed by a run of Migrate, Steps,
+// Up or Down. It's read concurrently with the run, see Progress.
+type runProgress struct {
+	mu      sync.Mutex
+	done    int
+	total   int
+	elapsed time.Duration
+}
+
+// plan resets the progress for a run of total migrations.
+// total is -1 if unknown.
+func (p *runProgress) plan(total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done, p.total, p.elapsed = 0, total, 0
+}
+
+// complete records a migration that completed after d.
+func (p *runProgress) complete(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	p.elapsed += d
+}
+
+// Progress returns the number of migrations completed by the current or
+// last run of Migrate, Steps, Up or Down, the number of migrations of the
+// run, and the estimated time until the remaining ones have completed,
+// based on the average duration of the completed ones. eta is UnknownETA
+// until the first migration has completed. total is -1 unless
+// EstimateProgress is set, or if the versions of the source couldn't be
+// listed.
+// It's safe to call Progress concurrently with running migrations.
+func (m *Migrate) Progress() (done, total int, eta time.Duration) {
+	m.progress.mu.Lock()
+	defer m.progress.mu.Unlock()
+	done, total = m.progress.done, m.progress.total
+	if done == 0 || total < 0 {
+		return done, total, UnknownETA
+	}
+	remaining := total - done
+	if remaining < 0 {
+		remaining = 0
+	}
+	return done, total, m.progress.elapsed / time.Duration(done) * time.Duration(remaining)
+}
+
+// planProgress resets the progress for a run migrating the versions of
+// the source above lo up to and including hi, at most limit of them.
+// hi and limit can be -1, implying no bound. The migrations are only
+// counted if EstimateProgress is set.
+func (m *Migrate) planProgress(lo, hi, limit int) {
+	if !m.EstimateProgress {
+		m.progress.plan(-1)
+		return
+	}
+
+	total := 0
+	err := m.eachSourceVersion(func(version uint) {
+		if int(version) > lo && (hi < 0 || int(version) <= hi) && (limit < 0 || total < limit) {
+			total++
+		}
+	})
+	if err != nil {
+		total = -1
+	}
+	m.progress.plan(total)
+}
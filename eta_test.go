@@ -0,0 +1,111 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	"github.com/golang-migrate/migrate/v4/source"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+func TestProgress(t *testing.T) {
+	const delay = 10 * time.Millisecond
+
+	m, _ := New("stub://", "stub://")
+	migrations := source.NewMigrations()
+	for version := uint(1); version <= 5; version++ {
+		migrations.Append(&source.Migration{Version: version, Direction: source.Up, Identifier: fmt.Sprintf("CREATE %v", version)})
+		migrations.Append(&source.Migration{Version: version, Direction: source.Down, Identifier: fmt.Sprintf("DROP %v", version)})
+	}
+	m.sourceDrv.(*sStub.Stub).Migrations = migrations
+	// the stub only delays migrations run with a context
+	m.databaseDrv.(*dStub.Stub).RunDelay = delay
+	m.SetContext(context.Background())
+	m.EstimateProgress = true
+
+	if done, total, eta := m.Progress(); done != 0 || total != 0 || eta != UnknownETA {
+		t.Errorf("expected no progress before running, got %v/%v (eta %v)", done, total, eta)
+	}
+
+	type progress struct {
+		done, total int
+		eta         time.Duration
+	}
+	var reported []progress
+	m.SetResultFunc(func(MigrationResult) {
+		done, total, eta := m.Progress()
+		reported = append(reported, progress{done, total, eta})
+	})
+
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if len(reported) != 5 {
+		t.Fatalf("expected 5 results, got %v", len(reported))
+	}
+	for i, p := range reported[:4] {
+		remaining := time.Duration(4 - i)
+		if p.done != i+1 || p.total != 5 {
+			t.Errorf("expected %v/5 done, got %v/%v", i+1, p.done, p.total)
+		}
+		// each migration takes at least delay
+		if p.eta < remaining*delay || p.eta > remaining*time.Second {
+			t.Errorf("%v/5 done: implausible eta %v for %v remaining migrations", p.done, p.eta, remaining)
+		}
+	}
+	if last := reported[4]; last.done != 5 || last.eta != 0 {
+		t.Errorf("expected 5/5 done without eta, got %v/%v (eta %v)", last.done, last.total, last.eta)
+	}
+
+	reported = nil
+	if err := m.Steps(-2); err != nil {
+		t.Fatal(err)
+	}
+	if done, total, eta := m.Progress(); done != 2 || total != 2 || eta != 0 {
+		t.Errorf("expected 2/2 done, got %v/%v (eta %v)", done, total, eta)
+	}
+}
+
+func TestProgressNoneCompleted(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	m.EstimateProgress = true
+
+	m.planProgress(database.NilVersion, 4, -1)
+	if done, total, eta := m.Progress(); done != 0 || total != 3 || eta != UnknownETA {
+		t.Errorf("expected 0/3 done with unknown eta, got %v/%v (eta %v)", done, total, eta)
+	}
+}
+
+// countingSource is a stub source driver counting the calls of Next.
+type countingSource struct {
+	*sStub.Stub
+	next int
+}
+
+func (s *countingSource) Next(version uint) (uint, error) {
+	s.next++
+	return s.Stub.Next(version)
+}
+
+func TestProgressNotEstimated(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	src := &countingSource{Stub: m.sourceDrv.(*sStub.Stub)}
+	m.sourceDrv = src
+
+	if err := m.Steps(1); err != nil {
+		t.Fatal(err)
+	}
+	// the versions above the one applied aren't walked
+	if src.next > 1 {
+		t.Errorf("expected at most 1 call of Next, got %v", src.next)
+	}
+	if done, total, eta := m.Progress(); done != 1 || total != -1 || eta != UnknownETA {
+		t.Errorf("expected 1 done of unknown total, got %v/%v (eta %v)", done, total, eta)
+	}
+}
@@ -0,0 +1,46 @@
+package migrate
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// Explain returns the plan of the database for the up migration of version,
+// e.g. the output of EXPLAIN for each of its statements, without applying
+// it. The migration is rendered with the function set by SetTemplateFunc
+// first. It returns ErrNotSupported if the database driver doesn't
+// implement database.Explainer, and os.ErrNotExist if there is no up
+// migration for version.
+func (m *Migrate) Explain(version uint) (plan string, err error) {
+	e, ok := m.databaseDrv.(database.Explainer)
+	if !ok {
+		return "", fmt.Errorf("explain: %w", ErrNotSupported)
+	}
+
+	r, _, err := m.sourceDrv.ReadUp(version)
+	if err != nil {
+		return "", err
+	}
+	defer func(r io.Closer) {
+		if errClose := r.Close(); errClose != nil {
+			err = multierror.Append(err, errClose)
+		}
+	}(r)
+
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	if m.templateFunc != nil {
+		if content, err = m.templateFunc(version, content); err != nil {
+			return "", fmt.Errorf("render %v: %w", version, err)
+		}
+	}
+	return e.Explain(bytes.NewReader(content))
+}
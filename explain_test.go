@@ -0,0 +1,57 @@
+package migrate
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+// explainingStub is a stub database driver explaining migrations
+// by echoing them.
+type explainingStub struct {
+	*dStub.Stub
+}
+
+func (s *explainingStub) Explain(migration io.Reader) (string, error) {
+	body, err := ioutil.ReadAll(migration)
+	if err != nil {
+		return "", err
+	}
+	return "plan for " + string(body), nil
+}
+
+func TestExplain(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := &explainingStub{Stub: m.databaseDrv.(*dStub.Stub)}
+	m.databaseDrv = dbDrv
+
+	plan, err := m.Explain(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan != "plan for CREATE 1" {
+		t.Errorf("expected plan for CREATE 1, got %q", plan)
+	}
+	if len(dbDrv.MigrationSequence) != 0 {
+		t.Errorf("expected no migrations to run, got %v", dbDrv.MigrationSequence)
+	}
+
+	if _, err := m.Explain(5); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist for version without up migration, got %v", err)
+	}
+}
+
+func TestExplainNotSupported(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+
+	if _, err := m.Explain(1); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported, got %v", err)
+	}
+}
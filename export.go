@@ -0,0 +1,68 @@
+package migrate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// ExportApplied concatenates the up migrations of all versions applied to
+// the database, up to and including the currently active version, into a
+// single script that can be replayed on a fresh database.
+// Each migration is preceded by a comment naming its version and identifier.
+// Versions without an up migration in the source are skipped.
+// It returns ErrNilVersion if no migration has been applied yet
+// and ErrDirty if the database is dirty.
+func (m *Migrate) ExportApplied() ([]byte, error) {
+	curVersion, dirty, err := m.Version()
+	if err != nil {
+		return nil, err
+	}
+	if dirty {
+		return nil, ErrDirty{int(curVersion)}
+	}
+
+	var script bytes.Buffer
+	version, err := m.sourceDrv.First()
+	for err == nil && version <= curVersion {
+		if err := m.exportUp(&script, version); err != nil {
+			return nil, err
+		}
+		version, err = m.sourceDrv.Next(version)
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	return script.Bytes(), nil
+}
+
+// exportUp appends the up migration of version to script, if any.
+func (m *Migrate) exportUp(script *bytes.Buffer, version uint) error {
+	r, identifier, err := m.sourceDrv.ReadUp(version)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if m.templateFunc != nil {
+		if content, err = m.templateFunc(version, content); err != nil {
+			return fmt.Errorf("render %v: %w", version, err)
+		}
+	}
+
+	fmt.Fprintf(script, "-- version %v: %v\n", version, identifier)
+	script.Write(content)
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		script.WriteByte('\n')
+	}
+	return nil
+}
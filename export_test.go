@@ -0,0 +1,40 @@
+package migrate
+
+import (
+	"testing"
+
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+func TestExportApplied(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+
+	if _, err := m.ExportApplied(); err != ErrNilVersion {
+		t.Fatalf("expected ErrNilVersion, got %v", err)
+	}
+
+	if err := m.Migrate(4); err != nil {
+		t.Fatal(err)
+	}
+
+	script, err := m.ExportApplied()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "-- version 1: 1.up.stub\nCREATE 1\n" +
+		"-- version 3: 3.up.stub\nCREATE 3\n" +
+		"-- version 4: 4.up.stub\nCREATE 4\n"
+	if string(script) != expected {
+		t.Errorf("expected script %q, got %q", expected, string(script))
+	}
+
+	dbDrv.IsDirty = true
+	if _, err := m.ExportApplied(); err == nil {
+		t.Error("expected error exporting a dirty database")
+	} else if _, ok := err.(ErrDirty); !ok {
+		t.Errorf("expected ErrDirty, got %v", err)
+	}
+}
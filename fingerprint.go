@@ -0,0 +1,138 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// ErrFingerprintMismatch is returned if the up or down migration of an
+// applied version has been changed since it was applied.
+// See VerifyFingerprints.
+type ErrFingerprintMismatch struct {
+	Version  uint
+	Expected string
+	Actual   string
+}
+
+// Error implements the error interface.
+func (e ErrFingerprintMismatch) Error() string {
+	return fmt.Sprintf("migration %v changed since it was applied: expected fingerprint %v, got %v", e.Version, e.Expected, e.Actual)
+}
+
+// Fingerprint returns a hash of the up and down migrations of
+// version in the source, as recorded for applied versions if the database
+// driver implements database.MigrationFingerprintStore. A missing migration
+// is hashed like an empty one.
+func (m *Migrate) Fingerprint(version uint) (string, error) {
+	upSum, err := migrationSum(m.sourceDrv.ReadUp(version))
+	if err != nil {
+		return "", err
+	}
+	downSum, err := migrationSum(m.sourceDrv.ReadDown(version))
+	if err != nil {
+		return "", err
+	}
+	return fingerprint(upSum, downSum), nil
+}
+
+// fingerprint returns the fingerprint of a version from the hashes of its
+// up and down migrations returned by migrationSum.
+func fingerprint(upSum, downSum []byte) string {
+	h := sha256.New()
+	h.Write(upSum)
+	h.Write(downSum)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// migrationSum returns the SHA-256 hash of the migration r returned by
+// ReadUp or ReadDown.
+func migrationSum(r io.ReadCloser, identifier string, err error) ([]byte, error) {
+	h := sha256.New()
+	if errors.Is(err, os.ErrNotExist) {
+		return h.Sum(nil), nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// CheckFingerprints compares the fingerprint of each applied version of the
+// source, up to the currently active version, against the one recorded when
+// it was applied, and returns ErrFingerprintMismatch for the first one that
+// differs. Versions applied without a recorded fingerprint are skipped.
+// It returns ErrNotSupported if the database driver doesn't implement
+// database.MigrationFingerprintStore.
+func (m *Migrate) CheckFingerprints() error {
+	store, ok := m.databaseDrv.(database.MigrationFingerprintStore)
+	if !ok {
+		return ErrNotSupported
+	}
+
+	curVersion, _, err := m.readVersion()
+	if err != nil {
+		return err
+	}
+
+	var result error
+	err = m.eachSourceVersion(func(version uint) {
+		if result != nil || int(version) > curVersion {
+			return
+		}
+		result = m.checkFingerprint(store, version)
+	})
+	if err != nil {
+		return err
+	}
+	return result
+}
+
+// checkFingerprint compares the fingerprint of version against the one
+// recorded in store.
+func (m *Migrate) checkFingerprint(store database.MigrationFingerprintStore, version uint) error {
+	expected, err := store.MigrationFingerprint(int(version))
+	if err != nil || expected == "" {
+		return err
+	}
+	actual, err := m.Fingerprint(version)
+	if err != nil {
+		return err
+	}
+	if actual != expected {
+		return ErrFingerprintMismatch{Version: version, Expected: expected, Actual: actual}
+	}
+	return nil
+}
+
+// preflightFingerprints checks the fingerprints of the applied versions
+// if VerifyFingerprints is set.
+func (m *Migrate) preflightFingerprints() error {
+	if !m.VerifyFingerprints {
+		return nil
+	}
+	return m.CheckFingerprints()
+}
+
+// recordFingerprint records the fingerprint of migr once it has been
+// applied, if the database driver implements
+// database.MigrationFingerprintStore.
+func (m *Migrate) recordFingerprint(migr *Migration) error {
+	store, ok := m.databaseDrv.(database.MigrationFingerprintStore)
+	if !ok || migr.TargetVersion != int(migr.Version) {
+		return nil
+	}
+	fingerprint, err := m.Fingerprint(migr.Version)
+	if err != nil {
+		return err
+	}
+	return store.SetMigrationFingerprint(migr.TargetVersion, fingerprint)
+}
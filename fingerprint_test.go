@@ -0,0 +1,108 @@
+package migrate
+
+import (
+	"errors"
+	"testing"
+
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	"github.com/golang-migrate/migrate/v4/source"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+// editedStubMigrations returns sourceStubMigrations with the body of the
+// down migration of version 4 replaced by body.
+func editedStubMigrations(body string) *source.Migrations {
+	migrations := source.NewMigrations()
+	for _, migr := range []*source.Migration{
+		{Version: 1, Direction: source.Up, Identifier: "CREATE 1"},
+		{Version: 1, Direction: source.Down, Identifier: "DROP 1"},
+		{Version: 3, Direction: source.Up, Identifier: "CREATE 3"},
+		{Version: 4, Direction: source.Up, Identifier: "CREATE 4"},
+		{Version: 4, Direction: source.Down, Identifier: body},
+		{Version: 5, Direction: source.Down, Identifier: "DROP 5"},
+		{Version: 7, Direction: source.Up, Identifier: "CREATE 7"},
+		{Version: 7, Direction: source.Down, Identifier: "DROP 7"},
+	} {
+		migrations.Append(migr)
+	}
+	return migrations
+}
+
+func TestFingerprint(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	srcDrv := m.sourceDrv.(*sStub.Stub)
+	srcDrv.Migrations = sourceStubMigrations
+
+	fingerprint, err := m.Fingerprint(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := m.Fingerprint(4); again != fingerprint {
+		t.Errorf("expected the same fingerprint, got %v and %v", fingerprint, again)
+	}
+	if other, _ := m.Fingerprint(1); other == fingerprint {
+		t.Errorf("expected fingerprints of versions 1 and 4 to differ, got %v", other)
+	}
+
+	srcDrv.Migrations = editedStubMigrations("DROP 4 CASCADE")
+	if edited, _ := m.Fingerprint(4); edited == fingerprint {
+		t.Errorf("expected fingerprint to change with the down migration, got %v", edited)
+	}
+}
+
+func TestCheckFingerprints(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	srcDrv := m.sourceDrv.(*sStub.Stub)
+	srcDrv.Migrations = sourceStubMigrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+
+	if err := m.Migrate(4); err != nil {
+		t.Fatal(err)
+	}
+	for _, version := range []int{1, 3, 4} {
+		if dbDrv.Fingerprints[version] == "" {
+			t.Errorf("expected fingerprint of version %v to be recorded", version)
+		}
+	}
+	if err := m.CheckFingerprints(); err != nil {
+		t.Fatalf("expected unchanged migrations, got %v", err)
+	}
+
+	// the down migration of an applied version is edited
+	srcDrv.Migrations = editedStubMigrations("DROP 4 CASCADE")
+
+	err := m.CheckFingerprints()
+	var mismatch ErrFingerprintMismatch
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("expected ErrFingerprintMismatch, got %v", err)
+	}
+	if mismatch.Version != 4 || mismatch.Expected != dbDrv.Fingerprints[4] {
+		t.Errorf("unexpected mismatch %+v", mismatch)
+	}
+
+	m.VerifyFingerprints = true
+	if err := m.Up(); !errors.As(err, &mismatch) {
+		t.Fatalf("expected Up to refuse to run, got %v", err)
+	}
+	if dbDrv.CurrentVersion != 4 {
+		t.Errorf("expected version 4, got %v", dbDrv.CurrentVersion)
+	}
+
+	// once the edit is reverted, Up proceeds
+	srcDrv.Migrations = sourceStubMigrations
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if dbDrv.CurrentVersion != 7 {
+		t.Errorf("expected version 7, got %v", dbDrv.CurrentVersion)
+	}
+}
+
+func TestCheckFingerprintsNotSupported(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.databaseDrv = nonTxStub{m.databaseDrv}
+
+	if err := m.CheckFingerprints(); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported, got %v", err)
+	}
+}
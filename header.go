@@ -0,0 +1,109 @@
+package migrate
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// headerPrefix is the prefix of comment lines at the top of a migration
+// that carry directives for the runner, e.g. `-- migrate:verify SELECT 1`.
+const headerPrefix = "migrate:"
+
+// headers holds the directives declared in the leading
+// `-- migrate:<directive> <value>` comment lines of a migration.
+// Directives are only recognized before the first non-comment line.
+type headers struct {
+	// Verify is a query that must return a truthy result after the
+	// migration has been applied. See database.Verifier.
+	Verify string
+
+	// Timeout limits the time the database driver may spend running the
+	// migration. See database.ContextRunner.
+	Timeout time.Duration
+
+	// Phase names the phase the migration belongs to. The migrations of a
+	// phase are applied together by UpPhase.
+	Phase string
+
+	// Irreversible marks a down migration as unable to restore the state
+	// before its up migration. See ErrIrreversible.
+	Irreversible bool
+
+	// NoVersion runs the migration without recording its version, e.g.
+	// for operational statements like ANALYZE TABLE.
+	NoVersion bool
+}
+
+// readHeaders parses the directives from the leading comment lines of r.
+// The returned reader yields the complete, unmodified content of r,
+// so the directives are still sent to the database as regular comments.
+func readHeaders(r io.Reader) (headers, io.Reader, error) {
+	var h headers
+	consumed := &bytes.Buffer{}
+	br := bufio.NewReader(r)
+
+	for {
+		// skip blank lines between header comments
+		b, err := br.Peek(1)
+		if err == nil && (b[0] == '\n' || b[0] == '\r') {
+			c, _ := br.ReadByte()
+			consumed.WriteByte(c)
+			continue
+		}
+
+		b, err = br.Peek(2)
+		if err != nil || string(b) != "--" {
+			break
+		}
+
+		line, err := br.ReadString('\n')
+		consumed.WriteString(line)
+		if perr := h.parse(line); perr != nil {
+			return h, nil, perr
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return h, nil, err
+		}
+	}
+
+	return h, io.MultiReader(consumed, br), nil
+}
+
+// parse sets the directive found in a single comment line, if any.
+// Unknown directives are ignored, since other tools use the same prefix.
+func (h *headers) parse(line string) error {
+	line = strings.TrimSpace(strings.TrimPrefix(line, "--"))
+	if !strings.HasPrefix(line, headerPrefix) {
+		return nil
+	}
+	line = strings.TrimPrefix(line, headerPrefix)
+
+	name, value := line, ""
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		name, value = line[:i], strings.TrimSpace(line[i+1:])
+	}
+
+	switch name {
+	case "verify":
+		h.Verify = value
+	case "timeout":
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid timeout header %q: %w", value, err)
+		}
+		h.Timeout = d
+	case "phase":
+		h.Phase = value
+	case "irreversible":
+		h.Irreversible = true
+	case "no-version":
+		h.NoVersion = true
+	}
+	return nil
+}
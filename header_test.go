@@ -0,0 +1,121 @@
+package migrate
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	"github.com/golang-migrate/migrate/v4/source"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+func TestReadHeaders(t *testing.T) {
+	testCases := []struct {
+		name    string
+		body    string
+		verify  string
+		timeout time.Duration
+		phase   string
+		irrev   bool
+		noVer   bool
+	}{
+		{name: "no headers", body: "CREATE TABLE t (id int);"},
+		{name: "empty body", body: ""},
+		{name: "verify", body: "-- migrate:verify SELECT 1\nCREATE TABLE t (id int);", verify: "SELECT 1"},
+		{name: "verify after comment", body: "-- some comment\n\n--migrate:verify  SELECT 1 \nCREATE TABLE t (id int);", verify: "SELECT 1"},
+		{name: "verify without newline", body: "-- migrate:verify SELECT 1", verify: "SELECT 1"},
+		{name: "verify after statement", body: "CREATE TABLE t (id int);\n-- migrate:verify SELECT 1"},
+		{name: "unknown directive", body: "-- migrate:up\nCREATE TABLE t (id int);"},
+		{name: "timeout", body: "-- migrate:timeout 10m\n-- migrate:verify SELECT 1\nCREATE TABLE t (id int);", verify: "SELECT 1", timeout: 10 * time.Minute},
+		{name: "phase", body: "-- migrate:phase release-2\nCREATE TABLE t (id int);", phase: "release-2"},
+		{name: "irreversible", body: "-- migrate:irreversible\n-- drops the users' data\n", irrev: true},
+		{name: "no version", body: "-- migrate:no-version\nANALYZE TABLE t;", noVer: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h, r, err := readHeaders(strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if h.Verify != tc.verify {
+				t.Errorf("expected verify %q, got %q", tc.verify, h.Verify)
+			}
+			if h.Timeout != tc.timeout {
+				t.Errorf("expected timeout %v, got %v", tc.timeout, h.Timeout)
+			}
+			if h.Phase != tc.phase {
+				t.Errorf("expected phase %q, got %q", tc.phase, h.Phase)
+			}
+			if h.Irreversible != tc.irrev {
+				t.Errorf("expected irreversible %v, got %v", tc.irrev, h.Irreversible)
+			}
+			if h.NoVersion != tc.noVer {
+				t.Errorf("expected no-version %v, got %v", tc.noVer, h.NoVersion)
+			}
+
+			body, err := ioutil.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != tc.body {
+				t.Errorf("expected body %q, got %q", tc.body, string(body))
+			}
+		})
+	}
+}
+
+func TestReadHeadersInvalidTimeout(t *testing.T) {
+	if _, _, err := readHeaders(strings.NewReader("-- migrate:timeout soon\nCREATE TABLE t (id int);")); err == nil {
+		t.Error("expected error for invalid timeout")
+	}
+}
+
+func TestNoVersionHeader(t *testing.T) {
+	migrations := source.NewMigrations()
+	migrations.Append(&source.Migration{Version: 1, Direction: source.Up, Identifier: "CREATE 1"})
+	migrations.Append(&source.Migration{Version: 2, Direction: source.Up, Identifier: "-- migrate:no-version\nANALYZE 2"})
+
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = migrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	// the no-version migration ran, but the version stays at 1
+	equalDbSeq(t, 0, migrationSequence{mr("CREATE 1"), mr("-- migrate:no-version\nANALYZE 2")}, dbDrv)
+	if dbDrv.CurrentVersion != 1 || dbDrv.IsDirty {
+		t.Errorf("expected version 1 (dirty: false), got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+
+	// following migrations record their version as usual
+	migrations.Append(&source.Migration{Version: 3, Direction: source.Up, Identifier: "CREATE 3"})
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if dbDrv.CurrentVersion != 3 || dbDrv.IsDirty {
+		t.Errorf("expected version 3 (dirty: false), got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+
+	// once a later version is recorded, going down walks through the
+	// no-version migration like any other, recording its version
+	migrations.Append(&source.Migration{Version: 1, Direction: source.Down, Identifier: "DROP 1"})
+	migrations.Append(&source.Migration{Version: 2, Direction: source.Down, Identifier: "UNDO 2"})
+	migrations.Append(&source.Migration{Version: 3, Direction: source.Down, Identifier: "DROP 3"})
+	if err := m.Steps(-1); err != nil {
+		t.Fatal(err)
+	}
+	if dbDrv.CurrentVersion != 2 || dbDrv.IsDirty {
+		t.Errorf("expected version 2 (dirty: false), got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+	if err := m.Down(); err != nil {
+		t.Fatal(err)
+	}
+	equalDbSeq(t, 1, migrationSequence{mr("CREATE 1"), mr("-- migrate:no-version\nANALYZE 2"), mr("-- migrate:no-version\nANALYZE 2"), mr("CREATE 3"), mr("DROP 3"), mr("UNDO 2"), mr("DROP 1")}, dbDrv)
+	if dbDrv.CurrentVersion != -1 || dbDrv.IsDirty {
+		t.Errorf("expected no version (dirty: false), got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+}
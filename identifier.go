@@ -0,0 +1,38 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// Identifier returns the title of version for logs and UIs, formatted like
+// the file names of migrations: "{version}_{identifier}", e.g.
+// "20230101120000_add_users", or only the version if the source doesn't
+// provide an identifier. The identifier is taken from the up migration,
+// or from the down migration if there's none. It returns an error wrapping
+// os.ErrNotExist if the source has no migration for version.
+func (m *Migrate) Identifier(version uint) (title string, err error) {
+	r, identifier, err := m.sourceDrv.ReadUp(version)
+	if errors.Is(err, os.ErrNotExist) {
+		r, identifier, err = m.sourceDrv.ReadDown(version)
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("no migration found for version %d: %w", version, err)
+	} else if err != nil {
+		return "", err
+	}
+	defer func(r io.Closer) {
+		if errClose := r.Close(); errClose != nil {
+			err = multierror.Append(err, errClose)
+		}
+	}(r)
+
+	if identifier == "" {
+		return fmt.Sprint(version), nil
+	}
+	return fmt.Sprintf("%v_%v", version, identifier), nil
+}
@@ -0,0 +1,75 @@
+package migrate
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+)
+
+func TestIdentifier(t *testing.T) {
+	testCases := []struct {
+		name     string
+		files    []string
+		version  uint
+		expected string
+	}{
+		{
+			name:     "timestamp",
+			files:    []string{"20230101120000_add_users.up.sql", "20230101120000_add_users.down.sql", "20230102080000_add_email.up.sql"},
+			version:  20230102080000,
+			expected: "20230102080000_add_email",
+		},
+		{
+			name:     "sequential",
+			files:    []string{"1_init.up.sql", "2_add_users.up.sql", "2_add_users.down.sql"},
+			version:  2,
+			expected: "2_add_users",
+		},
+		{
+			name:     "down only",
+			files:    []string{"1_init.up.sql", "2_drop_users.down.sql"},
+			version:  2,
+			expected: "2_drop_users",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "migrate-identifier-test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				if err := os.RemoveAll(dir); err != nil {
+					t.Error(err)
+				}
+			}()
+			for _, file := range tc.files {
+				if err := ioutil.WriteFile(filepath.Join(dir, file), []byte("SELECT 1;"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			m, err := New("file://"+dir, "stub://")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			title, err := m.Identifier(tc.version)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if title != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, title)
+			}
+
+			if _, err := m.Identifier(3); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("expected os.ErrNotExist, got %v", err)
+			}
+		})
+	}
+}
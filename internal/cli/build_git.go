@@ -0,0 +1,7 @@
+// +build git
+
+package cli
+
+import (
+	_ "github.com/golang-migrate/migrate/v4/source/git"
+)
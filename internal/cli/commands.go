@@ -147,8 +147,8 @@ func createFile(filename string) error {
 	return f.Close()
 }
 
-func gotoCmd(m *migrate.Migrate, v uint) error {
-	if err := m.Migrate(v); err != nil {
+func gotoCmd(m *migrate.Migrate, target string) error {
+	if err := m.MigrateToTarget(target); err != nil {
 		if err != migrate.ErrNoChange {
 			return err
 		}
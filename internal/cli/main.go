@@ -51,7 +51,7 @@ Commands:
 			   Create a set of timestamped up/down migrations titled NAME, in directory D with extension E.
 			   Use -seq option to generate sequential up/down migrations with N digits.
 			   Use -format option to specify a Go time format string. Note: migrations with the same time cause "duplicate migration version" error. 
-  goto V       Migrate to version V
+  goto V       Migrate to version V, or to latest, previous or baseline
   up [N]       Apply all or N up migrations
   down [N]     Apply all or N down migrations
   drop [-f]    Drop everything inside database
@@ -153,12 +153,7 @@ Database drivers: `+strings.Join(database.List(), ", ")+"\n")
 			log.fatal("error: please specify version argument V")
 		}
 
-		v, err := strconv.ParseUint(flag.Arg(1), 10, 64)
-		if err != nil {
-			log.fatal("error: can't read version argument V")
-		}
-
-		if err := gotoCmd(migrater, uint(v)); err != nil {
+		if err := gotoCmd(migrater, flag.Arg(1)); err != nil {
 			log.fatalErr(err)
 		}
 
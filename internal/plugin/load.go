@@ -0,0 +1,55 @@
+// +build linux,cgo darwin,cgo freebsd,cgo
+
+package plugin
+
+import (
+	"fmt"
+	"plugin"
+	"strings"
+	"sync"
+)
+
+var (
+	loadedMu sync.Mutex
+	// loaded holds the plugins already registered, as opening a plugin
+	// twice returns the same one, registering its drivers again.
+	loaded = make(map[*plugin.Plugin]bool)
+)
+
+// Load opens the plugin at path and calls its exported Register function,
+// which is expected to register its drivers, e.g. with database.Register.
+// Loading a plugin again is a no-op.
+func Load(path string) (err error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		if strings.Contains(err.Error(), "different version of package") {
+			return fmt.Errorf("load plugin %v: %w: %v", path, ErrVersionMismatch, err)
+		}
+		return fmt.Errorf("load plugin %v: %w", path, err)
+	}
+
+	loadedMu.Lock()
+	defer loadedMu.Unlock()
+	if loaded[p] {
+		return nil
+	}
+
+	sym, err := p.Lookup("Register")
+	if err != nil {
+		return fmt.Errorf("load plugin %v: %w", path, ErrNoRegister)
+	}
+	register, ok := sym.(func())
+	if !ok {
+		return fmt.Errorf("load plugin %v: %w, got %T", path, ErrNoRegister, sym)
+	}
+
+	// Register panics if a driver of the same name is registered already
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("load plugin %v: %v", path, r)
+		}
+	}()
+	register()
+	loaded[p] = true
+	return nil
+}
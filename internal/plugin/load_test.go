@@ -0,0 +1,64 @@
+// +build linux,cgo darwin,cgo freebsd,cgo
+
+package plugin
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// buildPlugin builds the plugin in testdata/name, skipping the test if the
+// toolchain can't build plugins.
+func buildPlugin(t *testing.T, dir, name string) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("building plugins is slow")
+	}
+	path := filepath.Join(dir, name+".so")
+	args := []string{"build", "-buildmode=plugin", "-o", path}
+	if raceEnabled {
+		args = append(args, "-race")
+	}
+	cmd := exec.Command("go", append(args, "./testdata/"+name)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("can't build plugin %v: %v\n%s", name, err, out)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plugin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	path := buildPlugin(t, dir, "register")
+	if err := Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if v := os.Getenv("MIGRATE_TEST_PLUGIN"); v != "registered" {
+		t.Errorf("expected Register to be called, got %q", v)
+	}
+
+	// loading it again doesn't register it twice
+	if err := Load(path); err != nil {
+		t.Error(err)
+	}
+
+	if err := Load(buildPlugin(t, dir, "noregister")); !errors.Is(err, ErrNoRegister) {
+		t.Errorf("expected ErrNoRegister, got %v", err)
+	}
+
+	if err := Load(filepath.Join(dir, "missing.so")); err == nil {
+		t.Error("expected error for a missing plugin")
+	}
+}
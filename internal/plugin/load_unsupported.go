@@ -0,0 +1,11 @@
+// +build !linux,!darwin,!freebsd !cgo
+
+package plugin
+
+import "fmt"
+
+// Load returns ErrNotSupported, as the plugin package doesn't support this
+// platform or build.
+func Load(path string) error {
+	return fmt.Errorf("load plugin %v: %w", path, ErrNotSupported)
+}
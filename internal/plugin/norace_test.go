@@ -0,0 +1,5 @@
+// +build !race
+
+package plugin
+
+const raceEnabled = false
@@ -0,0 +1,18 @@
+// Package plugin loads database and source drivers built as Go plugins.
+package plugin
+
+import "errors"
+
+var (
+	// ErrNotSupported is returned when loading plugins on platforms the
+	// plugin package doesn't support, or in builds without cgo.
+	ErrNotSupported = errors.New("plugins not supported on this platform")
+
+	// ErrNoRegister is returned when a plugin doesn't export a Register
+	// function.
+	ErrNoRegister = errors.New("plugin doesn't export func Register()")
+
+	// ErrVersionMismatch is returned when a plugin was built with another
+	// version of Go or of a package it shares with the program, e.g. migrate.
+	ErrVersionMismatch = errors.New("plugin built with another version of Go or migrate; rebuild it with the same ones as the program")
+)
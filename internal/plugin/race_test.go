@@ -0,0 +1,7 @@
+// +build race
+
+package plugin
+
+// raceEnabled is set if the test binary is built with -race, which the
+// plugins loaded by the tests must be built with, too.
+const raceEnabled = true
@@ -0,0 +1,6 @@
+package main
+
+// Init isn't the Register function looked up by the loader.
+func Init() {}
+
+func main() {}
@@ -0,0 +1,12 @@
+package main
+
+import "os"
+
+// Register marks the plugin as registered for the test loading it.
+func Register() {
+	if err := os.Setenv("MIGRATE_TEST_PLUGIN", "registered"); err != nil {
+		panic(err)
+	}
+}
+
+func main() {}
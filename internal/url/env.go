@@ -0,0 +1,83 @@
+package url
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ExpandEnv replaces `${VAR}` in url with the value of the environment
+// variable VAR, so secrets don't need to be embedded in URLs.
+// `${VAR:-default}` expands to default if VAR is unset or empty, and `$${`
+// expands to a literal `${`. Any other `$`, e.g. in a password like
+// `pa$word`, is kept. Bare `$VAR` isn't expanded for that reason, unlike in
+// shells. Expanded values are not URL-encoded.
+func ExpandEnv(url string) (string, error) {
+	return expandEnv(url, os.LookupEnv)
+}
+
+// EscapeEnv escapes every `${` in url, so ExpandEnv returns url unchanged,
+// e.g. for a URL whose variables have been expanded already.
+func EscapeEnv(url string) string {
+	return strings.Replace(url, "${", "$${", -1)
+}
+
+func expandEnv(url string, lookup func(string) (string, bool)) (string, error) {
+	if !strings.Contains(url, "${") {
+		return url, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(url); i++ {
+		switch {
+		case strings.HasPrefix(url[i:], "$${"):
+			b.WriteString("${")
+			i += 2
+
+		case strings.HasPrefix(url[i:], "${"):
+			// the URL isn't part of the errors, since it likely contains secrets
+			end := strings.IndexByte(url[i+2:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated variable in URL at offset %v", i)
+			}
+			name, def := url[i+2:i+2+end], ""
+			hasDefault := false
+			if j := strings.Index(name, ":-"); j >= 0 {
+				name, def, hasDefault = name[:j], name[j+2:], true
+			}
+			if !isEnvName(name) {
+				return "", fmt.Errorf("invalid variable name in URL at offset %v", i)
+			}
+			value, ok := lookup(name)
+			switch {
+			case hasDefault && value == "":
+				value = def
+			case !ok:
+				return "", fmt.Errorf("undefined environment variable in URL: %v", name)
+			}
+			b.WriteString(value)
+			i += 2 + end
+
+		default:
+			b.WriteByte(url[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// isEnvName reports whether name is a valid environment variable name.
+func isEnvName(name string) bool {
+	if name == "" || !isEnvNameStart(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if !isEnvNameStart(name[i]) && !('0' <= name[i] && name[i] <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func isEnvNameStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
@@ -0,0 +1,85 @@
+package migrate
+
+import (
+	"testing"
+
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	"github.com/golang-migrate/migrate/v4/source"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+// iterableStub is a stub source driver implementing source.Iterable
+// and counting the calls to Next.
+type iterableStub struct {
+	*sStub.Stub
+	nextCalls int
+}
+
+func (s *iterableStub) Next(version uint) (uint, error) {
+	s.nextCalls++
+	return s.Stub.Next(version)
+}
+
+func (s *iterableStub) Iterate(version uint) (source.Iterator, error) {
+	var versions []uint
+	for v, ok := s.Migrations.Next(version); ok; v, ok = s.Migrations.Next(v) {
+		versions = append(versions, v)
+	}
+	return &sliceIterator{versions: versions}, nil
+}
+
+type sliceIterator struct {
+	versions []uint
+}
+
+func (i *sliceIterator) Next() (uint, bool) {
+	if len(i.versions) == 0 {
+		return 0, false
+	}
+	v := i.versions[0]
+	i.versions = i.versions[1:]
+	return v, true
+}
+
+func (i *sliceIterator) Err() error {
+	return nil
+}
+
+func TestIterate(t *testing.T) {
+	tt := []struct {
+		name string
+		run  func(m *Migrate) error
+	}{
+		{name: "up", run: (*Migrate).Up},
+		{name: "steps", run: func(m *Migrate) error { return m.Steps(3) }},
+		{name: "migrate", run: func(m *Migrate) error { return m.Migrate(5) }},
+	}
+
+	for _, v := range tt {
+		t.Run(v.name, func(t *testing.T) {
+			legacy, _ := New("stub://", "stub://")
+			legacy.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+			if err := v.run(legacy); err != nil {
+				t.Fatal(err)
+			}
+
+			m, _ := New("stub://", "stub://")
+			m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+			srcDrv := &iterableStub{Stub: m.sourceDrv.(*sStub.Stub)}
+			m.sourceDrv = srcDrv
+			if err := v.run(m); err != nil {
+				t.Fatal(err)
+			}
+
+			expected := legacy.databaseDrv.(*dStub.Stub)
+			got := m.databaseDrv.(*dStub.Stub)
+			if !got.EqualSequence(expected.MigrationSequence) || got.CurrentVersion != expected.CurrentVersion {
+				t.Errorf("expected version %v and sequence %v, got version %v and sequence %v",
+					expected.CurrentVersion, expected.MigrationSequence, got.CurrentVersion, got.MigrationSequence)
+			}
+			if srcDrv.nextCalls != 0 {
+				t.Errorf("expected Next not to be called, got %v calls", srcDrv.nextCalls)
+			}
+		})
+	}
+}
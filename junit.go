@@ -0,0 +1,72 @@
+package migrate
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+// junitTestSuites is the root element of a JUnit XML report.
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnitReport renders results as a JUnit XML report with a single test
+// suite named migrate and a test case per migration, i.e. for CI systems
+// showing test reports. Failed migrations are reported as failures carrying
+// the error. Use SetResultFunc to collect the results of a run.
+func JUnitReport(results []MigrationResult) ([]byte, error) {
+	suite := junitTestSuite{Name: "migrate", Tests: len(results)}
+
+	var total time.Duration
+	for _, r := range results {
+		direction := "up"
+		if r.Down() {
+			direction = "down"
+		}
+		tc := junitTestCase{
+			Name:      fmt.Sprintf("%v %v (%v)", r.Version, r.Identifier, direction),
+			ClassName: "migrate." + direction,
+			Time:      junitTime(r.Duration),
+		}
+		if r.Err != nil {
+			tc.Failure = &junitFailure{Message: r.Err.Error(), Text: r.Err.Error()}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+		total += r.Duration
+	}
+	suite.Time = junitTime(total)
+
+	out, err := xml.MarshalIndent(junitTestSuites{Suites: []junitTestSuite{suite}}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
+
+// junitTime formats d in seconds, as expected by JUnit.
+func junitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,73 @@
+package migrate
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJUnitReport(t *testing.T) {
+	results := []MigrationResult{
+		{Version: 1, TargetVersion: 1, Identifier: "create_users", Duration: 1500 * time.Millisecond},
+		{Version: 2, TargetVersion: 2, Identifier: "add_email", Duration: 250 * time.Millisecond, Err: errors.New("column <email> exists")},
+		{Version: 2, TargetVersion: 1, Identifier: "add_email", Duration: 10 * time.Millisecond},
+	}
+
+	out, err := JUnitReport(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(out), xml.Header) {
+		t.Errorf("expected XML header, got %q", out)
+	}
+
+	var report junitTestSuites
+	if err := xml.Unmarshal(out, &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Suites) != 1 {
+		t.Fatalf("expected a single test suite, got %v", len(report.Suites))
+	}
+
+	suite := report.Suites[0]
+	if suite.Name != "migrate" || suite.Tests != 3 || suite.Failures != 1 || suite.Time != "1.760" {
+		t.Errorf("unexpected test suite %+v", suite)
+	}
+
+	expected := []junitTestCase{
+		{Name: "1 create_users (up)", ClassName: "migrate.up", Time: "1.500"},
+		{Name: "2 add_email (up)", ClassName: "migrate.up", Time: "0.250",
+			Failure: &junitFailure{Message: "column <email> exists", Text: "column <email> exists"}},
+		{Name: "2 add_email (down)", ClassName: "migrate.down", Time: "0.010"},
+	}
+	if len(suite.Cases) != len(expected) {
+		t.Fatalf("expected %v test cases, got %v", len(expected), len(suite.Cases))
+	}
+	for i, tc := range suite.Cases {
+		if tc.Name != expected[i].Name || tc.ClassName != expected[i].ClassName || tc.Time != expected[i].Time {
+			t.Errorf("expected test case %+v, got %+v", expected[i], tc)
+		}
+		if (tc.Failure == nil) != (expected[i].Failure == nil) {
+			t.Errorf("%v: expected failure %v, got %v", tc.Name, expected[i].Failure, tc.Failure)
+		} else if tc.Failure != nil && *tc.Failure != *expected[i].Failure {
+			t.Errorf("%v: expected failure %+v, got %+v", tc.Name, *expected[i].Failure, *tc.Failure)
+		}
+	}
+}
+
+func TestJUnitReportEmpty(t *testing.T) {
+	out, err := JUnitReport(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var report junitTestSuites
+	if err := xml.Unmarshal(out, &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Suites) != 1 || report.Suites[0].Tests != 0 || len(report.Suites[0].Cases) != 0 {
+		t.Errorf("expected an empty test suite, got %+v", report.Suites)
+	}
+}
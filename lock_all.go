@@ -0,0 +1,74 @@
+package migrate
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// LockAll acquires the database locks of instances in the order given by
+// order, which reports whether the instance at index i must be locked before
+// the one at index j, like the less function of sort.Slice. Processes
+// locking the same databases with the same order can't deadlock each other.
+// Instances comparing equal are locked in the order they are given.
+//
+// While the locks are held, Migrate, Steps, Up, Down and the other methods
+// taking the lock run on the instances without locking and unlocking the
+// database again. The returned unlock releases the locks in reverse order.
+// If a lock can't be acquired, the locks acquired before are released and
+// the combined error is returned.
+func LockAll(instances []*Migrate, order func(i, j int) bool) (unlock func() error, err error) {
+	indexes := make([]int, len(instances))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	if order != nil {
+		sort.SliceStable(indexes, func(a, b int) bool {
+			return order(indexes[a], indexes[b])
+		})
+	}
+
+	locked := make([]*Migrate, 0, len(instances))
+	unlock = func() error {
+		var err error
+		for i := len(locked) - 1; i >= 0; i-- {
+			if errUnlock := locked[i].releaseHeldLock(); errUnlock != nil {
+				err = multierror.Append(err, errUnlock)
+			}
+		}
+		locked = locked[:0]
+		return err
+	}
+
+	for _, i := range indexes {
+		if err := instances[i].holdLock(); err != nil {
+			err = fmt.Errorf("lock instance %v: %w", i, err)
+			if errUnlock := unlock(); errUnlock != nil {
+				err = multierror.Append(err, errUnlock)
+			}
+			return nil, err
+		}
+		locked = append(locked, instances[i])
+	}
+	return unlock, nil
+}
+
+// holdLock locks the database and keeps it locked until releaseHeldLock.
+func (m *Migrate) holdLock() error {
+	if err := m.lock(); err != nil {
+		return err
+	}
+	m.isLockedMu.Lock()
+	m.isLockHeld = true
+	m.isLockedMu.Unlock()
+	return nil
+}
+
+// releaseHeldLock unlocks the database locked by holdLock.
+func (m *Migrate) releaseHeldLock() error {
+	m.isLockedMu.Lock()
+	m.isLockHeld = false
+	m.isLockedMu.Unlock()
+	return m.unlock()
+}
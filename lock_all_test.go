@@ -0,0 +1,136 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+// recordingStub is a stub database driver recording the order
+// its lock is acquired and released in.
+type recordingStub struct {
+	*dStub.Stub
+	name   string
+	events *[]string
+	locked bool // fail to lock, as if held by someone else
+}
+
+func (s *recordingStub) Lock() error {
+	if s.locked {
+		return database.ErrLocked
+	}
+	*s.events = append(*s.events, "lock "+s.name)
+	return s.Stub.Lock()
+}
+
+func (s *recordingStub) Unlock() error {
+	*s.events = append(*s.events, "unlock "+s.name)
+	return s.Stub.Unlock()
+}
+
+func (s *recordingStub) LockContext(ctx context.Context) error {
+	return s.Lock()
+}
+
+func (s *recordingStub) UnlockContext(ctx context.Context) error {
+	return s.Unlock()
+}
+
+func newRecordingInstances(t *testing.T, events *[]string, names ...string) ([]*Migrate, []*recordingStub) {
+	instances := make([]*Migrate, 0, len(names))
+	drivers := make([]*recordingStub, 0, len(names))
+	for _, name := range names {
+		m, err := New("stub://", "stub://")
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+		dbDrv := &recordingStub{Stub: m.databaseDrv.(*dStub.Stub), name: name, events: events}
+		m.databaseDrv = dbDrv
+		instances = append(instances, m)
+		drivers = append(drivers, dbDrv)
+	}
+	return instances, drivers
+}
+
+func TestLockAll(t *testing.T) {
+	var events []string
+	instances, drivers := newRecordingInstances(t, &events, "b", "a")
+	byName := func(i, j int) bool { return drivers[i].name < drivers[j].name }
+
+	unlock, err := LockAll(instances, byName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// migrations run with the held locks
+	for _, m := range instances {
+		if err := m.Up(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, d := range drivers {
+		if !d.IsLocked {
+			t.Errorf("expected %v to stay locked", d.name)
+		}
+	}
+
+	if err := unlock(); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"lock a", "lock b", "unlock b", "unlock a"}
+	if !equalStrings(events, expected) {
+		t.Errorf("expected %v, got %v", expected, events)
+	}
+
+	// the instances lock on their own again
+	events = events[:0]
+	if err := instances[0].Steps(-1); err != nil {
+		t.Fatal(err)
+	}
+	if !equalStrings(events, []string{"lock b", "unlock b"}) {
+		t.Errorf("expected b to be locked and unlocked, got %v", events)
+	}
+}
+
+func TestLockAllRollback(t *testing.T) {
+	var events []string
+	instances, drivers := newRecordingInstances(t, &events, "a", "b", "c")
+	drivers[2].locked = true
+
+	unlock, err := LockAll(instances, nil)
+	if !errors.Is(err, database.ErrLocked) {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+	if unlock != nil {
+		t.Error("expected no unlock func")
+	}
+	expected := []string{"lock a", "lock b", "unlock b", "unlock a"}
+	if !equalStrings(events, expected) {
+		t.Errorf("expected %v, got %v", expected, events)
+	}
+	for _, d := range drivers {
+		if d.IsLocked {
+			t.Errorf("expected %v to be unlocked", d.name)
+		}
+	}
+	if err := instances[0].Up(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// LockRetryPolicy configures retrying to acquire the database lock,
+// see SetLockRetry.
+type LockRetryPolicy struct {
+	// InitialInterval is the wait before the second attempt.
+	// It's doubled after every attempt, up to MaxInterval.
+	InitialInterval time.Duration
+
+	// MaxInterval caps the wait between attempts.
+	// It's raised to InitialInterval if smaller.
+	MaxInterval time.Duration
+
+	// Timeout is the max time spent retrying, including the wait for an
+	// attempt exceeding LockTimeout, see SetLockRetry. A timeout <= 0 only
+	// waits for the context.
+	Timeout time.Duration
+}
+
+// lockRetry holds the arguments of SetLockRetry.
+type lockRetry struct {
+	ctx    context.Context
+	policy LockRetryPolicy
+}
+
+// SetLockRetry makes the following migrations retry acquiring the database
+// lock before running, if it fails, e.g. because another deploy is briefly
+// holding it. It doesn't acquire the lock itself. Attempts are repeated with
+// exponential backoff until one succeeds, ctx is done or policy.Timeout has
+// passed, in which case the migration fails with an error wrapping
+// ErrLockTimeout. Only failed attempts are retried: an attempt exceeding
+// LockTimeout is waited for until the database driver returns, since the
+// Lock of drivers not implementing database.ContextLocker can't be
+// cancelled, and locking again meanwhile could acquire the lock twice.
+// If ctx is done or policy.Timeout passes while waiting, the attempt is
+// abandoned, and the lock released once it's acquired after all.
+// Database drivers whose Lock blocks until the lock is released usually
+// succeed at the first attempt.
+func (m *Migrate) SetLockRetry(ctx context.Context, policy LockRetryPolicy) error {
+	if policy.InitialInterval <= 0 {
+		return fmt.Errorf("invalid interval %v", policy.InitialInterval)
+	}
+	if policy.MaxInterval < policy.InitialInterval {
+		policy.MaxInterval = policy.InitialInterval
+	}
+	m.lockRetry = &lockRetry{ctx: ctx, policy: policy}
+	return nil
+}
+
+// retryLock tries to lock the database as configured by SetLockRetry.
+func (m *Migrate) retryLock() error {
+	ctx, policy := m.lockRetry.ctx, m.lockRetry.policy
+	if policy.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, policy.Timeout)
+		defer cancel()
+	}
+
+	interval := policy.InitialInterval
+	for attempt := 1; ; attempt++ {
+		pending, err := m.attemptLock()
+		if pending != nil {
+			m.logVerbosePrintf("Acquiring lock takes longer than %v (attempt %v), waiting for it\n", m.LockTimeout, attempt)
+			select {
+			case err = <-pending:
+			case <-ctx.Done():
+				go m.releaseAbandonedLock(pending)
+				return fmt.Errorf("%w: %v (attempt %v still pending)", ErrLockTimeout, ctx.Err(), attempt)
+			}
+		}
+		if err == nil {
+			return nil
+		}
+		m.logVerbosePrintf("Acquiring lock failed (attempt %v): %v\n", attempt, err)
+
+		t := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return fmt.Errorf("%w: %v (last error: %v)", ErrLockTimeout, ctx.Err(), err)
+		case <-t.C:
+		}
+
+		if interval *= 2; interval > policy.MaxInterval {
+			interval = policy.MaxInterval
+		}
+	}
+}
+
+// releaseAbandonedLock releases the lock if the attempt pending has been
+// abandoned by retryLock, but acquires the lock after all.
+func (m *Migrate) releaseAbandonedLock(pending <-chan error) {
+	if err := <-pending; err != nil {
+		return
+	}
+	if err := m.unlockVersions(); err != nil {
+		m.logErr(fmt.Errorf("releasing abandoned lock failed: %w", err))
+	}
+}
@@ -0,0 +1,160 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+// contendedStub is a stub database driver whose lock is held by
+// someone else for the first failures attempts.
+type contendedStub struct {
+	*dStub.Stub
+	failures int
+	attempts int
+}
+
+func (s *contendedStub) Lock() error {
+	s.attempts++
+	if s.attempts <= s.failures {
+		return database.ErrLocked
+	}
+	return s.Stub.Lock()
+}
+
+func (s *contendedStub) LockContext(ctx context.Context) error {
+	return s.Lock()
+}
+
+func TestSetLockRetry(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := &contendedStub{Stub: m.databaseDrv.(*dStub.Stub), failures: 3}
+	m.databaseDrv = dbDrv
+
+	policy := LockRetryPolicy{InitialInterval: time.Millisecond, MaxInterval: 2 * time.Millisecond, Timeout: time.Second}
+	if err := m.SetLockRetry(context.Background(), policy); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if dbDrv.attempts != 4 {
+		t.Errorf("expected 4 attempts, got %v", dbDrv.attempts)
+	}
+	if dbDrv.IsLocked {
+		t.Error("expected the lock to be released")
+	}
+	equalDbSeq(t, 0, migrationSequence{mr("CREATE 1"), mr("CREATE 3"), mr("CREATE 4"), mr("CREATE 7")}, dbDrv.Stub)
+}
+
+func TestSetLockRetryTimeout(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := &contendedStub{Stub: m.databaseDrv.(*dStub.Stub), failures: 1 << 30}
+	m.databaseDrv = dbDrv
+
+	policy := LockRetryPolicy{InitialInterval: time.Millisecond, Timeout: 20 * time.Millisecond}
+	if err := m.SetLockRetry(context.Background(), policy); err != nil {
+		t.Fatal(err)
+	}
+	err := m.Up()
+	if !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("expected ErrLockTimeout, got %v", err)
+	}
+	if dbDrv.attempts < 2 {
+		t.Errorf("expected several attempts, got %v", dbDrv.attempts)
+	}
+}
+
+// slowLockStub is a stub database driver without database.ContextLocker,
+// whose Lock blocks for delay, like a blocking advisory lock held by
+// someone else.
+type slowLockStub struct {
+	database.Driver
+	delay    time.Duration
+	calls    int32
+	inFlight int32
+	overlaps int32
+	unlocks  int32
+}
+
+func (s *slowLockStub) Lock() error {
+	atomic.AddInt32(&s.calls, 1)
+	if atomic.AddInt32(&s.inFlight, 1) > 1 {
+		atomic.AddInt32(&s.overlaps, 1)
+	}
+	defer atomic.AddInt32(&s.inFlight, -1)
+	time.Sleep(s.delay)
+	return s.Driver.Lock()
+}
+
+func (s *slowLockStub) Unlock() error {
+	atomic.AddInt32(&s.unlocks, 1)
+	return s.Driver.Unlock()
+}
+
+func TestSetLockRetrySlowAttempt(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := &slowLockStub{Driver: m.databaseDrv, delay: 50 * time.Millisecond}
+	m.databaseDrv = dbDrv
+	m.LockTimeout = 5 * time.Millisecond
+
+	policy := LockRetryPolicy{InitialInterval: time.Millisecond, Timeout: time.Second}
+	if err := m.SetLockRetry(context.Background(), policy); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	// the attempt exceeding LockTimeout is waited for instead of locking again
+	if calls := atomic.LoadInt32(&dbDrv.calls); calls != 1 {
+		t.Errorf("expected 1 call of Lock, got %v", calls)
+	}
+	if overlaps := atomic.LoadInt32(&dbDrv.overlaps); overlaps != 0 {
+		t.Errorf("expected no concurrent calls of Lock, got %v", overlaps)
+	}
+}
+
+func TestSetLockRetryAbandonedAttempt(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := &slowLockStub{Driver: m.databaseDrv, delay: 100 * time.Millisecond}
+	m.databaseDrv = dbDrv
+	m.LockTimeout = 5 * time.Millisecond
+
+	policy := LockRetryPolicy{InitialInterval: time.Millisecond, Timeout: 20 * time.Millisecond}
+	if err := m.SetLockRetry(context.Background(), policy); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := m.Up(); !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("expected ErrLockTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= dbDrv.delay {
+		t.Errorf("expected to give up after the timeout, took %v", elapsed)
+	}
+
+	// the lock acquired by the abandoned attempt is released
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&dbDrv.unlocks) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the abandoned lock to be released")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSetLockRetryInvalidInterval(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	if err := m.SetLockRetry(context.Background(), LockRetryPolicy{}); err == nil {
+		t.Error("expected error for a zero interval")
+	}
+}
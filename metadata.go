@@ -0,0 +1,23 @@
+package migrate
+
+import (
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// Metadata returns the metadata of the migration with the given version,
+// e.g. its author or ticket, read by the source driver from a
+// `{version}_{title}.yaml` sidecar file with `key: value` lines.
+// It returns an empty map if there is no sidecar file or the source driver
+// doesn't implement source.MetadataReader, and os.ErrNotExist if the
+// version doesn't exist.
+func (m *Migrate) Metadata(version uint) (map[string]string, error) {
+	if err := m.versionExists(version); err != nil {
+		return nil, err
+	}
+
+	reader, ok := m.sourceDrv.(source.MetadataReader)
+	if !ok {
+		return map[string]string{}, nil
+	}
+	return reader.ReadMetadata(version)
+}
@@ -0,0 +1,59 @@
+package migrate
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/source"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+// noMetadataStub hides source.MetadataReader of the wrapped source driver.
+type noMetadataStub struct {
+	source.Driver
+}
+
+func TestMetadata(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	srcDrv := m.sourceDrv.(*sStub.Stub)
+	srcDrv.Migrations = sourceStubMigrations
+	srcDrv.Metadata = map[uint]map[string]string{
+		1: {"author": "gopher", "ticket": "MIG-1"},
+	}
+
+	metadata, err := m.Metadata(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata["author"] != "gopher" || metadata["ticket"] != "MIG-1" {
+		t.Errorf("expected metadata of version 1, got %v", metadata)
+	}
+
+	// no sidecar
+	metadata, err = m.Metadata(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata == nil || len(metadata) != 0 {
+		t.Errorf("expected empty metadata for version 3, got %v", metadata)
+	}
+
+	if _, err := m.Metadata(2); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist for missing version 2, got %v", err)
+	}
+}
+
+func TestMetadataNotSupported(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	m.sourceDrv = &noMetadataStub{m.sourceDrv}
+
+	metadata, err := m.Metadata(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata == nil || len(metadata) != 0 {
+		t.Errorf("expected empty metadata, got %v", metadata)
+	}
+}
@@ -5,8 +5,12 @@
 package migrate
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"sync"
 	"time"
@@ -34,6 +38,7 @@ var (
 	ErrInvalidVersion = errors.New("version must be >= -1")
 	ErrLocked         = errors.New("database locked")
 	ErrLockTimeout    = errors.New("timeout: can't acquire database lock")
+	ErrNotSupported   = errors.New("not supported by database driver")
 )
 
 // ErrShortLimit is an error returned when not enough migrations
@@ -55,6 +60,19 @@ func (e ErrDirty) Error() string {
 	return fmt.Sprintf("Dirty database version %v. Fix and force version.", e.Version)
 }
 
+// ErrVerificationFailed is an error returned when the verification query
+// declared in a migration's `-- migrate:verify` header didn't return a
+// truthy result after the migration has been applied.
+type ErrVerificationFailed struct {
+	Version uint
+	Query   string
+}
+
+// Error implements the error interface.
+func (e ErrVerificationFailed) Error() string {
+	return fmt.Sprintf("verification failed for version %v: %v", e.Version, e.Query)
+}
+
 type Migrate struct {
 	sourceName   string
 	sourceDrv    source.Driver
@@ -72,6 +90,9 @@ type Migrate struct {
 
 	isGracefulStop bool
 	isLocked       bool
+	// isLockHeld is set while the lock is held by LockAll, so migrations
+	// run on the instance don't lock and unlock the database themselves.
+	isLockHeld bool
 
 	// PrefetchMigrations defaults to DefaultPrefetchMigrations,
 	// but can be set per Migrate instance.
@@ -80,13 +101,101 @@ type Migrate struct {
 	// LockTimeout defaults to DefaultLockTimeout,
 	// but can be set per Migrate instance.
 	LockTimeout time.Duration
+
+	// RequireMigrationFiles makes Migrate, Steps, Up and Down check that
+	// every version to be applied has a migration file in the direction of
+	// the migration before taking the lock. By default a missing file is
+	// applied as an empty migration. See ErrMissingUpFile.
+	RequireMigrationFiles bool
+
+	// StatementCheckpoints makes up migrations run statement by statement
+	// if the database driver implements database.StatementCheckpointStore,
+	// recording the number of completed statements, so Resume can continue
+	// an interrupted migration. Statements are separated by semicolons.
+	// Timeouts declared in the headers of migrations don't apply then.
+	StatementCheckpoints bool
+
+	// TimeoutPolicy decides whether a migration exceeding the timeout
+	// declared in its headers fails, which is the default, or only logs
+	// a warning. See TimeoutWarn.
+	TimeoutPolicy TimeoutPolicy
+
+	// AllowIrreversible makes Migrate, Steps and Down roll back migrations
+	// whose down migration is marked with `-- migrate:irreversible`.
+	// By default they stop at such a version. See ErrIrreversible.
+	AllowIrreversible bool
+
+	// VerifyFingerprints makes Migrate, Steps, Up and Down check that the
+	// migrations of the applied versions haven't changed since they were
+	// applied, before taking the lock. See CheckFingerprints.
+	VerifyFingerprints bool
+
+	// EstimateProgress makes Migrate, Steps, Up and Down count the
+	// migrations they are going to run before running them, so Progress
+	// reports the total and an ETA. Counting walks the versions of the
+	// source once more, which is slow for remote sources, so it's disabled
+	// by default.
+	EstimateProgress bool
+
+	// versionStore keeps track of the active version instead of
+	// the database driver if set. See SetVersionStore.
+	versionStore VersionStore
+
+	// epoch is the epoch of the version if the database driver
+	// implements database.EpochVersioner.
+	epoch versionEpoch
+
+	// templateFunc is applied to the content of each migration before
+	// it is run. See SetTemplateFunc.
+	templateFunc TemplateFunc
+
+	// current is the migration being run. See Current.
+	current currentMigration
+
+	// progress counts the migrations completed by the current run.
+	// See Progress.
+	progress runProgress
+
+	// lockRetry retries acquiring the lock if set. See SetLockRetry.
+	lockRetry *lockRetry
+
+	// progressFunc reports the progress of reading migrations from
+	// the source. See SetProgressFunc.
+	progressFunc ProgressFunc
+
+	// resultFunc receives the result of every migration run. See SetResultFunc.
+	resultFunc ResultFunc
+
+	// summaryHandler receives the summary of every run. See SetSummaryHandler.
+	summaryHandler func(Summary)
+
+	// ctx stops the following migrations once done. See SetContext.
+	ctx context.Context
+
+	// inTx is set while migrations run in a database.Transactioner
+	// transaction, i.e. by UpAtomic, DryRun, UpPhase and for migrations
+	// declaring a verification query, so timeouts aren't ignored within it.
+	inTx bool
 }
 
+// TemplateFunc transforms the content of the migration with the given
+// version before it is run against the database.
+type TemplateFunc func(version uint, content []byte) ([]byte, error)
+
 // New returns a new Migrate instance from a source URL and a database URL.
 // The URL scheme is defined by each driver.
 func New(sourceURL, databaseURL string) (*Migrate, error) {
 	m := newCommon()
 
+	sourceURL, err := resolveURL(sourceURL)
+	if err != nil {
+		return nil, err
+	}
+	databaseURL, err = resolveURL(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+
 	sourceName, err := iurl.SchemeFromURL(sourceURL)
 	if err != nil {
 		return nil, err
@@ -121,6 +230,11 @@ func New(sourceURL, databaseURL string) (*Migrate, error) {
 func NewWithDatabaseInstance(sourceURL string, databaseName string, databaseInstance database.Driver) (*Migrate, error) {
 	m := newCommon()
 
+	sourceURL, err := resolveURL(sourceURL)
+	if err != nil {
+		return nil, err
+	}
+
 	sourceName, err := iurl.SchemeFromURL(sourceURL)
 	if err != nil {
 		return nil, err
@@ -147,6 +261,11 @@ func NewWithDatabaseInstance(sourceURL string, databaseName string, databaseInst
 func NewWithSourceInstance(sourceName string, sourceInstance source.Driver, databaseURL string) (*Migrate, error) {
 	m := newCommon()
 
+	databaseURL, err := resolveURL(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+
 	databaseName, err := iurl.SchemeFromURL(databaseURL)
 	if err != nil {
 		return nil, err
@@ -191,6 +310,15 @@ func newCommon() *Migrate {
 	}
 }
 
+// SetTemplateFunc sets a function that is applied to the content of each
+// migration after it has been read from the source, but before it is run,
+// i.e. to render Go templates or substitute environment specific values.
+// Migrations are passed through unchanged if fn is nil, which is the default.
+// Note that each migration is fully read into memory when fn is set.
+func (m *Migrate) SetTemplateFunc(fn TemplateFunc) {
+	m.templateFunc = fn
+}
+
 // Close closes the source and the database.
 func (m *Migrate) Close() (source error, database error) {
 	databaseSrvClose := make(chan error)
@@ -212,11 +340,15 @@ func (m *Migrate) Close() (source error, database error) {
 // Migrate looks at the currently active migration version,
 // then migrates either up or down to the specified version.
 func (m *Migrate) Migrate(version uint) error {
+	if err := m.preflightMigrate(version); err != nil {
+		return err
+	}
+
 	if err := m.lock(); err != nil {
 		return err
 	}
 
-	curVersion, dirty, err := m.databaseDrv.Version()
+	curVersion, dirty, err := m.versions().GetVersion()
 	if err != nil {
 		return m.unlockErr(err)
 	}
@@ -238,11 +370,19 @@ func (m *Migrate) Steps(n int) error {
 		return ErrNoChange
 	}
 
+	if n > 0 {
+		if err := m.preflightUp(-1, n); err != nil {
+			return err
+		}
+	} else if err := m.preflightDown(database.NilVersion, -n); err != nil {
+		return err
+	}
+
 	if err := m.lock(); err != nil {
 		return err
 	}
 
-	curVersion, dirty, err := m.databaseDrv.Version()
+	curVersion, dirty, err := m.versions().GetVersion()
 	if err != nil {
 		return m.unlockErr(err)
 	}
@@ -265,11 +405,15 @@ func (m *Migrate) Steps(n int) error {
 // Up looks at the currently active migration version
 // and will migrate all the way up (applying all up migrations).
 func (m *Migrate) Up() error {
+	if err := m.preflightUp(-1, -1); err != nil {
+		return err
+	}
+
 	if err := m.lock(); err != nil {
 		return err
 	}
 
-	curVersion, dirty, err := m.databaseDrv.Version()
+	curVersion, dirty, err := m.versions().GetVersion()
 	if err != nil {
 		return m.unlockErr(err)
 	}
@@ -287,11 +431,15 @@ func (m *Migrate) Up() error {
 // Down looks at the currently active migration version
 // and will migrate all the way down (applying all down migrations).
 func (m *Migrate) Down() error {
+	if err := m.preflightDown(database.NilVersion, -1); err != nil {
+		return err
+	}
+
 	if err := m.lock(); err != nil {
 		return err
 	}
 
-	curVersion, dirty, err := m.databaseDrv.Version()
+	curVersion, dirty, err := m.versions().GetVersion()
 	if err != nil {
 		return m.unlockErr(err)
 	}
@@ -329,7 +477,7 @@ func (m *Migrate) Run(migration ...*Migration) error {
 		return err
 	}
 
-	curVersion, dirty, err := m.databaseDrv.Version()
+	curVersion, dirty, err := m.versions().GetVersion()
 	if err != nil {
 		return m.unlockErr(err)
 	}
@@ -342,6 +490,7 @@ func (m *Migrate) Run(migration ...*Migration) error {
 
 	go func() {
 		defer close(ret)
+		m.progress.plan(len(migration))
 		for _, migr := range migration {
 			if m.PrefetchMigrations > 0 && migr.Body != nil {
 				m.logVerbosePrintf("Start buffering %v\n", migr.LogString())
@@ -373,7 +522,10 @@ func (m *Migrate) Force(version int) error {
 		return err
 	}
 
-	if err := m.databaseDrv.SetVersion(version, false); err != nil {
+	// read the epoch again under the lock, instead of overwriting a
+	// version written since it was read last
+	m.epoch.invalidate()
+	if err := m.versions().SetVersion(version, false); err != nil {
 		return m.unlockErr(err)
 	}
 
@@ -383,7 +535,7 @@ func (m *Migrate) Force(version int) error {
 // Version returns the currently active migration version.
 // If no migration has been applied, yet, it will return ErrNilVersion.
 func (m *Migrate) Version() (version uint, dirty bool, err error) {
-	v, d, err := m.databaseDrv.Version()
+	v, d, err := m.readVersion()
 	if err != nil {
 		return 0, false, err
 	}
@@ -426,6 +578,8 @@ func (m *Migrate) read(from int, to int, ret chan<- interface{}) {
 
 	if from < to {
 		// it's going up
+		m.planProgress(from, to, -1)
+
 		// apply first migration if from is nil version
 		if from == -1 {
 			firstVersion, err := m.sourceDrv.First()
@@ -451,13 +605,25 @@ func (m *Migrate) read(from int, to int, ret chan<- interface{}) {
 		}
 
 		// run until we reach target ...
+		var it source.Iterator
 		for from < to {
 			if m.stop() {
 				return
 			}
 
-			next, err := m.sourceDrv.Next(suint(from))
-			if err != nil {
+			if it == nil {
+				var err error
+				if it, err = source.Iterate(m.sourceDrv, suint(from)); err != nil {
+					ret <- err
+					return
+				}
+			}
+			next, ok := it.Next()
+			if !ok {
+				err := it.Err()
+				if err == nil {
+					err = fmt.Errorf("next for version %v: %w", from, os.ErrNotExist)
+				}
 				ret <- err
 				return
 			}
@@ -480,6 +646,8 @@ func (m *Migrate) read(from int, to int, ret chan<- interface{}) {
 
 	} else {
 		// it's going down
+		m.planProgress(to, from, -1)
+
 		// run until we reach target ...
 		for from > to && from >= 0 {
 			if m.stop() {
@@ -547,6 +715,11 @@ func (m *Migrate) readUp(from int, limit int, ret chan<- interface{}) {
 		return
 	}
 
+	m.planProgress(from, -1, limit)
+
+	// it iterates the versions following from, once from is a valid version
+	var it source.Iterator
+
 	count := 0
 	for count < limit || limit == -1 {
 		if m.stop() {
@@ -579,8 +752,20 @@ func (m *Migrate) readUp(from int, limit int, ret chan<- interface{}) {
 		}
 
 		// apply next migration
-		next, err := m.sourceDrv.Next(suint(from))
-		if errors.Is(err, os.ErrNotExist) {
+		if it == nil {
+			var err error
+			if it, err = source.Iterate(m.sourceDrv, suint(from)); err != nil {
+				ret <- err
+				return
+			}
+		}
+		next, ok := it.Next()
+		if !ok {
+			if err := it.Err(); err != nil {
+				ret <- err
+				return
+			}
+
 			// no limit, but no migrations applied?
 			if limit == -1 && count == 0 {
 				ret <- ErrNoChange
@@ -604,10 +789,6 @@ func (m *Migrate) readUp(from int, limit int, ret chan<- interface{}) {
 				return
 			}
 		}
-		if err != nil {
-			ret <- err
-			return
-		}
 
 		migr, err := m.newMigration(next, int(next))
 		if err != nil {
@@ -659,6 +840,8 @@ func (m *Migrate) readDown(from int, limit int, ret chan<- interface{}) {
 		return
 	}
 
+	m.planProgress(database.NilVersion, from, limit)
+
 	count := 0
 	for count < limit || limit == -1 {
 		if m.stop() {
@@ -722,54 +905,240 @@ func (m *Migrate) readDown(from int, limit int, ret chan<- interface{}) {
 // Before running a newly received migration it will check if it's supposed
 // to stop execution because it might have received a stop signal on the
 // GracefulStop channel.
-func (m *Migrate) runMigrations(ret <-chan interface{}) error {
+func (m *Migrate) runMigrations(ret <-chan interface{}) (err error) {
+	defer m.current.stop()
+
+	var summary Summary
+	defer func() {
+		m.reportSummary(summary, err)
+	}()
+
 	for r := range ret {
 
 		if m.stop() {
 			return nil
 		}
+		if err := m.context().Err(); err != nil {
+			return err
+		}
 
 		switch r := r.(type) {
 		case error:
 			return r
 
 		case *Migration:
-			migr := r
-
-			// set version with dirty state
-			if err := m.databaseDrv.SetVersion(migr.TargetVersion, true); err != nil {
-				return err
+			start := time.Now()
+			runErr := m.runMigration(r)
+			d := time.Since(start)
+			if runErr == nil {
+				m.progress.complete(d)
 			}
-
-			if migr.Body != nil {
-				m.logVerbosePrintf("Read and execute %v\n", migr.LogString())
-				if err := m.databaseDrv.Run(migr.BufferedBody); err != nil {
-					return err
-				}
+			m.reportResult(r, d, runErr)
+			summary.add(r, d, runErr)
+			if runErr != nil {
+				return runErr
 			}
 
-			// set clean state
-			if err := m.databaseDrv.SetVersion(migr.TargetVersion, false); err != nil {
-				return err
-			}
+		default:
+			return fmt.Errorf("unknown type: %T with value: %+v", r, r)
+		}
+	}
+	return nil
+}
 
-			endTime := time.Now()
-			readTime := migr.FinishedReading.Sub(migr.StartedBuffering)
-			runTime := endTime.Sub(migr.FinishedReading)
+// runMigration runs a single migration received by runMigrations,
+// see applyMigration.
+func (m *Migrate) runMigration(migr *Migration) error {
+	h, body, err := m.readBody(migr)
+	if err != nil {
+		return err
+	}
 
-			// log either verbose or normal
-			if m.Log != nil {
-				if m.Log.Verbose() {
-					m.logPrintf("Finished %v (read %v, ran %v)\n", migr.LogString(), readTime, runTime)
-				} else {
-					m.logPrintf("%v (%v)\n", migr.LogString(), readTime+runTime)
-				}
-			}
+	if h.Irreversible && migr.TargetVersion < int(migr.Version) && !m.AllowIrreversible {
+		return ErrIrreversible{migr.Version}
+	}
 
-		default:
-			return fmt.Errorf("unknown type: %T with value: %+v", r, r)
+	m.current.start(migr)
+
+	if tx, ok := m.databaseDrv.(database.Transactioner); ok && len(h.Verify) > 0 && !m.inTx {
+		return m.runVerifiedTx(tx, migr, body, h)
+	}
+	return m.applyMigration(migr, body, h)
+}
+
+// runVerifiedTx applies migr declaring a `-- migrate:verify` header in a
+// transaction, so it's rolled back if the verification fails, leaving the
+// version clean at the one before migr.
+func (m *Migrate) runVerifiedTx(tx database.Transactioner, migr *Migration, body io.Reader, h headers) error {
+	curVersion, _, err := m.versions().GetVersion()
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Begin(); err != nil {
+		return err
+	}
+
+	m.inTx = true
+	err = m.applyMigration(migr, body, h)
+	m.inTx = false
+	if err != nil {
+		return m.rollbackTx(tx, curVersion, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return m.rollbackTx(tx, curVersion, err)
+	}
+	return nil
+}
+
+// applyMigration applies migr with the body and headers read by
+// runMigration, updating the version and logging the result.
+func (m *Migrate) applyMigration(migr *Migration, body io.Reader, h headers) error {
+	if h.NoVersion {
+		return m.runWithoutVersion(migr, body, h)
+	}
+
+	// set version with dirty state
+	if err := m.versions().SetVersion(migr.TargetVersion, true); err != nil {
+		return err
+	}
+
+	if migr.Body != nil {
+		m.logVerbosePrintf("Read and execute %v\n", migr.LogString())
+		var err error
+		if store, ok := m.checkpointStore(migr); ok {
+			err = m.runStatements(store, body, 0)
+		} else {
+			err = m.run(body, h)
+		}
+		if err != nil && !m.ignoreTimeout(migr, err) {
+			return err
+		}
+	}
+
+	if err := m.verify(migr, h); err != nil {
+		return err
+	}
+
+	// set clean state
+	if err := m.versions().SetVersion(migr.TargetVersion, false); err != nil {
+		return err
+	}
+	if err := m.recordFingerprint(migr); err != nil {
+		return err
+	}
+	m.current.stop()
+
+	endTime := time.Now()
+	readTime := migr.FinishedReading.Sub(migr.StartedBuffering)
+	runTime := endTime.Sub(migr.FinishedReading)
+
+	// log either verbose or normal
+	if m.Log != nil {
+		if m.Log.Verbose() {
+			m.logPrintf("Finished %v (read %v, ran %v)\n", migr.LogString(), readTime, runTime)
+		} else {
+			m.logPrintf("%v (%v)\n", migr.LogString(), readTime+runTime)
 		}
 	}
+
+	return nil
+}
+
+// runWithoutVersion runs migr declaring the `-- migrate:no-version` header,
+// leaving the version untouched, so a failing migration doesn't leave the
+// version dirty either.
+func (m *Migrate) runWithoutVersion(migr *Migration, body io.Reader, h headers) error {
+	m.logVerbosePrintf("Read and execute %v without recording its version\n", migr.LogString())
+	if err := m.run(body, h); err != nil && !m.ignoreTimeout(migr, err) {
+		return err
+	}
+	if err := m.verify(migr, h); err != nil {
+		return err
+	}
+	m.current.stop()
+
+	m.logPrintf("%v (version not recorded)\n", migr.LogString())
+	return nil
+}
+
+// readBody renders the body of migr and reads its headers.
+// The body is nil for migrations without a body.
+func (m *Migrate) readBody(migr *Migration) (h headers, body io.Reader, err error) {
+	if migr.Body == nil {
+		return h, nil, nil
+	}
+	if body, err = m.render(migr); err != nil {
+		return h, nil, err
+	}
+	return readHeaders(body)
+}
+
+// run runs body with the database driver, within the timeout
+// declared in its headers if the driver supports it.
+func (m *Migrate) run(body io.Reader, h headers) error {
+	if h.Timeout <= 0 && m.ctx == nil {
+		return m.databaseDrv.Run(body)
+	}
+
+	cr, ok := m.databaseDrv.(database.ContextRunner)
+	if !ok {
+		if h.Timeout > 0 {
+			m.logVerbosePrintf("Database driver doesn't support timeouts, ignoring timeout of %v\n", h.Timeout)
+		}
+		return m.databaseDrv.Run(body)
+	}
+
+	if h.Timeout <= 0 {
+		return cr.RunContext(m.context(), body)
+	}
+
+	ctx, cancel := context.WithTimeout(m.context(), h.Timeout)
+	defer cancel()
+	err := cr.RunContext(ctx, body)
+	if err != nil && ctx.Err() == context.DeadlineExceeded && m.context().Err() == nil {
+		return timeoutError{err: err, timeout: h.Timeout}
+	}
+	return err
+}
+
+// render applies m.templateFunc to the body of migr, if set.
+func (m *Migrate) render(migr *Migration) (io.Reader, error) {
+	if m.templateFunc == nil {
+		return migr.BufferedBody, nil
+	}
+
+	content, err := ioutil.ReadAll(migr.BufferedBody)
+	if err != nil {
+		return nil, err
+	}
+	content, err = m.templateFunc(migr.Version, content)
+	if err != nil {
+		return nil, fmt.Errorf("render %v: %w", migr.LogString(), err)
+	}
+	return bytes.NewReader(content), nil
+}
+
+// verify runs the verification query declared in the headers of migr, if any.
+func (m *Migrate) verify(migr *Migration, h headers) error {
+	if len(h.Verify) == 0 {
+		return nil
+	}
+
+	v, ok := m.databaseDrv.(database.Verifier)
+	if !ok {
+		return fmt.Errorf("verify %v: %w", migr.LogString(), ErrNotSupported)
+	}
+
+	m.logVerbosePrintf("Verify %v\n", migr.LogString())
+	ok, err := v.Verify(h.Verify)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrVerificationFailed{Version: migr.Version, Query: h.Verify}
+	}
 	return nil
 }
 
@@ -875,6 +1244,10 @@ func (m *Migrate) newMigration(version uint, targetVersion int) (*Migration, err
 		}
 	}
 
+	if err := m.withProgress(migr); err != nil {
+		return nil, err
+	}
+
 	if m.PrefetchMigrations > 0 && migr.Body != nil {
 		m.logVerbosePrintf("Start buffering %v\n", migr.LogString())
 	} else {
@@ -891,47 +1264,58 @@ func (m *Migrate) lock() error {
 	defer m.isLockedMu.Unlock()
 
 	if m.isLocked {
+		if m.isLockHeld {
+			return nil
+		}
 		return ErrLocked
 	}
 
-	// create done channel, used in the timeout goroutine
-	done := make(chan bool, 1)
-	defer func() {
-		done <- true
-	}()
+	var err error
+	if m.lockRetry != nil {
+		err = m.retryLock()
+	} else {
+		err = m.tryLock()
+	}
+	if err == nil {
+		m.isLocked = true
+	}
+	return err
+}
 
-	// use errchan to signal error back to this context
-	errchan := make(chan error, 2)
+// tryLock makes a single attempt to lock the database within LockTimeout.
+func (m *Migrate) tryLock() error {
+	_, err := m.attemptLock()
+	return err
+}
 
-	// start timeout goroutine
-	timeout := time.After(m.LockTimeout)
-	go func() {
-		for {
-			select {
-			case <-done:
-				return
-			case <-timeout:
-				errchan <- ErrLockTimeout
-				return
-			}
-		}
-	}()
+// attemptLock makes a single attempt to lock the database within
+// LockTimeout. If it times out, or the context of m is done, before the
+// attempt has returned, e.g. because the database driver doesn't implement
+// database.ContextLocker, pending receives its result once it returns.
+func (m *Migrate) attemptLock() (pending <-chan error, err error) {
+	result := make(chan error, 1)
+
+	parent := m.context()
+	ctx, cancel := context.WithTimeout(parent, m.LockTimeout)
 
 	// now try to acquire the lock
 	go func() {
-		if err := m.databaseDrv.Lock(); err != nil {
-			errchan <- err
-		} else {
-			errchan <- nil
-		}
+		defer cancel()
+		result <- m.lockVersions(ctx)
 	}()
 
-	// wait until we either receive ErrLockTimeout or error from Lock operation
-	err := <-errchan
-	if err == nil {
-		m.isLocked = true
+	timeout := time.NewTimer(m.LockTimeout)
+	defer timeout.Stop()
+
+	// wait until we either time out or receive the error from Lock operation
+	select {
+	case err := <-result:
+		return nil, err
+	case <-timeout.C:
+		return result, ErrLockTimeout
+	case <-parent.Done():
+		return result, parent.Err()
 	}
-	return err
 }
 
 // unlock is a thread safe helper function to unlock the database.
@@ -941,8 +1325,13 @@ func (m *Migrate) unlock() error {
 	m.isLockedMu.Lock()
 	defer m.isLockedMu.Unlock()
 
-	if err := m.databaseDrv.Unlock(); err != nil {
-		// BUG: Can potentially create a deadlock. Add a timeout.
+	if m.isLockHeld {
+		return nil
+	}
+
+	if err := m.unlockVersions(); err != nil {
+		// BUG: Can potentially create a deadlock if the database driver
+		// doesn't implement database.ContextLocker. Add a timeout.
 		return err
 	}
 
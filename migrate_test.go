@@ -2,16 +2,21 @@ package migrate
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"errors"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
 	"strings"
 	"testing"
+	"text/template"
+	"time"
 )
 
 import (
+	"github.com/golang-migrate/migrate/v4/database"
 	dStub "github.com/golang-migrate/migrate/v4/database/stub"
 	"github.com/golang-migrate/migrate/v4/source"
 	sStub "github.com/golang-migrate/migrate/v4/source/stub"
@@ -218,6 +223,34 @@ func TestNewWithInstance(t *testing.T) {
 	}
 }
 
+func TestNewWithInstanceUp(t *testing.T) {
+	sInst, err := sStub.WithInstance(&DummyInstance{"source"}, &sStub.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sInst.(*sStub.Stub).Migrations = sourceStubMigrations
+
+	dbInst, err := dStub.WithInstance(&DummyInstance{"database"}, &dStub.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewWithInstance(srcDrvNameStub, sInst, dbDrvNameStub, dbInst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	dbDrv := dbInst.(*dStub.Stub)
+	if dbDrv.CurrentVersion != 7 || dbDrv.IsDirty {
+		t.Errorf("expected clean version 7, got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+	equalDbSeq(t, 0, migrationSequence{mr("CREATE 1"), mr("CREATE 3"), mr("CREATE 4"), mr("CREATE 7")}, dbDrv)
+}
+
 func ExampleNewWithInstance() {
 	// See NewWithDatabaseInstance and NewWithSourceInstance for an example.
 }
@@ -1415,3 +1448,283 @@ func equalDbSeq(t *testing.T, i int, expected migrationSequence, got *dStub.Stub
 		t.Fatalf("\nexpected sequence %v,\ngot               %v, in %v", bs, got.MigrationSequence, i)
 	}
 }
+
+func TestVerify(t *testing.T) {
+	migrations := source.NewMigrations()
+	migrations.Append(&source.Migration{Version: 1, Direction: source.Up, Identifier: "-- migrate:verify SELECT 1\nCREATE 1"})
+	migrations.Append(&source.Migration{Version: 2, Direction: source.Up, Identifier: "-- migrate:verify SELECT 2\nCREATE 2"})
+
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = migrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+	dbDrv.VerifyResults = map[string]bool{"SELECT 1": true, "SELECT 2": false}
+
+	err := m.Up()
+	var verr ErrVerificationFailed
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected ErrVerificationFailed, got %v", err)
+	}
+	if verr.Version != 2 || verr.Query != "SELECT 2" {
+		t.Errorf("expected failed verification of version 2, got %+v", verr)
+	}
+
+	// the failed migration is rolled back, as the stub supports transactions
+	v, dirty, err := m.Version()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 1 || dirty {
+		t.Errorf("expected clean version 1, got %v (dirty: %v)", v, dirty)
+	}
+	equalDbSeq(t, 0, migrationSequence{mr("-- migrate:verify SELECT 1\nCREATE 1")}, dbDrv)
+
+	dbDrv.VerifyResults["SELECT 2"] = true
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	v, dirty, err = m.Version()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 2 || dirty {
+		t.Errorf("expected clean version 2, got %v (dirty: %v)", v, dirty)
+	}
+	if m.inTx {
+		t.Error("expected inTx to be reset")
+	}
+}
+
+// nonTxVerifierStub is a stub database driver supporting verification
+// queries, but not transactions.
+type nonTxVerifierStub struct {
+	nonTxStub
+}
+
+func (s nonTxVerifierStub) Verify(query string) (bool, error) {
+	return s.Driver.(database.Verifier).Verify(query)
+}
+
+func TestVerifyWithoutTransaction(t *testing.T) {
+	migrations := source.NewMigrations()
+	migrations.Append(&source.Migration{Version: 1, Direction: source.Up, Identifier: "-- migrate:verify SELECT 1\nCREATE 1"})
+
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = migrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+	dbDrv.VerifyResults = map[string]bool{"SELECT 1": false}
+	m.databaseDrv = nonTxVerifierStub{nonTxStub{dbDrv}}
+
+	if err := m.Up(); !errors.As(err, &ErrVerificationFailed{}) {
+		t.Fatalf("expected ErrVerificationFailed, got %v", err)
+	}
+	// nothing is rolled back
+	if dbDrv.CurrentVersion != 1 || !dbDrv.IsDirty {
+		t.Errorf("expected dirty version 1, got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+	equalDbSeq(t, 0, migrationSequence{mr("-- migrate:verify SELECT 1\nCREATE 1")}, dbDrv)
+}
+
+// slowStub is a stub database driver taking delay to run a migration,
+// unless the context is done before.
+type slowStub struct {
+	*dStub.Stub
+	delay time.Duration
+}
+
+func (s *slowStub) RunContext(ctx context.Context, migration io.Reader) error {
+	select {
+	case <-time.After(s.delay):
+		return s.Stub.Run(migration)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestTimeoutHeader(t *testing.T) {
+	testCases := []struct {
+		name    string
+		timeout string
+		err     error
+	}{
+		{name: "timeout fires", timeout: "10ms", err: context.DeadlineExceeded},
+		{name: "timeout passes", timeout: "10s"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			migrations := source.NewMigrations()
+			migrations.Append(&source.Migration{Version: 1, Direction: source.Up, Identifier: "-- migrate:timeout " + tc.timeout + "\nCREATE 1"})
+
+			m, _ := New("stub://", "stub://")
+			m.sourceDrv.(*sStub.Stub).Migrations = migrations
+			dbDrv := &slowStub{Stub: m.databaseDrv.(*dStub.Stub), delay: 100 * time.Millisecond}
+			m.databaseDrv = dbDrv
+
+			if err := m.Up(); !errors.Is(err, tc.err) {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+
+			v, dirty, err := m.Version()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v != 1 || dirty != (tc.err != nil) {
+				t.Errorf("expected version 1 (dirty: %v), got %v (dirty: %v)", tc.err != nil, v, dirty)
+			}
+		})
+	}
+}
+
+func TestSetTemplateFunc(t *testing.T) {
+	migrations := source.NewMigrations()
+	migrations.Append(&source.Migration{Version: 1, Direction: source.Up, Identifier: "CREATE TABLE {{.Schema}}.users"})
+
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = migrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+
+	var versions []uint
+	m.SetTemplateFunc(func(version uint, content []byte) ([]byte, error) {
+		versions = append(versions, version)
+		tmpl, err := template.New("migration").Parse(string(content))
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, struct{ Schema string }{"staging"}); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	})
+
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	if string(dbDrv.LastRunMigration) != "CREATE TABLE staging.users" {
+		t.Errorf("expected rendered migration, got %q", dbDrv.LastRunMigration)
+	}
+	if len(versions) != 1 || versions[0] != 1 {
+		t.Errorf("expected template func to be called for version 1, got %v", versions)
+	}
+}
+
+func TestSetTemplateFuncError(t *testing.T) {
+	migrations := source.NewMigrations()
+	migrations.Append(&source.Migration{Version: 1, Direction: source.Up, Identifier: "CREATE 1"})
+
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = migrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+
+	errTemplate := errors.New("template error")
+	m.SetTemplateFunc(func(version uint, content []byte) ([]byte, error) {
+		return nil, errTemplate
+	})
+
+	if err := m.Up(); !errors.Is(err, errTemplate) {
+		t.Fatalf("expected template error, got %v", err)
+	}
+	if len(dbDrv.MigrationSequence) != 0 {
+		t.Errorf("expected no migrations to run, got %v", dbDrv.MigrationSequence)
+	}
+}
+
+// concurrentStub is a stub database driver simulating another process
+// writing the version while a migration is run.
+type concurrentStub struct {
+	*dStub.Stub
+}
+
+func (s *concurrentStub) Run(migration io.Reader) error {
+	if err := s.Stub.Run(migration); err != nil {
+		return err
+	}
+	return s.Stub.SetVersion(42, false)
+}
+
+func TestConcurrentModification(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+
+	if err := m.Steps(1); err != nil {
+		t.Fatal(err)
+	}
+
+	m.databaseDrv = &concurrentStub{Stub: dbDrv}
+	if err := m.Steps(1); !errors.Is(err, database.ErrConcurrentModification) {
+		t.Fatalf("expected %v, got %v", database.ErrConcurrentModification, err)
+	}
+
+	// the version written by the other process is kept
+	if dbDrv.CurrentVersion != 42 || dbDrv.IsDirty {
+		t.Errorf("expected version 42 (dirty: false), got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+
+	// the version is read again by the next operation
+	m.databaseDrv = dbDrv
+	if err := m.Force(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Steps(1); err != nil {
+		t.Fatal(err)
+	}
+	if dbDrv.CurrentVersion != 4 {
+		t.Errorf("expected version 4, got %v", dbDrv.CurrentVersion)
+	}
+}
+
+// versionReadingStub is a concurrentStub whose version is read with
+// Version, e.g. by another goroutine, once the other process wrote it.
+type versionReadingStub struct {
+	concurrentStub
+	m *Migrate
+}
+
+func (s *versionReadingStub) Run(migration io.Reader) error {
+	if err := s.concurrentStub.Run(migration); err != nil {
+		return err
+	}
+	_, _, err := s.m.Version()
+	return err
+}
+
+func TestConcurrentModificationVersion(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+
+	if err := m.Steps(1); err != nil {
+		t.Fatal(err)
+	}
+
+	// reading the version doesn't hide the concurrent write
+	m.databaseDrv = &versionReadingStub{concurrentStub: concurrentStub{Stub: dbDrv}, m: m}
+	if err := m.Steps(1); !errors.Is(err, database.ErrConcurrentModification) {
+		t.Fatalf("expected %v, got %v", database.ErrConcurrentModification, err)
+	}
+}
+
+func TestForceConcurrentModification(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+
+	if err := m.Steps(1); err != nil {
+		t.Fatal(err)
+	}
+
+	// another process writes the version after it was read last
+	if err := dbDrv.SetVersion(42, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Force(3); err != nil {
+		t.Fatal(err)
+	}
+	if dbDrv.CurrentVersion != 3 || dbDrv.IsDirty {
+		t.Errorf("expected version 3 (dirty: false), got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+}
@@ -0,0 +1,169 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// ErrUnknownPhase is returned by UpPhase if no up migration
+// declares the phase.
+var ErrUnknownPhase = errors.New("unknown phase")
+
+// UpPhase applies the up migrations declaring the phase name with a
+// `-- migrate:phase <name>` header. The versions of a phase must follow
+// each other in the source, and the first pending one must follow the
+// currently active version.
+// If the database driver implements database.Transactioner, the phase is
+// applied in a single transaction, so a failing migration rolls back the
+// whole phase. Otherwise UpPhase stops at the failing migration, like Up.
+func (m *Migrate) UpPhase(name string) error {
+	if err := m.lock(); err != nil {
+		return err
+	}
+
+	curVersion, dirty, err := m.versions().GetVersion()
+	if err != nil {
+		return m.unlockErr(err)
+	}
+
+	if dirty {
+		return m.unlockErr(ErrDirty{curVersion})
+	}
+
+	pending, err := m.phaseVersions(name, curVersion)
+	if err != nil {
+		return m.unlockErr(err)
+	}
+
+	tx, ok := m.databaseDrv.(database.Transactioner)
+	if !ok {
+		m.logVerbosePrintf("Database driver doesn't support transactions, applying phase %v without a transaction\n", name)
+		ret := make(chan interface{}, m.PrefetchMigrations)
+		go m.readUp(curVersion, pending, ret)
+		return m.unlockErr(m.runMigrations(ret))
+	}
+
+	if err := tx.Begin(); err != nil {
+		return m.unlockErr(err)
+	}
+
+	m.inTx = true
+	defer func() { m.inTx = false }()
+
+	ret := make(chan interface{}, m.PrefetchMigrations)
+	go m.readUp(curVersion, pending, ret)
+	if err := m.runMigrations(ret); err != nil {
+		return m.unlockErr(m.rollbackTx(tx, curVersion, err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return m.unlockErr(m.rollbackTx(tx, curVersion, err))
+	}
+
+	m.logPrintf("Applied phase %v\n", name)
+	return m.unlock()
+}
+
+// rollbackTx rolls back the transaction of the migrations run from
+// curVersion, and restores curVersion if it isn't kept by the database
+// driver. prevErr is the error the migrations failed with, if any.
+func (m *Migrate) rollbackTx(tx database.Transactioner, curVersion int, prevErr error) error {
+	if err := tx.Rollback(); err != nil {
+		return multierror.Append(prevErr, err)
+	}
+
+	// the epoch written during the transaction was rolled back, too
+	m.epoch.invalidate()
+
+	if m.versionStore != nil {
+		if err := m.versionStore.SetVersion(curVersion, false); err != nil {
+			return multierror.Append(prevErr, err)
+		}
+	}
+	return prevErr
+}
+
+// phaseVersions returns the number of versions of the phase name following
+// curVersion in the source.
+func (m *Migrate) phaseVersions(name string, curVersion int) (pending int, err error) {
+	version, err := m.sourceDrv.First()
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, fmt.Errorf("%w: %v", ErrUnknownPhase, name)
+	} else if err != nil {
+		return 0, err
+	}
+
+	it, err := source.Iterate(m.sourceDrv, version)
+	if err != nil {
+		return 0, err
+	}
+
+	// next is the first version following curVersion
+	var next uint
+	var hasNext, started, ended bool
+	var total int
+	for ok := true; ok; version, ok = it.Next() {
+		if !hasNext && int(version) > curVersion {
+			next, hasNext = version, true
+		}
+
+		phase, err := m.phase(version)
+		if err != nil {
+			return 0, err
+		}
+		if phase != name {
+			ended = started
+			continue
+		}
+		if ended {
+			return 0, fmt.Errorf("phase %v: version %v doesn't follow the other versions of the phase", name, version)
+		}
+		started = true
+		total++
+
+		if int(version) > curVersion {
+			if pending == 0 && version != next {
+				return 0, fmt.Errorf("phase %v: version %v must be applied before the phase", name, next)
+			}
+			pending++
+		}
+	}
+	if err := it.Err(); err != nil {
+		return 0, err
+	}
+
+	if total == 0 {
+		return 0, fmt.Errorf("%w: %v", ErrUnknownPhase, name)
+	}
+	if pending == 0 {
+		return 0, ErrNoChange
+	}
+	return pending, nil
+}
+
+// phase returns the phase declared by the up migration of version, if any.
+func (m *Migrate) phase(version uint) (phase string, err error) {
+	r, _, err := m.sourceDrv.ReadUp(version)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	defer func() {
+		if errClose := r.Close(); errClose != nil {
+			err = multierror.Append(err, errClose)
+		}
+	}()
+
+	h, _, err := readHeaders(r)
+	if err != nil {
+		return "", err
+	}
+	return h.Phase, nil
+}
@@ -0,0 +1,123 @@
+package migrate
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	"github.com/golang-migrate/migrate/v4/source"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+func phaseMigrations() *source.Migrations {
+	migrations := source.NewMigrations()
+	migrations.Append(&source.Migration{Version: 1, Direction: source.Up, Identifier: "-- migrate:phase p1\nCREATE 1"})
+	migrations.Append(&source.Migration{Version: 2, Direction: source.Up, Identifier: "-- migrate:phase p2\nCREATE 2"})
+	migrations.Append(&source.Migration{Version: 3, Direction: source.Up, Identifier: "-- migrate:phase p2\nCREATE 3"})
+	migrations.Append(&source.Migration{Version: 4, Direction: source.Up, Identifier: "-- migrate:phase p2\nCREATE 4"})
+	migrations.Append(&source.Migration{Version: 5, Direction: source.Up, Identifier: "CREATE 5"})
+	return migrations
+}
+
+func TestUpPhase(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = phaseMigrations()
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+
+	if err := m.UpPhase("p2"); err == nil {
+		t.Error("expected error applying phase p2 before version 1")
+	}
+
+	if err := m.UpPhase("unknown"); !errors.Is(err, ErrUnknownPhase) {
+		t.Errorf("expected ErrUnknownPhase, got %v", err)
+	}
+
+	if err := m.UpPhase("p1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.UpPhase("p2"); err != nil {
+		t.Fatal(err)
+	}
+	if dbDrv.CurrentVersion != 4 || dbDrv.IsDirty {
+		t.Errorf("expected version 4 (dirty: false), got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+	equalDbSeq(t, 0, migrationSequence{
+		mr("-- migrate:phase p1\nCREATE 1"),
+		mr("-- migrate:phase p2\nCREATE 2"),
+		mr("-- migrate:phase p2\nCREATE 3"),
+		mr("-- migrate:phase p2\nCREATE 4"),
+	}, dbDrv)
+
+	if err := m.UpPhase("p2"); !errors.Is(err, ErrNoChange) {
+		t.Errorf("expected ErrNoChange, got %v", err)
+	}
+}
+
+func TestUpPhaseRollback(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = phaseMigrations()
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+	m.databaseDrv = &failingStub{Stub: dbDrv, failOn: "-- migrate:phase p2\nCREATE 3"}
+
+	if err := m.UpPhase("p1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.UpPhase("p2"); err == nil {
+		t.Fatal("expected error applying phase p2")
+	}
+
+	// none of the migrations of the phase is kept
+	if dbDrv.CurrentVersion != 1 || dbDrv.IsDirty {
+		t.Errorf("expected version 1 (dirty: false), got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+	equalDbSeq(t, 0, migrationSequence{mr("-- migrate:phase p1\nCREATE 1")}, dbDrv)
+
+	// the phase can be applied again once fixed
+	m.databaseDrv = dbDrv
+	if err := m.UpPhase("p2"); err != nil {
+		t.Fatal(err)
+	}
+	if dbDrv.CurrentVersion != 4 {
+		t.Errorf("expected version 4, got %v", dbDrv.CurrentVersion)
+	}
+}
+
+// nonTxStub is a database driver not supporting transactions.
+type nonTxStub struct {
+	database.Driver
+}
+
+func TestUpPhaseWithoutTransaction(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = phaseMigrations()
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+	m.databaseDrv = nonTxStub{&failingStub{Stub: dbDrv, failOn: "-- migrate:phase p2\nCREATE 3"}}
+
+	if err := m.UpPhase("p1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.UpPhase("p2"); err == nil {
+		t.Fatal("expected error applying phase p2")
+	}
+
+	// the phase stops at the failing migration
+	if dbDrv.CurrentVersion != 3 || !dbDrv.IsDirty {
+		t.Errorf("expected version 3 (dirty: true), got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+}
+
+func TestUpPhaseNotContiguous(t *testing.T) {
+	migrations := phaseMigrations()
+	migrations.Append(&source.Migration{Version: 6, Direction: source.Up, Identifier: "-- migrate:phase p2\nCREATE 6"})
+
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = migrations
+
+	if err := m.UpPhase("p1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.UpPhase("p2"); err == nil {
+		t.Error("expected error applying phase p2 interrupted by version 5")
+	}
+}
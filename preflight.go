@@ -0,0 +1,152 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// ErrMissingUpFile is returned if RequireMigrationFiles is set and
+// a version to be migrated up has no up migration in the source.
+type ErrMissingUpFile struct {
+	Version uint
+}
+
+// Error implements the error interface.
+func (e ErrMissingUpFile) Error() string {
+	return fmt.Sprintf("missing up migration for version %v", e.Version)
+}
+
+// ErrMissingDownFile is returned if RequireMigrationFiles is set and
+// a version to be migrated down has no down migration in the source.
+type ErrMissingDownFile struct {
+	Version uint
+}
+
+// Error implements the error interface.
+func (e ErrMissingDownFile) Error() string {
+	return fmt.Sprintf("missing down migration for version %v", e.Version)
+}
+
+// preflightVersion returns the currently active version to check the
+// migration files from. ok is false if there's nothing to check, either
+// because RequireMigrationFiles isn't set or because the version can't
+// be used. Errors are reported once the lock has been taken.
+func (m *Migrate) preflightVersion() (curVersion int, ok bool) {
+	if !m.RequireMigrationFiles {
+		return 0, false
+	}
+	curVersion, dirty, err := m.readVersion()
+	if err != nil || dirty {
+		return 0, false
+	}
+	return curVersion, true
+}
+
+// preflightMigrate checks the migration files needed to migrate to version.
+func (m *Migrate) preflightMigrate(version uint) error {
+	if err := m.preflightFingerprints(); err != nil {
+		return err
+	}
+	curVersion, ok := m.preflightVersion()
+	if !ok {
+		return nil
+	}
+	if int(version) > curVersion {
+		return m.checkUpFiles(curVersion, int(version), -1)
+	}
+	return m.checkDownFiles(curVersion, int(version), -1)
+}
+
+// preflightUp checks the up migration files after the currently active
+// version up to version to, reading at most limit versions.
+// to and limit can be -1, implying no bound.
+func (m *Migrate) preflightUp(to int, limit int) error {
+	if err := m.preflightFingerprints(); err != nil {
+		return err
+	}
+	curVersion, ok := m.preflightVersion()
+	if !ok {
+		return nil
+	}
+	return m.checkUpFiles(curVersion, to, limit)
+}
+
+// preflightDown checks the down migration files from the currently active
+// version down to version to, reading at most limit versions.
+// limit can be -1, implying no limit.
+func (m *Migrate) preflightDown(to int, limit int) error {
+	if err := m.preflightFingerprints(); err != nil {
+		return err
+	}
+	curVersion, ok := m.preflightVersion()
+	if !ok {
+		return nil
+	}
+	return m.checkDownFiles(curVersion, to, limit)
+}
+
+func (m *Migrate) checkUpFiles(from int, to int, limit int) error {
+	var version uint
+	var err error
+	if from == database.NilVersion {
+		version, err = m.sourceDrv.First()
+	} else {
+		version, err = m.sourceDrv.Next(suint(from))
+	}
+
+	for count := 0; err == nil && (to < 0 || int(version) <= to) && (limit < 0 || count < limit); count++ {
+		if err := m.checkFile(version, source.Up); err != nil {
+			return err
+		}
+		version, err = m.sourceDrv.Next(version)
+	}
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (m *Migrate) checkDownFiles(from int, to int, limit int) error {
+	for count := 0; from > to && from >= 0 && (limit < 0 || count < limit); count++ {
+		if err := m.checkFile(suint(from), source.Down); err != nil {
+			return err
+		}
+
+		prev, err := m.sourceDrv.Prev(suint(from))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		from = int(prev)
+	}
+	return nil
+}
+
+// checkFile checks that the migration file of version in direction d
+// exists and can be opened.
+func (m *Migrate) checkFile(version uint, d source.Direction) error {
+	var r io.ReadCloser
+	var err error
+	if d == source.Up {
+		r, _, err = m.sourceDrv.ReadUp(version)
+	} else {
+		r, _, err = m.sourceDrv.ReadDown(version)
+	}
+
+	if errors.Is(err, os.ErrNotExist) {
+		if d == source.Up {
+			return ErrMissingUpFile{Version: version}
+		}
+		return ErrMissingDownFile{Version: version}
+	} else if err != nil {
+		return err
+	}
+	return r.Close()
+}
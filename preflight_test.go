@@ -0,0 +1,48 @@
+package migrate
+
+import (
+	"testing"
+
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+func TestRequireMigrationFiles(t *testing.T) {
+	tt := []struct {
+		name      string
+		version   int
+		run       func(m *Migrate) error
+		expectErr error
+	}{
+		{name: "up", version: -1, run: (*Migrate).Up, expectErr: ErrMissingUpFile{Version: 5}},
+		{name: "up before missing file", version: -1, run: func(m *Migrate) error { return m.Migrate(4) }},
+		{name: "steps up", version: 4, run: func(m *Migrate) error { return m.Steps(1) }, expectErr: ErrMissingUpFile{Version: 5}},
+		{name: "down", version: 7, run: (*Migrate).Down, expectErr: ErrMissingDownFile{Version: 3}},
+		{name: "steps down", version: 4, run: func(m *Migrate) error { return m.Steps(-2) }, expectErr: ErrMissingDownFile{Version: 3}},
+		{name: "steps down before missing file", version: 7, run: func(m *Migrate) error { return m.Steps(-2) }},
+		{name: "migrate down", version: 7, run: func(m *Migrate) error { return m.Migrate(1) }, expectErr: ErrMissingDownFile{Version: 3}},
+	}
+
+	for _, v := range tt {
+		t.Run(v.name, func(t *testing.T) {
+			m, _ := New("stub://", "stub://")
+			m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+			m.RequireMigrationFiles = true
+			dbDrv := m.databaseDrv.(*dStub.Stub)
+			dbDrv.CurrentVersion = v.version
+
+			err := v.run(m)
+			if err != v.expectErr {
+				t.Fatalf("expected %v, got %v", v.expectErr, err)
+			}
+			if v.expectErr != nil {
+				if dbDrv.CurrentVersion != v.version || len(dbDrv.MigrationSequence) != 0 {
+					t.Errorf("expected no migration to run, got version %v and sequence %v", dbDrv.CurrentVersion, dbDrv.MigrationSequence)
+				}
+				if dbDrv.IsLocked || m.isLocked {
+					t.Error("expected the lock not to be taken")
+				}
+			}
+		})
+	}
+}
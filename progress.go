@@ -0,0 +1,66 @@
+package migrate
+
+import (
+	"io"
+
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// ProgressFunc is called while the migration migr is read from the source,
+// with the number of bytes read so far and the length of the migration.
+type ProgressFunc func(migr *Migration, read, total int64)
+
+// SetProgressFunc sets a function reporting the progress of reading each
+// migration from the source, as its content is streamed to the database,
+// i.e. to log the progress of loading large seed files. Progress is only
+// reported if the source driver implements source.Sized. fn must be safe
+// for concurrent use, as migrations are prefetched in parallel, see
+// PrefetchMigrations.
+func (m *Migrate) SetProgressFunc(fn ProgressFunc) {
+	m.progressFunc = fn
+}
+
+// withProgress wraps the body of migr to report progress to
+// m.progressFunc, if set and supported by the source driver.
+func (m *Migrate) withProgress(migr *Migration) error {
+	if m.progressFunc == nil || migr.Body == nil {
+		return nil
+	}
+
+	sized, ok := m.sourceDrv.(source.Sized)
+	if !ok {
+		return nil
+	}
+
+	var total int64
+	var err error
+	if migr.TargetVersion >= int(migr.Version) {
+		total, err = sized.SizeUp(migr.Version)
+	} else {
+		total, err = sized.SizeDown(migr.Version)
+	}
+	if err != nil {
+		return err
+	}
+
+	migr.Body = &progressReader{ReadCloser: migr.Body, migr: migr, total: total, fn: m.progressFunc}
+	return nil
+}
+
+// progressReader calls fn with the number of bytes read from ReadCloser.
+type progressReader struct {
+	io.ReadCloser
+	migr  *Migration
+	read  int64
+	total int64
+	fn    ProgressFunc
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.read += int64(n)
+		r.fn(r.migr, r.read, r.total)
+	}
+	return n, err
+}
@@ -0,0 +1,84 @@
+package migrate
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	"github.com/golang-migrate/migrate/v4/source"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+// unsizedStub is a source driver not reporting the length of migrations.
+type unsizedStub struct {
+	source.Driver
+}
+
+func TestProgressFunc(t *testing.T) {
+	seed := strings.Repeat("INSERT INTO seed VALUES (1);\n", 1<<16)
+
+	m, _ := New("stub://", "stub://")
+	migrations := source.NewMigrations()
+	migrations.Append(&source.Migration{Version: 1, Direction: source.Up, Identifier: "CREATE TABLE seed"})
+	migrations.Append(&source.Migration{Version: 2, Direction: source.Up, Identifier: seed})
+	m.sourceDrv.(*sStub.Stub).Migrations = migrations
+
+	type progress struct {
+		version     uint
+		read, total int64
+	}
+	var mu sync.Mutex
+	var reports []progress
+	m.SetProgressFunc(func(migr *Migration, read, total int64) {
+		mu.Lock()
+		defer mu.Unlock()
+		reports = append(reports, progress{migr.Version, read, total})
+	})
+
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var seedReports []progress
+	for _, p := range reports {
+		if p.version == 2 {
+			seedReports = append(seedReports, p)
+		}
+	}
+	if len(seedReports) < 2 {
+		t.Fatalf("expected several progress reports for the seed, got %v", len(seedReports))
+	}
+	for i, p := range seedReports {
+		if p.total != int64(len(seed)) {
+			t.Fatalf("expected total %v, got %v", len(seed), p.total)
+		}
+		if i > 0 && p.read <= seedReports[i-1].read {
+			t.Fatalf("expected increasing progress, got %v after %v", p.read, seedReports[i-1].read)
+		}
+	}
+	if last := seedReports[len(seedReports)-1]; last.read != last.total {
+		t.Errorf("expected the seed to be read completely, got %v of %v bytes", last.read, last.total)
+	}
+
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+	if got := dbDrv.MigrationSequence[len(dbDrv.MigrationSequence)-1]; got != seed {
+		t.Errorf("expected the seed to be run unchanged, got %v bytes", len(got))
+	}
+}
+
+func TestProgressFuncUnsizedSource(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	m.sourceDrv = unsizedStub{m.sourceDrv}
+
+	m.SetProgressFunc(func(migr *Migration, read, total int64) {
+		t.Errorf("unexpected progress report for %v", migr.LogString())
+	})
+
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,46 @@
+package migrate
+
+import (
+	"io"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// RunRaw opens the database at databaseURL and runs up as is, e.g. a script
+// piped to stdin, while holding the lock of the database. It bypasses
+// versioning: the version is neither read nor set, and up isn't rendered or
+// parsed for headers. Use it for one-off scripts only, as the changes aren't
+// recorded anywhere.
+func RunRaw(databaseURL string, up io.Reader) (err error) {
+	databaseURL, err = resolveURL(databaseURL)
+	if err != nil {
+		return err
+	}
+	databaseDrv, err := database.Open(databaseURL)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if errClose := databaseDrv.Close(); errClose != nil {
+			err = multierror.Append(err, errClose)
+		}
+	}()
+	return RunRawWithInstance(databaseDrv, up)
+}
+
+// RunRawWithInstance is like RunRaw, but runs up with an existing database
+// driver instance, which is left open.
+func RunRawWithInstance(databaseInstance database.Driver, up io.Reader) error {
+	if err := databaseInstance.Lock(); err != nil {
+		return err
+	}
+	if err := databaseInstance.Run(up); err != nil {
+		if errUnlock := databaseInstance.Unlock(); errUnlock != nil {
+			return multierror.Append(err, errUnlock)
+		}
+		return err
+	}
+	return databaseInstance.Unlock()
+}
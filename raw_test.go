@@ -0,0 +1,51 @@
+package migrate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+)
+
+func TestRunRaw(t *testing.T) {
+	if err := RunRaw("stub://", strings.NewReader("CREATE TABLE t")); err != nil {
+		t.Fatal(err)
+	}
+	if err := RunRaw("unknown://", strings.NewReader("CREATE TABLE t")); err == nil {
+		t.Error("expected error for unknown database driver")
+	}
+}
+
+func TestRunRawWithInstance(t *testing.T) {
+	d, err := dStub.WithInstance(nil, &dStub.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := d.(*dStub.Stub)
+	stub.CurrentVersion = 3
+
+	if err := RunRawWithInstance(d, strings.NewReader("CREATE TABLE t")); err != nil {
+		t.Fatal(err)
+	}
+	if string(stub.LastRunMigration) != "CREATE TABLE t" {
+		t.Errorf("expected raw statement to be run, got %q", stub.LastRunMigration)
+	}
+	if v, dirty, err := d.Version(); err != nil {
+		t.Fatal(err)
+	} else if v != 3 || dirty {
+		t.Errorf("expected version 3 to be unchanged, got %v (dirty %v)", v, dirty)
+	}
+	if stub.IsLocked {
+		t.Error("expected lock to be released")
+	}
+
+	stub.IsLocked = true
+	if err := RunRawWithInstance(d, strings.NewReader("DROP TABLE t")); !errors.Is(err, database.ErrLocked) {
+		t.Errorf("expected database.ErrLocked, got %v", err)
+	}
+	if string(stub.LastRunMigration) != "CREATE TABLE t" {
+		t.Errorf("expected nothing to be run while locked, got %q", stub.LastRunMigration)
+	}
+}
@@ -0,0 +1,27 @@
+package migrate
+
+import (
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// ReloadSource makes the source driver pick up migrations added, changed or
+// removed since it was opened, e.g. files edited on disk, so long-running
+// services don't need to create a new Migrate instance. It returns
+// ErrLocked while migrations are run by this instance, and ErrNotSupported
+// if the source driver doesn't implement source.Reloader.
+func (m *Migrate) ReloadSource() error {
+	r, ok := m.sourceDrv.(source.Reloader)
+	if !ok {
+		return fmt.Errorf("reload source %v: %w", m.sourceName, ErrNotSupported)
+	}
+
+	m.isLockedMu.Lock()
+	defer m.isLockedMu.Unlock()
+
+	if m.isLocked {
+		return ErrLocked
+	}
+	return r.Reload()
+}
@@ -0,0 +1,88 @@
+package migrate
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+)
+
+func TestReloadSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate-reload-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Error(err)
+		}
+	}()
+	writeMigration := func(name, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeMigration("1_init.up.sql", "CREATE 1")
+
+	m, err := New("file://"+dir, "stub://")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	writeMigration("2_users.up.sql", "CREATE 2")
+	if err := m.Up(); !errors.Is(err, ErrNoChange) {
+		t.Fatalf("expected ErrNoChange before reload, got %v", err)
+	}
+
+	if err := m.ReloadSource(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	equalDbSeq(t, 0, migrationSequence{mr("CREATE 1"), mr("CREATE 2")}, dbDrv)
+}
+
+func TestReloadSourceNotSupported(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	if err := m.ReloadSource(); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported, got %v", err)
+	}
+}
+
+func TestReloadSourceLocked(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate-reload-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	m, err := New("file://"+dir, "stub://")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.lock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.ReloadSource(); !errors.Is(err, ErrLocked) {
+		t.Errorf("expected ErrLocked, got %v", err)
+	}
+	if err := m.unlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.ReloadSource(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,31 @@
+package migrate
+
+import (
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// ResetVersionState forgets which migrations have been applied, leaving the
+// migrated data intact, e.g. to baseline an existing database again with
+// Force or Baseline. If the database driver implements
+// database.VersionDropper, its migrations table is dropped. Otherwise the
+// version is set to NilVersion.
+func (m *Migrate) ResetVersionState() error {
+	if err := m.lock(); err != nil {
+		return err
+	}
+
+	if d, ok := m.databaseDrv.(database.VersionDropper); ok && m.versionStore == nil {
+		if err := d.DropVersion(); err != nil {
+			return m.unlockErr(err)
+		}
+		m.epoch.invalidate()
+		m.logPrintf("Dropped the version state\n")
+		return m.unlock()
+	}
+
+	if err := m.versions().SetVersion(database.NilVersion, false); err != nil {
+		return m.unlockErr(err)
+	}
+	m.logPrintf("Reset the version state\n")
+	return m.unlock()
+}
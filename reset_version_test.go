@@ -0,0 +1,65 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+// versionDroppingStub implements database.VersionDropper, counting calls.
+type versionDroppingStub struct {
+	*dStub.Stub
+	dropped int
+}
+
+func (s *versionDroppingStub) DropVersion() error {
+	s.dropped++
+	s.CurrentVersion = database.NilVersion
+	s.IsDirty = false
+	return nil
+}
+
+func TestResetVersionState(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := &versionDroppingStub{Stub: m.databaseDrv.(*dStub.Stub)}
+	m.databaseDrv = dbDrv
+
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.ResetVersionState(); err != nil {
+		t.Fatal(err)
+	}
+	if dbDrv.dropped != 1 {
+		t.Errorf("expected DropVersion to be called once, got %v", dbDrv.dropped)
+	}
+	if _, _, err := m.Version(); err != ErrNilVersion {
+		t.Errorf("expected ErrNilVersion, got %v", err)
+	}
+	// the applied migrations aren't undone
+	equalDbSeq(t, 0, migrationSequence{mr("CREATE 1"), mr("CREATE 3"), mr("CREATE 4"), mr("CREATE 7")}, dbDrv.Stub)
+	if dbDrv.IsLocked {
+		t.Error("expected lock to be released")
+	}
+}
+
+func TestResetVersionStateWithoutDropper(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+
+	if err := m.Steps(2); err != nil {
+		t.Fatal(err)
+	}
+	dbDrv.IsDirty = true
+	if err := m.ResetVersionState(); err != nil {
+		t.Fatal(err)
+	}
+	if dbDrv.CurrentVersion != database.NilVersion || dbDrv.IsDirty {
+		t.Errorf("expected no version (dirty: false), got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+	equalDbSeq(t, 0, migrationSequence{mr("CREATE 1"), mr("CREATE 3")}, dbDrv)
+}
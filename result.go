@@ -0,0 +1,54 @@
+package migrate
+
+import (
+	"time"
+)
+
+// MigrationResult is the outcome of running a single migration.
+type MigrationResult struct {
+	// Version is the version of the migration.
+	Version uint
+
+	// TargetVersion is the version after the migration,
+	// lower than Version for down migrations.
+	TargetVersion int
+
+	// Identifier is the identifier of the migration, see Migration.
+	Identifier string
+
+	// Duration is the time spent reading and running the migration.
+	Duration time.Duration
+
+	// Err is the error the migration failed with, nil on success.
+	Err error
+}
+
+// Down reports whether the result is of a down migration.
+func (r MigrationResult) Down() bool {
+	return r.TargetVersion < int(r.Version)
+}
+
+// ResultFunc is called after each migration has been run.
+type ResultFunc func(result MigrationResult)
+
+// SetResultFunc sets a function receiving the result of every migration
+// run by Migrate, Steps, Up and Down, including the one that failed, i.e.
+// to collect the results for a report, see JUnitReport. Migrations not
+// run because an earlier one failed aren't reported.
+func (m *Migrate) SetResultFunc(fn ResultFunc) {
+	m.resultFunc = fn
+}
+
+// reportResult passes the result of running migr to m.resultFunc, if set.
+func (m *Migrate) reportResult(migr *Migration, d time.Duration, err error) {
+	if m.resultFunc == nil {
+		return
+	}
+	m.resultFunc(MigrationResult{
+		Version:       migr.Version,
+		TargetVersion: migr.TargetVersion,
+		Identifier:    migr.Identifier,
+		Duration:      d,
+		Err:           err,
+	})
+}
@@ -0,0 +1,38 @@
+package migrate
+
+import (
+	"testing"
+
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+func TestSetResultFunc(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	m.databaseDrv = &failingStub{Stub: m.databaseDrv.(*dStub.Stub), failOn: "CREATE 4"}
+
+	var results []MigrationResult
+	m.SetResultFunc(func(r MigrationResult) {
+		results = append(results, r)
+	})
+
+	if err := m.Up(); err == nil {
+		t.Fatal("expected migration to fail")
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %+v", results)
+	}
+	for i, version := range []uint{1, 3, 4} {
+		if results[i].Version != version || results[i].Down() {
+			t.Errorf("expected up migration %v, got %+v", version, results[i])
+		}
+	}
+	if results[0].Err != nil || results[1].Err != nil {
+		t.Errorf("expected versions 1 and 3 to succeed, got %v, %v", results[0].Err, results[1].Err)
+	}
+	if results[2].Err == nil {
+		t.Error("expected version 4 to fail")
+	}
+}
@@ -0,0 +1,83 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrNotReversible is returned by TestReversibility for the first version
+// that can't be migrated down cleanly to the version it was applied on.
+type ErrNotReversible struct {
+	Version uint
+	Err     error
+}
+
+// Error implements the error interface.
+func (e ErrNotReversible) Error() string {
+	return fmt.Sprintf("version %v is not reversible: %v", e.Version, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e ErrNotReversible) Unwrap() error {
+	return e.Err
+}
+
+// ErrIrreversible is returned when migrating down would roll back a version
+// whose down migration is marked with `-- migrate:irreversible` and
+// AllowIrreversible isn't set. The versions above it have been rolled back.
+type ErrIrreversible struct {
+	Version uint
+}
+
+// Error implements the error interface.
+func (e ErrIrreversible) Error() string {
+	return fmt.Sprintf("version %v is irreversible", e.Version)
+}
+
+// TestReversibility verifies that every migration from the currently active
+// version to the last version available in the source can be reversed.
+// For each version it migrates up one step, down one step, checks that the
+// database is back at the previous version and not dirty, and then migrates
+// up again to continue with the next version.
+// It returns ErrNotReversible for the first version failing to reverse cleanly.
+// TestReversibility is meant to be used in tests against a disposable database.
+func TestReversibility(m *Migrate) error {
+	for {
+		before, _, err := m.versions().GetVersion()
+		if err != nil {
+			return err
+		}
+
+		if err := m.Steps(1); errors.Is(err, os.ErrNotExist) {
+			// reached the last version
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		applied, _, err := m.versions().GetVersion()
+		if err != nil {
+			return err
+		}
+
+		if err := m.Steps(-1); err != nil {
+			return ErrNotReversible{Version: suint(applied), Err: err}
+		}
+
+		after, dirty, err := m.versions().GetVersion()
+		if err != nil {
+			return err
+		}
+		if after != before || dirty {
+			return ErrNotReversible{
+				Version: suint(applied),
+				Err:     fmt.Errorf("expected clean version %v, got version %v (dirty: %v)", before, after, dirty),
+			}
+		}
+
+		if err := m.Steps(1); err != nil {
+			return err
+		}
+	}
+}
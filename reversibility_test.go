@@ -0,0 +1,120 @@
+package migrate
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	"github.com/golang-migrate/migrate/v4/source"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+// failingStub is a stub database driver failing to run the migration
+// with the given body.
+type failingStub struct {
+	*dStub.Stub
+	failOn string
+}
+
+func (s *failingStub) Run(migration io.Reader) error {
+	body, err := ioutil.ReadAll(migration)
+	if err != nil {
+		return err
+	}
+	if string(body) == s.failOn {
+		return errors.New("failed to run " + s.failOn)
+	}
+	return s.Stub.Run(bytes.NewReader(body))
+}
+
+func TestTestReversibility(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+
+	if err := TestReversibility(m); err != nil {
+		t.Fatal(err)
+	}
+
+	if dbDrv.CurrentVersion != 7 || dbDrv.IsDirty {
+		t.Errorf("expected clean version 7, got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+}
+
+func TestTestReversibilityFails(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	m.databaseDrv = &failingStub{Stub: m.databaseDrv.(*dStub.Stub), failOn: "DROP 4"}
+
+	err := TestReversibility(m)
+	var rerr ErrNotReversible
+	if !errors.As(err, &rerr) {
+		t.Fatalf("expected ErrNotReversible, got %v", err)
+	}
+	if rerr.Version != 4 {
+		t.Errorf("expected version 4 not to be reversible, got %v", rerr.Version)
+	}
+}
+
+func irreversibleMigrations() *source.Migrations {
+	migrations := source.NewMigrations()
+	migrations.Append(&source.Migration{Version: 1, Direction: source.Up, Identifier: "CREATE 1"})
+	migrations.Append(&source.Migration{Version: 1, Direction: source.Down, Identifier: "DROP 1"})
+	migrations.Append(&source.Migration{Version: 2, Direction: source.Up, Identifier: "DELETE 2"})
+	migrations.Append(&source.Migration{Version: 2, Direction: source.Down, Identifier: "-- migrate:irreversible\n"})
+	migrations.Append(&source.Migration{Version: 3, Direction: source.Up, Identifier: "CREATE 3"})
+	migrations.Append(&source.Migration{Version: 3, Direction: source.Down, Identifier: "DROP 3"})
+	return migrations
+}
+
+func TestDownIrreversible(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = irreversibleMigrations()
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	err := m.Down()
+	var ierr ErrIrreversible
+	if !errors.As(err, &ierr) {
+		t.Fatalf("expected ErrIrreversible, got %v", err)
+	}
+	if ierr.Version != 2 {
+		t.Errorf("expected version 2 to be irreversible, got %v", ierr.Version)
+	}
+	if dbDrv.CurrentVersion != 2 || dbDrv.IsDirty {
+		t.Errorf("expected clean version 2, got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+
+	if err := m.Steps(-1); !errors.As(err, &ierr) {
+		t.Fatalf("expected ErrIrreversible, got %v", err)
+	}
+	if err := m.Migrate(1); !errors.As(err, &ierr) {
+		t.Fatalf("expected ErrIrreversible, got %v", err)
+	}
+	if dbDrv.CurrentVersion != 2 {
+		t.Errorf("expected version 2, got %v", dbDrv.CurrentVersion)
+	}
+}
+
+func TestDownAllowIrreversible(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = irreversibleMigrations()
+	m.AllowIrreversible = true
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Steps(-2); err != nil {
+		t.Fatal(err)
+	}
+	if dbDrv.CurrentVersion != 1 || dbDrv.IsDirty {
+		t.Errorf("expected clean version 1, got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+}
@@ -0,0 +1,83 @@
+package source
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// CollisionPolicy decides how source drivers handle migrations with the same
+// version and direction but different identifiers, e.g. after merging two
+// branches that both added a migration.
+type CollisionPolicy int
+
+const (
+	// CollisionError rejects colliding migrations with ErrDuplicateMigration.
+	CollisionError CollisionPolicy = iota
+
+	// CollisionSuffix keeps the version of the colliding migration whose
+	// identifier sorts first, and appends a digit to the versions of the
+	// others in the order of their identifiers, e.g. 5_add_users and
+	// 5_add_orders become 5 and 51. Identifiers are used instead of
+	// modification times, which aren't stable across checkouts. Opening
+	// the source fails if another version lies between a colliding
+	// version and its suffixed versions, since the migrations would no
+	// longer run in order.
+	CollisionSuffix
+)
+
+// DefaultCollisionPolicy is the CollisionPolicy of the source drivers based
+// on httpfs, i.e. file, httpfs, pkger and godoc_vfs. Other source drivers
+// always reject colliding migrations. Like DefaultParse, it has to be set
+// before the source driver is opened.
+var DefaultCollisionPolicy = CollisionError
+
+// SuffixCollisions changes the versions of colliding migrations as described
+// for CollisionSuffix. Up and down migrations with the same identifier keep
+// sharing a version. It fails if another version lies between a colliding
+// version and its suffixed versions, or equals one of them.
+func SuffixCollisions(migrations []*Migration) error {
+	identifiers := make(map[uint]map[string]bool)
+	colliding := make(map[uint]bool)
+	directions := make(map[uint]map[Direction]string)
+	for _, m := range migrations {
+		if identifiers[m.Version] == nil {
+			identifiers[m.Version] = make(map[string]bool)
+			directions[m.Version] = make(map[Direction]string)
+		}
+		identifiers[m.Version][m.Identifier] = true
+		if id, ok := directions[m.Version][m.Direction]; ok && id != m.Identifier {
+			colliding[m.Version] = true
+		}
+		directions[m.Version][m.Direction] = m.Identifier
+	}
+
+	suffixes := make(map[uint]map[string]uint)
+	for version := range colliding {
+		ids := make([]string, 0, len(identifiers[version]))
+		for id := range identifiers[version] {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		if uint64(version) > (math.MaxUint64-uint64(len(ids)))/10 {
+			return fmt.Errorf("can't suffix colliding version %v", version)
+		}
+		last := version*10 + uint(len(ids)-1)
+		for other := range identifiers {
+			if other > version && other <= last {
+				return fmt.Errorf("can't suffix colliding version %v: version %v lies between it and %v, renumber the migrations", version, other, last)
+			}
+		}
+		suffixes[version] = make(map[string]uint)
+		for i, id := range ids[1:] {
+			suffixes[version][id] = version*10 + uint(i+1)
+		}
+	}
+
+	for _, m := range migrations {
+		if v, ok := suffixes[m.Version][m.Identifier]; ok {
+			m.Version = v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package source
+
+import (
+	"testing"
+)
+
+func TestSuffixCollisions(t *testing.T) {
+	migrations := []*Migration{
+		{Version: 5, Identifier: "add_users", Direction: Up},
+		{Version: 5, Identifier: "add_orders", Direction: Down},
+		{Version: 5, Identifier: "add_users", Direction: Down},
+		{Version: 5, Identifier: "add_orders", Direction: Up},
+		{Version: 3, Identifier: "add_index", Direction: Up},
+		// an up and a down migration with different identifiers don't collide
+		{Version: 4, Identifier: "rename", Direction: Up},
+		{Version: 4, Identifier: "undo_rename", Direction: Down},
+	}
+	if err := SuffixCollisions(migrations); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []uint{51, 5, 51, 5, 3, 4, 4}
+	for i, m := range migrations {
+		if m.Version != expected[i] {
+			t.Errorf("expected version %v for %v.%v, got %v", expected[i], m.Identifier, m.Direction, m.Version)
+		}
+	}
+}
+
+func TestSuffixCollisionsOrder(t *testing.T) {
+	for name, other := range map[string]uint{"JumpPast": 6, "Collision": 51} {
+		t.Run(name, func(t *testing.T) {
+			migrations := []*Migration{
+				{Version: 5, Identifier: "add_users", Direction: Up},
+				{Version: 5, Identifier: "add_orders", Direction: Up},
+				{Version: other, Identifier: "add_index", Direction: Up},
+			}
+			if err := SuffixCollisions(migrations); err == nil {
+				t.Fatalf("expected suffixing version 5 past version %v to fail", other)
+			}
+		})
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"io"
 	nurl "net/url"
 	"sync"
+
+	iurl "github.com/golang-migrate/migrate/v4/internal/url"
 )
 
 var driversMu sync.RWMutex
@@ -72,8 +74,46 @@ type Driver interface {
 	ReadDown(version uint) (r io.ReadCloser, identifier string, err error)
 }
 
+// Sized is an optional interface a Driver can implement to report the
+// length of migrations before they are read, e.g. for progress reports.
+type Sized interface {
+	// SizeUp returns the length in bytes of the UP migration for a given
+	// version. If there is no up migration available for this version,
+	// it must return os.ErrNotExist.
+	SizeUp(version uint) (int64, error)
+
+	// SizeDown returns the length in bytes of the DOWN migration for a given
+	// version. If there is no down migration available for this version,
+	// it must return os.ErrNotExist.
+	SizeDown(version uint) (int64, error)
+}
+
+// Reloader is an optional interface a Driver can implement to pick up
+// migrations added, changed or removed after Open, e.g. files on disk
+// edited while a long-running service embedding migrate is running.
+type Reloader interface {
+	// Reload rebuilds the index of available migrations from the current
+	// state of the source. If it fails, the previous index must be kept.
+	Reload() error
+}
+
+// ConcurrentReader is an optional interface a Driver can implement to declare
+// that ReadUp and ReadDown, and reading the migrations they return, are safe
+// to call from multiple goroutines, e.g. by Migrate.ValidateConcurrent.
+// Reads of other drivers are serialized.
+type ConcurrentReader interface {
+	// ConcurrentReads reports whether reads are safe for concurrent use.
+	ConcurrentReads() bool
+}
+
 // Open returns a new driver instance.
+// Environment variables in url are expanded, see README.md.
 func Open(url string) (Driver, error) {
+	url, err := iurl.ExpandEnv(url)
+	if err != nil {
+		return nil, err
+	}
+
 	u, err := nurl.Parse(url)
 	if err != nil {
 		return nil, err
@@ -1,15 +1,24 @@
 package source
 
-import "os"
+import (
+	"fmt"
+	"os"
+)
 
 // ErrDuplicateMigration is an error type for reporting duplicate migration
 // files.
 type ErrDuplicateMigration struct {
 	Migration
 	os.FileInfo
+
+	// Existing is the migration already using the version, if known.
+	Existing *Migration
 }
 
 // Error implements error interface.
 func (e ErrDuplicateMigration) Error() string {
+	if e.Existing != nil {
+		return fmt.Sprintf("duplicate migration file: %v (version %v collides with %v)", e.Name(), e.Version, e.Existing.Raw)
+	}
 	return "duplicate migration file: " + e.Name()
 }
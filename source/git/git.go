@@ -0,0 +1,213 @@
+// Package git reads migrations from a revision of a git repository,
+// without checking it out. It runs the git command, which must be
+// installed.
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	nurl "net/url"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+func init() {
+	for _, scheme := range []string{"git+https", "git+http", "git+ssh", "git+file"} {
+		source.Register(scheme, &Git{})
+	}
+}
+
+var (
+	ErrNoRepository = fmt.Errorf("no repository")
+)
+
+type Git struct {
+	url string
+
+	// dir is the bare repository the remote is cloned into.
+	dir string
+	// commit is the revision the migrations are read from,
+	// resolved once on Open.
+	commit     string
+	path       string
+	migrations *source.Migrations
+}
+
+// Open clones the repository of a URL like
+// `git+https://token@host/repo.git#ref?path=migrations` into a temporary
+// bare repository and reads the migrations of ref from path. ref defaults
+// to HEAD, path to the root of the repository. A token, or user and
+// password, in the URL are passed to the remote for authentication.
+func (g *Git) Open(url string) (source.Driver, error) {
+	u, err := nurl.Parse(url)
+	if err != nil {
+		return nil, err
+	}
+
+	ref, query := u.Fragment, u.Query()
+	// the query is part of the fragment in git+https://host/repo#ref?path=x
+	if i := strings.IndexByte(ref, '?'); i >= 0 {
+		if query, err = nurl.ParseQuery(ref[i+1:]); err != nil {
+			return nil, err
+		}
+		ref = ref[:i]
+	}
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	remote := *u
+	remote.Scheme = strings.TrimPrefix(u.Scheme, "git+")
+	remote.Fragment = ""
+	remote.RawQuery = ""
+	if remote.Host == "" && remote.Path == "" {
+		return nil, ErrNoRepository
+	}
+
+	dir, err := ioutil.TempDir("", "migrate-git")
+	if err != nil {
+		return nil, err
+	}
+
+	gn := &Git{
+		url:  url,
+		dir:  dir,
+		path: strings.Trim(query.Get("path"), "/"),
+	}
+	if err := gn.init(remote.String(), ref); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, redact(err, &remote)
+	}
+	return gn, nil
+}
+
+// init clones remote, resolves ref and reads the migrations
+// of the resolved commit.
+func (g *Git) init(remote, ref string) error {
+	if _, err := g.git("clone", "--bare", "--quiet", "--", remote, g.dir); err != nil {
+		return err
+	}
+
+	commit, err := g.git("rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return fmt.Errorf("unknown ref %q: %w", ref, err)
+	}
+	g.commit = strings.TrimSpace(string(commit))
+
+	tree := g.commit
+	if g.path != "" {
+		tree += ":" + g.path
+	}
+	names, err := g.git("ls-tree", "--name-only", "-z", tree)
+	if err != nil {
+		return err
+	}
+
+	g.migrations = source.NewMigrations()
+	for _, name := range strings.Split(string(names), "\x00") {
+		if name == "" {
+			continue
+		}
+		m, err := source.DefaultParse(name)
+		if err != nil {
+			continue // ignore files that we can't parse
+		}
+		if !g.migrations.Append(m) {
+			return fmt.Errorf("unable to parse file %v", name)
+		}
+	}
+	return nil
+}
+
+// git runs the git command with args on the bare repository
+// and returns its output.
+func (g *Git) git(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"--git-dir", g.dir}, args...)...)
+	// never prompt for credentials
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// redact removes the password or token of remote from err.
+func redact(err error, remote *nurl.URL) error {
+	if remote.User == nil {
+		return err
+	}
+	msg := err.Error()
+	if password, ok := remote.User.Password(); ok && password != "" {
+		msg = strings.Replace(msg, password, "xxxxx", -1)
+	} else if username := remote.User.Username(); username != "" {
+		msg = strings.Replace(msg, username, "xxxxx", -1)
+	}
+	return fmt.Errorf("%s", msg)
+}
+
+// Close removes the cloned repository.
+func (g *Git) Close() error {
+	return os.RemoveAll(g.dir)
+}
+
+func (g *Git) First() (version uint, err error) {
+	if v, ok := g.migrations.First(); ok {
+		return v, nil
+	}
+	return 0, &os.PathError{Op: "first", Path: g.path, Err: os.ErrNotExist}
+}
+
+func (g *Git) Prev(version uint) (prevVersion uint, err error) {
+	if v, ok := g.migrations.Prev(version); ok {
+		return v, nil
+	}
+	return 0, &os.PathError{Op: "prev for version " + strconv.FormatUint(uint64(version), 10), Path: g.path, Err: os.ErrNotExist}
+}
+
+func (g *Git) Next(version uint) (nextVersion uint, err error) {
+	if v, ok := g.migrations.Next(version); ok {
+		return v, nil
+	}
+	return 0, &os.PathError{Op: "next for version " + strconv.FormatUint(uint64(version), 10), Path: g.path, Err: os.ErrNotExist}
+}
+
+func (g *Git) ReadUp(version uint) (r io.ReadCloser, identifier string, err error) {
+	if m, ok := g.migrations.Up(version); ok {
+		r, err := g.read(m.Raw)
+		if err != nil {
+			return nil, "", err
+		}
+		return r, m.Identifier, nil
+	}
+	return nil, "", &os.PathError{Op: "read up for version " + strconv.FormatUint(uint64(version), 10), Path: g.path, Err: os.ErrNotExist}
+}
+
+func (g *Git) ReadDown(version uint) (r io.ReadCloser, identifier string, err error) {
+	if m, ok := g.migrations.Down(version); ok {
+		r, err := g.read(m.Raw)
+		if err != nil {
+			return nil, "", err
+		}
+		return r, m.Identifier, nil
+	}
+	return nil, "", &os.PathError{Op: "read down for version " + strconv.FormatUint(uint64(version), 10), Path: g.path, Err: os.ErrNotExist}
+}
+
+// read returns the content of the file name in path at the resolved commit.
+func (g *Git) read(name string) (io.ReadCloser, error) {
+	content, err := g.git("cat-file", "blob", g.commit+":"+path.Join(g.path, name))
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(content)), nil
+}
@@ -40,7 +40,8 @@ func (p *PartialDriver) Init(fs http.FileSystem, path string) error {
 		return err
 	}
 
-	ms := source.NewMigrations()
+	parsed := make([]*source.Migration, 0, len(files))
+	infos := make(map[string]os.FileInfo, len(files))
 	for _, file := range files {
 		if file.IsDir() {
 			continue
@@ -50,11 +51,27 @@ func (p *PartialDriver) Init(fs http.FileSystem, path string) error {
 		if err != nil {
 			continue // ignore files that we can't parse
 		}
+		parsed = append(parsed, m)
+		infos[m.Raw] = file
+	}
+
+	if source.DefaultCollisionPolicy == source.CollisionSuffix {
+		if err := source.SuffixCollisions(parsed); err != nil {
+			return err
+		}
+	}
 
+	ms := source.NewMigrations()
+	for _, m := range parsed {
 		if !ms.Append(m) {
+			existing, _ := ms.Up(m.Version)
+			if m.Direction == source.Down {
+				existing, _ = ms.Down(m.Version)
+			}
 			return source.ErrDuplicateMigration{
 				Migration: *m,
-				FileInfo:  file,
+				FileInfo:  infos[m.Raw],
+				Existing:  existing,
 			}
 		}
 	}
@@ -138,6 +155,96 @@ func (p *PartialDriver) ReadDown(version uint) (r io.ReadCloser, identifier stri
 	}
 }
 
+// Reload is part of source.Reloader interface implementation. It reads the
+// files of the path given to Init again.
+func (p *PartialDriver) Reload() error {
+	if p.fs == nil {
+		return errors.New("reload: driver not initialized")
+	}
+	return p.Init(p.fs, p.path)
+}
+
+// ConcurrentReads is part of source.ConcurrentReader interface
+// implementation. The files of a http.FileSystem can be opened and read
+// concurrently.
+func (p *PartialDriver) ConcurrentReads() bool {
+	return true
+}
+
+// SizeUp is part of source.Sized interface implementation.
+func (p *PartialDriver) SizeUp(version uint) (int64, error) {
+	if m, ok := p.migrations.Up(version); ok {
+		return p.size(path.Join(p.path, m.Raw))
+	}
+	return 0, &os.PathError{
+		Op:   "size up for version " + strconv.FormatUint(uint64(version), 10),
+		Path: p.path,
+		Err:  os.ErrNotExist,
+	}
+}
+
+// SizeDown is part of source.Sized interface implementation.
+func (p *PartialDriver) SizeDown(version uint) (int64, error) {
+	if m, ok := p.migrations.Down(version); ok {
+		return p.size(path.Join(p.path, m.Raw))
+	}
+	return 0, &os.PathError{
+		Op:   "size down for version " + strconv.FormatUint(uint64(version), 10),
+		Path: p.path,
+		Err:  os.ErrNotExist,
+	}
+}
+
+// ReadMetadata is part of source.MetadataReader interface implementation.
+// It reads the `{version}_{title}.yaml` sidecar file of the migration,
+// returning an empty map if there is none.
+func (p *PartialDriver) ReadMetadata(version uint) (metadata map[string]string, err error) {
+	var name string
+	if m, ok := p.migrations.Up(version); ok {
+		name = source.MetadataName(m.Raw, source.Up)
+	} else if m, ok := p.migrations.Down(version); ok {
+		name = source.MetadataName(m.Raw, source.Down)
+	} else {
+		return nil, &os.PathError{
+			Op:   "read metadata for version " + strconv.FormatUint(uint64(version), 10),
+			Path: p.path,
+			Err:  os.ErrNotExist,
+		}
+	}
+
+	f, err := p.open(path.Join(p.path, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errClose := f.Close(); errClose != nil && err == nil {
+			err = errClose
+		}
+	}()
+
+	return source.ParseMetadata(f)
+}
+
+func (p *PartialDriver) size(path string) (size int64, err error) {
+	f, err := p.open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if errClose := f.Close(); errClose != nil && err == nil {
+			err = errClose
+		}
+	}()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
 func (p *PartialDriver) open(path string) (http.File, error) {
 	f, err := p.fs.Open(path)
 	if err == nil {
@@ -3,6 +3,7 @@ package httpfs_test
 import (
 	"errors"
 	"net/http"
+	"os"
 	"strings"
 	"testing"
 
@@ -105,3 +106,96 @@ func TestFirstWithNoMigrations(t *testing.T) {
 		t.Errorf("Expected error on First(), got: %v", err)
 	}
 }
+
+func TestSize(t *testing.T) {
+	var d driver
+	if err := d.Init(http.Dir("testdata/sql"), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if size, err := d.SizeUp(1); err != nil {
+		t.Fatal(err)
+	} else if size != 5 {
+		t.Errorf("expected size 5 of up migration 1, got %v", size)
+	}
+	if size, err := d.SizeDown(1); err != nil {
+		t.Fatal(err)
+	} else if size != 7 {
+		t.Errorf("expected size 7 of down migration 1, got %v", size)
+	}
+	if _, err := d.SizeUp(5); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist for missing up migration 5, got %v", err)
+	}
+}
+
+func TestReadMetadata(t *testing.T) {
+	var d driver
+	if err := d.Init(http.Dir("testdata/sql"), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	metadata, err := d.ReadMetadata(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata["author"] != "gopher" || metadata["ticket"] != "MIG-1" {
+		t.Errorf("expected metadata of version 1, got %v", metadata)
+	}
+
+	// 3 has no sidecar
+	if metadata, err := d.ReadMetadata(3); err != nil {
+		t.Fatal(err)
+	} else if len(metadata) != 0 {
+		t.Errorf("expected no metadata for version 3, got %v", metadata)
+	}
+
+	if _, err := d.ReadMetadata(2); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist for missing version 2, got %v", err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	var d driver
+	if err := d.Reload(); err == nil {
+		t.Error("expected error for an uninitialized driver")
+	}
+
+	if err := d.Init(http.Dir("testdata/sql"), ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := d.First(); err != nil || v != 1 {
+		t.Errorf("expected first version 1 after reload, got %v (%v)", v, err)
+	}
+}
+
+func TestCollisionPolicy(t *testing.T) {
+	defer func(policy source.CollisionPolicy) { source.DefaultCollisionPolicy = policy }(source.DefaultCollisionPolicy)
+
+	var d driver
+	err := d.Init(http.Dir("testdata/duplicates"), "")
+	var dup source.ErrDuplicateMigration
+	if !errors.As(err, &dup) {
+		t.Fatalf("expected ErrDuplicateMigration, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "1_foobar.up.sql") || !strings.Contains(err.Error(), "1_foobaz.up.sql") {
+		t.Errorf("expected both colliding files in error, got %v", err)
+	}
+
+	source.DefaultCollisionPolicy = source.CollisionSuffix
+	if err := d.Init(http.Dir("testdata/duplicates"), ""); err != nil {
+		t.Fatal(err)
+	}
+	for version, identifier := range map[uint]string{1: "foobar", 11: "foobaz"} {
+		r, id, err := d.ReadUp(version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = r.Close()
+		if id != identifier {
+			t.Errorf("expected %v for version %v, got %v", identifier, version, id)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package source
+
+import (
+	"errors"
+	"os"
+)
+
+// Iterator yields migration versions in ascending order.
+type Iterator interface {
+	// Next returns the next version available to the driver.
+	// ok is false once there are no more versions or an error occurred.
+	Next() (version uint, ok bool)
+
+	// Err returns the error that stopped the iteration, if any.
+	// Reaching the last version is not an error.
+	Err() error
+}
+
+// Iterable is an optional interface a Driver can implement to stream
+// versions lazily, i.e. page by page from a remote source, instead of
+// looking up each version with a separate call to Next.
+type Iterable interface {
+	// Iterate returns an Iterator yielding the versions following version.
+	// Migrate only calls Iterate with versions available to the driver.
+	Iterate(version uint) (Iterator, error)
+}
+
+// Iterate returns an Iterator yielding the versions following version.
+// It uses the Iterable implementation of d if available and falls
+// back to calling d.Next otherwise.
+func Iterate(d Driver, version uint) (Iterator, error) {
+	if it, ok := d.(Iterable); ok {
+		return it.Iterate(version)
+	}
+	return &nextIterator{driver: d, version: version}, nil
+}
+
+// nextIterator is an Iterator for drivers not implementing Iterable.
+type nextIterator struct {
+	driver  Driver
+	version uint
+	done    bool
+	err     error
+}
+
+func (i *nextIterator) Next() (version uint, ok bool) {
+	if i.done {
+		return 0, false
+	}
+
+	next, err := i.driver.Next(i.version)
+	if err != nil {
+		i.done = true
+		if !errors.Is(err, os.ErrNotExist) {
+			i.err = err
+		}
+		return 0, false
+	}
+
+	i.version = next
+	return next, true
+}
+
+func (i *nextIterator) Err() error {
+	return i.err
+}
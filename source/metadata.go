@@ -0,0 +1,66 @@
+package source
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// MetadataReader is an optional interface a Driver can implement to read
+// structured metadata of a migration, e.g. its author or ticket, from a
+// `{version}_{title}.yaml` sidecar file next to the migration files.
+type MetadataReader interface {
+	// ReadMetadata returns the metadata of the migration with the given
+	// version. If there is no metadata for this version, it must return
+	// an empty map.
+	ReadMetadata(version uint) (map[string]string, error)
+}
+
+// ParseMetadata parses the content of a metadata sidecar file. Only a flat
+// subset of YAML is supported: `key: value` pairs, one per line, with
+// optionally quoted values. Blank lines and comments starting with # are
+// ignored.
+func ParseMetadata(r io.Reader) (map[string]string, error) {
+	metadata := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			return nil, fmt.Errorf("metadata line %d: nested values are not supported", n)
+		}
+
+		i := strings.Index(trimmed, ":")
+		if i <= 0 {
+			return nil, fmt.Errorf("metadata line %d: expected key: value, got %q", n, line)
+		}
+		key, value := strings.TrimSpace(trimmed[:i]), strings.TrimSpace(trimmed[i+1:])
+		metadata[key] = unquote(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// unquote removes the single or double quotes around value, if any.
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// MetadataName returns the name of the metadata sidecar file of the
+// migration file named raw, i.e. raw without the direction and extension:
+// 1_create_users.yaml for 1_create_users.up.sql.
+func MetadataName(raw string, direction Direction) string {
+	if i := strings.LastIndex(raw, "."+string(direction)+"."); i >= 0 {
+		raw = raw[:i]
+	}
+	return raw + ".yaml"
+}
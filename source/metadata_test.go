@@ -0,0 +1,56 @@
+package source
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMetadata(t *testing.T) {
+	content := `# added for the billing rollout
+author: gopher
+ticket: "BILL-42"
+description: 'Adds invoices: one per order'
+
+empty:
+`
+	metadata, err := ParseMetadata(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"author":      "gopher",
+		"ticket":      "BILL-42",
+		"description": "Adds invoices: one per order",
+		"empty":       "",
+	}
+	if !reflect.DeepEqual(metadata, expected) {
+		t.Errorf("expected %v, got %v", expected, metadata)
+	}
+}
+
+func TestParseMetadataInvalid(t *testing.T) {
+	for _, content := range []string{"author", "reviewers:\n  - gopher", ": value"} {
+		if _, err := ParseMetadata(strings.NewReader(content)); err == nil {
+			t.Errorf("expected error for %q", content)
+		}
+	}
+}
+
+func TestMetadataName(t *testing.T) {
+	testCases := []struct {
+		raw       string
+		direction Direction
+		expected  string
+	}{
+		{raw: "1_create_users.up.sql", direction: Up, expected: "1_create_users.yaml"},
+		{raw: "1_create_users.down.sql", direction: Down, expected: "1_create_users.yaml"},
+		{raw: "2023-01-01-add.up.users.up.sql", direction: Up, expected: "2023-01-01-add.up.users.yaml"},
+	}
+	for _, tc := range testCases {
+		if name := MetadataName(tc.raw, tc.direction); name != tc.expected {
+			t.Errorf("expected %v for %v, got %v", tc.expected, tc.raw, name)
+		}
+	}
+}
@@ -4,15 +4,22 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
 var (
 	ErrParse = fmt.Errorf("no match")
 )
 
+// VersionParser parses the name of a migration file into a Migration,
+// or returns ErrParse if it doesn't follow the naming scheme.
+// Source drivers parse file names with DefaultParse, which can be replaced
+// for other naming schemes, e.g. with a parser returned by NewRegexParser.
+type VersionParser func(raw string) (*Migration, error)
+
 var (
-	DefaultParse = Parse
-	DefaultRegex = Regex
+	DefaultParse VersionParser = Parse
+	DefaultRegex               = Regex
 )
 
 // Regex matches the following pattern:
@@ -37,3 +44,54 @@ func Parse(raw string) (*Migration, error) {
 	}
 	return nil, ErrParse
 }
+
+// NewRegexParser returns a VersionParser for file names matching re, which
+// must have the named groups "version", "identifier" and "direction".
+// Non-digits are removed from the version, so dates like 2023-01-01 can
+// be used as versions.
+func NewRegexParser(re *regexp.Regexp) (VersionParser, error) {
+	groups := make(map[string]int)
+	for i, name := range re.SubexpNames() {
+		if name != "" {
+			groups[name] = i
+		}
+	}
+	for _, name := range []string{"version", "identifier", "direction"} {
+		if _, ok := groups[name]; !ok {
+			return nil, fmt.Errorf("regex %v has no group named %v", re, name)
+		}
+	}
+
+	return func(raw string) (*Migration, error) {
+		m := re.FindStringSubmatch(raw)
+		if m == nil {
+			return nil, ErrParse
+		}
+
+		direction := Direction(m[groups["direction"]])
+		if direction != Up && direction != Down {
+			return nil, ErrParse
+		}
+
+		digits := strings.Map(func(r rune) rune {
+			if r < '0' || r > '9' {
+				return -1
+			}
+			return r
+		}, m[groups["version"]])
+		if digits == "" {
+			return nil, ErrParse
+		}
+		versionUint64, err := strconv.ParseUint(digits, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Migration{
+			Version:    uint(versionUint64),
+			Identifier: m[groups["identifier"]],
+			Direction:  direction,
+			Raw:        raw,
+		}, nil
+	}, nil
+}
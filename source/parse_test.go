@@ -1,6 +1,7 @@
 package source
 
 import (
+	"regexp"
 	"testing"
 )
 
@@ -104,3 +105,57 @@ func TestParse(t *testing.T) {
 		}
 	}
 }
+
+func TestNewRegexParser(t *testing.T) {
+	parse, err := NewRegexParser(regexp.MustCompile(`^(?P<version>\d{4}-\d{2}-\d{2}(?:-\d+)?)-(?P<identifier>.*)\.(?P<direction>up|down)\.sql$`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		name            string
+		expectErr       error
+		expectMigration *Migration
+	}{
+		{
+			name: "2023-01-01-add_users.up.sql",
+			expectMigration: &Migration{
+				Version:    20230101,
+				Identifier: "add_users",
+				Direction:  Up,
+				Raw:        "2023-01-01-add_users.up.sql",
+			},
+		},
+		{
+			name: "2023-01-01-2-add_users_email.down.sql",
+			expectMigration: &Migration{
+				Version:    202301012,
+				Identifier: "add_users_email",
+				Direction:  Down,
+				Raw:        "2023-01-01-2-add_users_email.down.sql",
+			},
+		},
+		{
+			name:      "1_foobar.up.sql",
+			expectErr: ErrParse,
+		},
+	}
+
+	for _, v := range tt {
+		t.Run(v.name, func(t *testing.T) {
+			f, err := parse(v.name)
+			if err != v.expectErr {
+				t.Errorf("expected %v, got %v", v.expectErr, err)
+			}
+			if v.expectMigration != nil && *f != *v.expectMigration {
+				t.Errorf("expected %+v, got %+v", *v.expectMigration, *f)
+			}
+		})
+	}
+}
+
+func TestNewRegexParserMissingGroup(t *testing.T) {
+	if _, err := NewRegexParser(regexp.MustCompile(`^(?P<version>\d+)_(.*)\.(up|down)\.sql$`)); err == nil {
+		t.Error("expected error for a regex without identifier and direction groups")
+	}
+}
@@ -0,0 +1,17 @@
+package source
+
+import (
+	"github.com/golang-migrate/migrate/v4/internal/plugin"
+)
+
+// ErrPluginVersionMismatch is returned by LoadPlugin if the plugin was built
+// with another version of Go or migrate than the program loading it.
+var ErrPluginVersionMismatch = plugin.ErrVersionMismatch
+
+// LoadPlugin loads a source driver built as a Go plugin, e.g. with
+// `go build -buildmode=plugin`. The plugin must export a `func Register()`
+// registering the driver with Register, making its scheme available to Open.
+// See database.LoadPlugin for the restrictions of plugins.
+func LoadPlugin(path string) error {
+	return plugin.Load(path)
+}
@@ -24,6 +24,8 @@ type Stub struct {
 	Instance   interface{}
 	Migrations *source.Migrations
 	Config     *Config
+	// Metadata is returned by ReadMetadata for each version.
+	Metadata map[uint]map[string]string
 }
 
 func (s *Stub) Open(url string) (source.Driver, error) {
@@ -83,3 +85,32 @@ func (s *Stub) ReadDown(version uint) (r io.ReadCloser, identifier string, err e
 	}
 	return nil, "", &os.PathError{Op: fmt.Sprintf("read down version %v", version), Path: s.Url, Err: os.ErrNotExist}
 }
+
+// SizeUp returns the length of the body returned by ReadUp.
+func (s *Stub) SizeUp(version uint) (int64, error) {
+	if m, ok := s.Migrations.Up(version); ok {
+		return int64(len(m.Identifier)), nil
+	}
+	return 0, &os.PathError{Op: fmt.Sprintf("size up version %v", version), Path: s.Url, Err: os.ErrNotExist}
+}
+
+// SizeDown returns the length of the body returned by ReadDown.
+func (s *Stub) SizeDown(version uint) (int64, error) {
+	if m, ok := s.Migrations.Down(version); ok {
+		return int64(len(m.Identifier)), nil
+	}
+	return 0, &os.PathError{Op: fmt.Sprintf("size down version %v", version), Path: s.Url, Err: os.ErrNotExist}
+}
+
+// ReadMetadata returns the Metadata of version, or an empty map.
+func (s *Stub) ReadMetadata(version uint) (map[string]string, error) {
+	if _, ok := s.Migrations.Up(version); !ok {
+		if _, ok := s.Migrations.Down(version); !ok {
+			return nil, &os.PathError{Op: fmt.Sprintf("read metadata version %v", version), Path: s.Url, Err: os.ErrNotExist}
+		}
+	}
+	if metadata, ok := s.Metadata[version]; ok {
+		return metadata, nil
+	}
+	return map[string]string{}, nil
+}
@@ -0,0 +1,47 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// SourceVersionsPaged returns at most limit versions available in the source,
+// in ascending order, skipping the first offset versions, i.e. to page
+// through the migrations in a UI. total is the number of versions
+// available in the source. An offset past the last version returns no
+// versions and the correct total.
+func (m *Migrate) SourceVersionsPaged(offset, limit int) (versions []uint, total int, err error) {
+	if offset < 0 || limit < 0 {
+		return nil, 0, fmt.Errorf("invalid offset %v or limit %v", offset, limit)
+	}
+
+	// limit may exceed the number of versions by far
+	versions = []uint{}
+
+	version, err := m.sourceDrv.First()
+	if errors.Is(err, os.ErrNotExist) {
+		return versions, 0, nil
+	} else if err != nil {
+		return nil, 0, err
+	}
+
+	it, err := source.Iterate(m.sourceDrv, version)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for ok := true; ok; version, ok = it.Next() {
+		if total >= offset && total-offset < limit {
+			versions = append(versions, version)
+		}
+		total++
+	}
+	if err := it.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return versions, total, nil
+}
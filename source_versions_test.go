@@ -0,0 +1,62 @@
+package migrate
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/source"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+const maxInt = int(^uint(0) >> 1)
+
+func TestSourceVersionsPaged(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+
+	tt := []struct {
+		name     string
+		offset   int
+		limit    int
+		versions []uint
+	}{
+		{name: "first page", offset: 0, limit: 2, versions: []uint{1, 3}},
+		{name: "middle page", offset: 2, limit: 2, versions: []uint{4, 5}},
+		{name: "last page", offset: 4, limit: 2, versions: []uint{7}},
+		{name: "past the end", offset: 10, limit: 2, versions: []uint{}},
+		{name: "empty page", offset: 0, limit: 0, versions: []uint{}},
+		{name: "unbounded limit", offset: 2, limit: maxInt, versions: []uint{4, 5, 7}},
+	}
+
+	for _, v := range tt {
+		t.Run(v.name, func(t *testing.T) {
+			versions, total, err := m.SourceVersionsPaged(v.offset, v.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != 5 {
+				t.Errorf("expected total 5, got %v", total)
+			}
+			if fmt.Sprint(versions) != fmt.Sprint(v.versions) {
+				t.Errorf("expected versions %v, got %v", v.versions, versions)
+			}
+		})
+	}
+
+	if _, _, err := m.SourceVersionsPaged(-1, 2); err == nil {
+		t.Error("expected error for a negative offset")
+	}
+}
+
+func TestSourceVersionsPagedEmptySource(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = source.NewMigrations()
+
+	versions, total, err := m.SourceVersionsPaged(0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 0 || total != 0 {
+		t.Errorf("expected no versions, got %v of %v", versions, total)
+	}
+}
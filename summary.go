@@ -0,0 +1,62 @@
+package migrate
+
+import (
+	"time"
+)
+
+// Summary aggregates the results of a run of Migrate, Steps, Up or Down.
+type Summary struct {
+	// Applied is the number of migrations run successfully,
+	// including those without a body.
+	Applied int
+
+	// Skipped is the number of applied migrations without a body in the
+	// source, whose version was only recorded.
+	Skipped int
+
+	// Failed is the number of migrations that failed, at most 1.
+	Failed int
+
+	// Duration is the total time spent reading and running migrations.
+	Duration time.Duration
+
+	// HighestVersion is the highest version among the migrations
+	// run successfully. It is only meaningful if Applied > 0.
+	HighestVersion uint
+
+	// Err is the error the run ended with, nil on success.
+	// ErrNoChange if there was nothing to migrate.
+	Err error
+}
+
+// add adds the result of running migr to s.
+func (s *Summary) add(migr *Migration, d time.Duration, err error) {
+	s.Duration += d
+	if err != nil {
+		s.Failed++
+		return
+	}
+	s.Applied++
+	if migr.Body == nil {
+		s.Skipped++
+	}
+	if migr.Version > s.HighestVersion {
+		s.HighestVersion = migr.Version
+	}
+}
+
+// SetSummaryHandler sets a function called once at the end of every run of
+// Migrate, Steps, Up and Down with the summary of the migrations run, even
+// if the run ended with an error.
+func (m *Migrate) SetSummaryHandler(fn func(Summary)) {
+	m.summaryHandler = fn
+}
+
+// reportSummary passes summary to m.summaryHandler, if set.
+func (m *Migrate) reportSummary(summary Summary, err error) {
+	if m.summaryHandler == nil {
+		return
+	}
+	summary.Err = err
+	m.summaryHandler(summary)
+}
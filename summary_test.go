@@ -0,0 +1,76 @@
+package migrate
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+func TestSummaryHandler(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+
+	var summaries []Summary
+	m.SetSummaryHandler(func(s Summary) {
+		summaries = append(summaries, s)
+	})
+
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(); !errors.Is(err, ErrNoChange) {
+		t.Fatalf("expected ErrNoChange, got %v", err)
+	}
+
+	if len(summaries) != 2 {
+		t.Fatalf("expected 2 summaries, got %+v", summaries)
+	}
+
+	// version 5 has no up migration
+	s := summaries[0]
+	if s.Applied != 5 || s.Skipped != 1 || s.Failed != 0 || s.HighestVersion != 7 || s.Err != nil {
+		t.Errorf("unexpected summary %+v", s)
+	}
+
+	s = summaries[1]
+	if s.Applied != 0 || s.Duration != 0 || !errors.Is(s.Err, ErrNoChange) {
+		t.Errorf("expected empty summary with ErrNoChange, got %+v", s)
+	}
+}
+
+func TestSummaryHandlerPartialFailure(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	m.databaseDrv = &failingStub{Stub: m.databaseDrv.(*dStub.Stub), failOn: "CREATE 4"}
+
+	var total time.Duration
+	m.SetResultFunc(func(r MigrationResult) {
+		total += r.Duration
+	})
+	var summaries []Summary
+	m.SetSummaryHandler(func(s Summary) {
+		summaries = append(summaries, s)
+	})
+
+	err := m.Up()
+	if err == nil {
+		t.Fatal("expected migration to fail")
+	}
+
+	if len(summaries) != 1 {
+		t.Fatalf("expected a single summary, got %+v", summaries)
+	}
+	s := summaries[0]
+	if s.Applied != 2 || s.Skipped != 0 || s.Failed != 1 || s.HighestVersion != 3 {
+		t.Errorf("expected versions 1 and 3 applied and 4 failed, got %+v", s)
+	}
+	if s.Duration != total || s.Duration <= 0 {
+		t.Errorf("expected duration %v, got %v", total, s.Duration)
+	}
+	if s.Err != err {
+		t.Errorf("expected error %v, got %v", err, s.Err)
+	}
+}
@@ -0,0 +1,144 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// Symbolic targets accepted by MigrateToTarget.
+const (
+	// TargetLatest is the last version available in the source.
+	TargetLatest = "latest"
+	// TargetPrevious is the version before the currently active version.
+	TargetPrevious = "previous"
+	// TargetBaseline is the baseline version recorded by Baseline.
+	TargetBaseline = "baseline"
+)
+
+var (
+	// ErrUnknownTarget is returned by MigrateToTarget for targets which are
+	// neither a version nor a symbolic target.
+	ErrUnknownTarget = errors.New("unknown target")
+
+	// ErrNoPreviousVersion is returned by MigrateToTarget for the previous
+	// target if no version or the first version of the source is active.
+	// Use Down to roll back the first version.
+	ErrNoPreviousVersion = errors.New("no version before the currently active version")
+
+	// ErrNoBaseline is returned by MigrateToTarget for the baseline target
+	// if no baseline version has been recorded.
+	ErrNoBaseline = errors.New("no baseline version recorded")
+)
+
+// MigrateToTarget migrates up or down to the version target resolves to,
+// like Migrate. target is a version, e.g. "20230101", or one of the
+// symbolic targets TargetLatest, TargetPrevious and TargetBaseline.
+// The baseline target requires a database driver implementing
+// database.Baseliner. The target is resolved while holding the lock, so
+// it's relative to the version the migrations start from.
+// See ResolveTarget.
+func (m *Migrate) MigrateToTarget(target string) error {
+	if err := m.lock(); err != nil {
+		return err
+	}
+
+	curVersion, dirty, err := m.versions().GetVersion()
+	if err != nil {
+		return m.unlockErr(err)
+	}
+
+	version, err := m.resolveTarget(target, func() (int, bool, error) {
+		return curVersion, dirty, nil
+	})
+	if err != nil {
+		return m.unlockErr(err)
+	}
+
+	if err := m.preflightMigrate(version); err != nil {
+		return m.unlockErr(err)
+	}
+
+	if dirty {
+		return m.unlockErr(ErrDirty{curVersion})
+	}
+
+	ret := make(chan interface{}, m.PrefetchMigrations)
+	go m.read(curVersion, int(version), ret)
+
+	return m.unlockErr(m.runMigrations(ret))
+}
+
+// ResolveTarget returns the version target resolves to, see MigrateToTarget.
+// It returns ErrUnknownTarget for unknown targets, and ErrDirty for the
+// previous target if the currently active version is dirty.
+// The currently active version is read without taking the lock, so the
+// version may be outdated once another process has migrated the database.
+func (m *Migrate) ResolveTarget(target string) (uint, error) {
+	return m.resolveTarget(target, m.readVersion)
+}
+
+// resolveTarget resolves target like ResolveTarget, reading the currently
+// active version with getVersion if needed.
+func (m *Migrate) resolveTarget(target string, getVersion func() (int, bool, error)) (uint, error) {
+	switch target {
+	case TargetLatest:
+		return m.latestVersion()
+
+	case TargetPrevious:
+		curVersion, dirty, err := getVersion()
+		if err != nil {
+			return 0, err
+		}
+		if dirty {
+			return 0, ErrDirty{curVersion}
+		}
+		if curVersion == database.NilVersion {
+			return 0, ErrNoPreviousVersion
+		}
+		prev, err := m.sourceDrv.Prev(suint(curVersion))
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, ErrNoPreviousVersion
+		} else if err != nil {
+			return 0, err
+		}
+		return prev, nil
+
+	case TargetBaseline:
+		b, ok := m.databaseDrv.(database.Baseliner)
+		if !ok {
+			return 0, fmt.Errorf("baseline target: %w", ErrNotSupported)
+		}
+		version, err := b.Baseline()
+		if err != nil {
+			return 0, err
+		}
+		if version == database.NilVersion {
+			return 0, ErrNoBaseline
+		}
+		return suint(version), nil
+	}
+
+	version, err := strconv.ParseUint(target, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownTarget, target)
+	}
+	return uint(version), nil
+}
+
+// latestVersion returns the last version available in the source.
+func (m *Migrate) latestVersion() (version uint, err error) {
+	found := false
+	if err := m.eachSourceVersion(func(v uint) {
+		version, found = v, true
+	}); err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, fmt.Errorf("latest target: %w", os.ErrNotExist)
+	}
+	return version, nil
+}
@@ -0,0 +1,121 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+func TestMigrateToTarget(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+
+	if err := m.MigrateToTarget(TargetPrevious); !errors.Is(err, ErrNoPreviousVersion) {
+		t.Errorf("expected ErrNoPreviousVersion without version, got %v", err)
+	}
+	if err := m.MigrateToTarget(TargetBaseline); !errors.Is(err, ErrNoBaseline) {
+		t.Errorf("expected ErrNoBaseline, got %v", err)
+	}
+
+	if err := m.Baseline(3); err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		target  string
+		version int
+	}{
+		{target: TargetLatest, version: 7},
+		// 5 only has a down migration, but is a version of the source
+		{target: TargetPrevious, version: 5},
+		{target: TargetPrevious, version: 4},
+		{target: "7", version: 7},
+		{target: TargetBaseline, version: 3},
+	}
+	for _, tc := range testcases {
+		if err := m.MigrateToTarget(tc.target); err != nil {
+			t.Fatalf("%v: %v", tc.target, err)
+		}
+		if dbDrv.CurrentVersion != tc.version || dbDrv.IsDirty {
+			t.Errorf("%v: expected version %v (dirty: false), got %v (dirty: %v)", tc.target, tc.version, dbDrv.CurrentVersion, dbDrv.IsDirty)
+		}
+	}
+
+	if err := m.MigrateToTarget(TargetBaseline); !errors.Is(err, ErrNoChange) {
+		t.Errorf("expected ErrNoChange at the baseline version, got %v", err)
+	}
+
+	if err := m.MigrateToTarget("1"); err != nil {
+		t.Fatal(err)
+	}
+	// 1 is the first version
+	if err := m.MigrateToTarget(TargetPrevious); !errors.Is(err, ErrNoPreviousVersion) {
+		t.Errorf("expected ErrNoPreviousVersion at the first version, got %v", err)
+	}
+}
+
+func TestMigrateToTargetUnknown(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+
+	for _, target := range []string{"", "head", "-1", "v7"} {
+		if err := m.MigrateToTarget(target); !errors.Is(err, ErrUnknownTarget) {
+			t.Errorf("%q: expected ErrUnknownTarget, got %v", target, err)
+		}
+	}
+}
+
+func TestResolveTargetLatestEmptySource(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+
+	if _, err := m.ResolveTarget(TargetLatest); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist, got %v", err)
+	}
+}
+
+func TestResolveTargetBaselineNotSupported(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.databaseDrv = nonTxStub{m.databaseDrv}
+
+	if _, err := m.ResolveTarget(TargetBaseline); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported, got %v", err)
+	}
+}
+
+// migratedStub is a stub database driver simulating another process
+// migrating to version while the lock is being acquired.
+type migratedStub struct {
+	*dStub.Stub
+	version int
+}
+
+func (s *migratedStub) LockContext(ctx context.Context) error {
+	if err := s.Stub.LockContext(ctx); err != nil {
+		return err
+	}
+	return s.Stub.SetVersion(s.version, false)
+}
+
+func TestMigrateToTargetUnderLock(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+
+	if err := m.Migrate(7); err != nil {
+		t.Fatal(err)
+	}
+
+	// the previous version is the one before 4, not before 7
+	m.databaseDrv = &migratedStub{Stub: dbDrv, version: 4}
+	if err := m.MigrateToTarget(TargetPrevious); err != nil {
+		t.Fatal(err)
+	}
+	if dbDrv.CurrentVersion != 3 || dbDrv.IsDirty {
+		t.Errorf("expected version 3 (dirty: false), got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+}
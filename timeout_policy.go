@@ -0,0 +1,74 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+// TimeoutPolicy decides what happens when a migration exceeds the timeout
+// declared in its `-- migrate:timeout <duration>` header.
+type TimeoutPolicy int
+
+const (
+	// TimeoutFail fails the migration, leaving its version dirty.
+	// It's the default.
+	TimeoutFail TimeoutPolicy = iota
+
+	// TimeoutWarn logs a warning and marks the migration as applied, then
+	// continues with the next one. The database driver has aborted the
+	// migration, so parts of it may not have been applied. This is unsafe
+	// and only meant for development databases and idempotent migrations
+	// which can simply be run again. Timeouts within the transaction of
+	// UpAtomic, DryRun and UpPhase still fail it. It doesn't work with the
+	// MySQL driver: aborting a migration closes the connection holding the
+	// lock, which the following migrations run on.
+	TimeoutWarn
+)
+
+// timeoutError is returned by run for a migration aborted because it
+// exceeded the timeout declared in its headers. It's a
+// context.DeadlineExceeded error, whatever error the database driver
+// returned for the aborted migration.
+type timeoutError struct {
+	err     error
+	timeout time.Duration
+}
+
+func (e timeoutError) Error() string {
+	return e.err.Error()
+}
+
+func (e timeoutError) Unwrap() error {
+	return e.err
+}
+
+func (e timeoutError) Is(target error) bool {
+	return target == context.DeadlineExceeded
+}
+
+// ignoreTimeout reports whether err of migr is a timeout which is only
+// logged as a warning because of TimeoutWarn. Timeouts are never ignored
+// within a transaction, i.e. by UpAtomic, DryRun and UpPhase, as the
+// aborted statement may have aborted the transaction, too.
+func (m *Migrate) ignoreTimeout(migr *Migration, err error) bool {
+	var te timeoutError
+	if m.TimeoutPolicy != TimeoutWarn || m.inTx || !errors.As(err, &te) {
+		return false
+	}
+	m.logPrintf("warning: %v exceeded its timeout of %v, continuing: %v\n", migr.LogString(), te.timeout, err)
+	drainBody(migr)
+	return true
+}
+
+// drainBody reads the rest of the body of migr, left unread by the aborted
+// migration, so Migration.Buffer doesn't block writing it and has finished
+// once the run continues.
+func drainBody(migr *Migration) {
+	if migr.BufferedBody == nil {
+		return
+	}
+	_, _ = io.Copy(ioutil.Discard, migr.BufferedBody)
+}
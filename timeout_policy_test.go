@@ -0,0 +1,125 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	"github.com/golang-migrate/migrate/v4/source"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+func TestTimeoutPolicy(t *testing.T) {
+	testCases := []struct {
+		name   string
+		policy TimeoutPolicy
+		err    error
+	}{
+		{name: "fail", policy: TimeoutFail, err: context.DeadlineExceeded},
+		{name: "warn", policy: TimeoutWarn},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			migrations := source.NewMigrations()
+			migrations.Append(&source.Migration{Version: 1, Direction: source.Up, Identifier: "-- migrate:timeout 10ms\nCREATE 1"})
+			migrations.Append(&source.Migration{Version: 2, Direction: source.Up, Identifier: "CREATE 2"})
+
+			m, _ := New("stub://", "stub://")
+			m.sourceDrv.(*sStub.Stub).Migrations = migrations
+			dbDrv := &slowStub{Stub: m.databaseDrv.(*dStub.Stub), delay: 100 * time.Millisecond}
+			m.databaseDrv = dbDrv
+			m.TimeoutPolicy = tc.policy
+			logs := &bufferLogger{}
+			m.Log = logs
+
+			if err := m.Up(); !errors.Is(err, tc.err) {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+
+			v, dirty, err := m.Version()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.err != nil {
+				if v != 1 || !dirty {
+					t.Errorf("expected dirty version 1, got %v (dirty: %v)", v, dirty)
+				}
+				return
+			}
+			if v != 2 || dirty {
+				t.Errorf("expected clean version 2, got %v (dirty: %v)", v, dirty)
+			}
+			if !strings.Contains(logs.String(), "exceeded its timeout of 10ms") {
+				t.Errorf("expected a warning for the timeout, got %q", logs.String())
+			}
+		})
+	}
+}
+
+func TestTimeoutPolicyTransaction(t *testing.T) {
+	testCases := []struct {
+		name string
+		run  func(m *Migrate) error
+	}{
+		{name: "dry run", run: (*Migrate).DryRun},
+		{name: "phase", run: func(m *Migrate) error { return m.UpPhase("expand") }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			migrations := source.NewMigrations()
+			migrations.Append(&source.Migration{Version: 1, Direction: source.Up, Identifier: "-- migrate:phase expand\n-- migrate:timeout 10ms\nCREATE 1"})
+			migrations.Append(&source.Migration{Version: 2, Direction: source.Up, Identifier: "-- migrate:phase expand\nCREATE 2"})
+
+			m, _ := New("stub://", "stub://")
+			m.sourceDrv.(*sStub.Stub).Migrations = migrations
+			dbDrv := &slowStub{Stub: m.databaseDrv.(*dStub.Stub), delay: 100 * time.Millisecond}
+			m.databaseDrv = dbDrv
+			// timeouts fail the transaction anyway
+			m.TimeoutPolicy = TimeoutWarn
+
+			if err := tc.run(m); !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+			}
+			if dbDrv.CurrentVersion != -1 || dbDrv.IsDirty {
+				t.Errorf("expected no version (dirty: false), got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+			}
+			if m.inTx {
+				t.Error("expected inTx to be reset")
+			}
+		})
+	}
+}
+
+func TestTimeoutPolicyOtherErrors(t *testing.T) {
+	migrations := source.NewMigrations()
+	migrations.Append(&source.Migration{Version: 1, Direction: source.Up, Identifier: "CREATE 1"})
+
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = migrations
+	m.databaseDrv = &failingStub{Stub: m.databaseDrv.(*dStub.Stub), failOn: "CREATE 1"}
+	m.TimeoutPolicy = TimeoutWarn
+
+	if err := m.Up(); err == nil {
+		t.Fatal("expected errors other than timeouts to fail the migration")
+	}
+}
+
+// bufferLogger is a Logger writing to a buffer.
+type bufferLogger struct {
+	bytes.Buffer
+}
+
+func (l *bufferLogger) Printf(format string, v ...interface{}) {
+	fmt.Fprintf(&l.Buffer, format, v...)
+}
+
+func (l *bufferLogger) Verbose() bool {
+	return false
+}
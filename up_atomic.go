@@ -0,0 +1,62 @@
+package migrate
+
+import (
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// UpAtomic applies all pending up migrations and the version updates in a
+// single transaction, so either all of them are applied or none is. If a
+// migration fails, the migrations applied before it are rolled back and
+// the version stays clean. A migration exceeding its timeout fails the
+// whole batch, regardless of TimeoutPolicy.
+// The database driver must implement database.Transactioner, and the
+// database must support transactional DDL. Otherwise UpAtomic returns
+// ErrNotSupported.
+//
+// The transaction holds the locks taken by all migrations until the last
+// one has been applied, and large data migrations keep a lot of changes
+// uncommitted. Prefer Up, or grouping migrations with UpPhase, for large
+// batches against databases in use.
+func (m *Migrate) UpAtomic() error {
+	tx, ok := m.databaseDrv.(database.Transactioner)
+	if !ok {
+		return fmt.Errorf("up atomic: %w", ErrNotSupported)
+	}
+
+	if err := m.preflightUp(-1, -1); err != nil {
+		return err
+	}
+
+	if err := m.lock(); err != nil {
+		return err
+	}
+
+	curVersion, dirty, err := m.versions().GetVersion()
+	if err != nil {
+		return m.unlockErr(err)
+	}
+
+	if dirty {
+		return m.unlockErr(ErrDirty{curVersion})
+	}
+
+	if err := tx.Begin(); err != nil {
+		return m.unlockErr(err)
+	}
+
+	m.inTx = true
+	defer func() { m.inTx = false }()
+
+	ret := make(chan interface{}, m.PrefetchMigrations)
+	go m.readUp(curVersion, -1, ret)
+	if err := m.runMigrations(ret); err != nil {
+		return m.unlockErr(m.rollbackTx(tx, curVersion, err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return m.unlockErr(m.rollbackTx(tx, curVersion, err))
+	}
+	return m.unlock()
+}
@@ -0,0 +1,82 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	"github.com/golang-migrate/migrate/v4/source"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+func TestUpAtomic(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+
+	if err := m.UpAtomic(); err != nil {
+		t.Fatal(err)
+	}
+	if dbDrv.CurrentVersion != 7 || dbDrv.IsDirty {
+		t.Errorf("expected version 7 (dirty: false), got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+	equalDbSeq(t, 0, migrationSequence{mr("CREATE 1"), mr("CREATE 3"), mr("CREATE 4"), mr("CREATE 7")}, dbDrv)
+
+	if err := m.UpAtomic(); !errors.Is(err, ErrNoChange) {
+		t.Errorf("expected ErrNoChange, got %v", err)
+	}
+}
+
+func TestUpAtomicRollback(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+	m.databaseDrv = &failingStub{Stub: dbDrv, failOn: "CREATE 7"}
+
+	if err := m.Steps(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.UpAtomic(); err == nil {
+		t.Fatal("expected error of the last migration")
+	}
+
+	// the migrations preceding the failing one are rolled back, too
+	if dbDrv.CurrentVersion != 1 || dbDrv.IsDirty {
+		t.Errorf("expected version 1 (dirty: false), got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+	equalDbSeq(t, 0, migrationSequence{mr("CREATE 1")}, dbDrv)
+}
+
+func TestUpAtomicTimeout(t *testing.T) {
+	migrations := source.NewMigrations()
+	migrations.Append(&source.Migration{Version: 1, Direction: source.Up, Identifier: "CREATE 1"})
+	migrations.Append(&source.Migration{Version: 2, Direction: source.Up, Identifier: "-- migrate:timeout 10ms\nCREATE 2"})
+
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = migrations
+	dbDrv := &slowStub{Stub: m.databaseDrv.(*dStub.Stub), delay: 100 * time.Millisecond}
+	m.databaseDrv = dbDrv
+	// timeouts fail the batch anyway
+	m.TimeoutPolicy = TimeoutWarn
+
+	if err := m.UpAtomic(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if dbDrv.CurrentVersion != -1 || dbDrv.IsDirty {
+		t.Errorf("expected no version (dirty: false), got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+	if m.inTx {
+		t.Error("expected inTx to be reset")
+	}
+}
+
+func TestUpAtomicNotSupported(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.databaseDrv = nonTxStub{m.databaseDrv}
+
+	if err := m.UpAtomic(); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported, got %v", err)
+	}
+}
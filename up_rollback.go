@@ -0,0 +1,116 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// UpWithRollback applies all pending up migrations like Up. If a migration
+// fails, its down migration is run to undo the partially applied changes,
+// so the database is left clean at the previous version instead of dirty
+// at the failed one. The error of the failed migration is returned either
+// way. If the failed migration has no down migration, or the down migration
+// fails, too, the version is left dirty and the errors of both are returned.
+//
+// The migrations applied before the failed one are kept. Down migrations
+// have to cope with partially applied up migrations, e.g. by using
+// `DROP TABLE IF EXISTS`, for the rollback to succeed.
+func (m *Migrate) UpWithRollback() error {
+	if err := m.preflightUp(-1, -1); err != nil {
+		return err
+	}
+
+	if err := m.lock(); err != nil {
+		return err
+	}
+
+	curVersion, dirty, err := m.versions().GetVersion()
+	if err != nil {
+		return m.unlockErr(err)
+	}
+
+	if dirty {
+		return m.unlockErr(ErrDirty{curVersion})
+	}
+
+	ret := make(chan interface{}, m.PrefetchMigrations)
+
+	go m.readUp(curVersion, -1, ret)
+	if err := m.runMigrations(ret); err != nil {
+		return m.unlockErr(m.rollbackDirty(err))
+	}
+	return m.unlock()
+}
+
+// rollbackDirty runs the down migration of the dirty version left by a
+// failed up migration. prevErr is the error the up migration failed with.
+func (m *Migrate) rollbackDirty(prevErr error) error {
+	version, dirty, err := m.versions().GetVersion()
+	if err != nil {
+		return multierror.Append(prevErr, err)
+	}
+	if !dirty || version == database.NilVersion {
+		// the migrations failed before marking a version dirty
+		return prevErr
+	}
+
+	targetVersion := database.NilVersion
+	prev, err := m.sourceDrv.Prev(suint(version))
+	if err == nil {
+		targetVersion = int(prev)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return multierror.Append(prevErr, err)
+	}
+
+	migr, err := m.newMigration(suint(version), targetVersion)
+	if err != nil {
+		return multierror.Append(prevErr, err)
+	}
+	if migr.Body == nil {
+		m.logPrintf("No down migration to roll back failed version %v, leaving it dirty\n", version)
+		return prevErr
+	}
+	go func() {
+		if err := migr.Buffer(); err != nil {
+			m.logErr(err)
+		}
+	}()
+
+	m.logPrintf("Rolling back failed version %v\n", version)
+	if err := m.runRollback(migr); err != nil {
+		m.current.stop()
+		return multierror.Append(prevErr, fmt.Errorf("roll back version %v: %w", version, err))
+	}
+	return prevErr
+}
+
+// runRollback runs the down migration migr of the dirty version left by a
+// failed up migration. Unlike runMigration, it doesn't mark the target
+// version dirty beforehand, so the failed version stays dirty if the down
+// migration fails, too. The target version is only set once it succeeded.
+func (m *Migrate) runRollback(migr *Migration) error {
+	h, body, err := m.readBody(migr)
+	if err != nil {
+		return err
+	}
+
+	m.current.start(migr)
+	if err := m.run(body, h); err != nil {
+		return err
+	}
+	if err := m.verify(migr, h); err != nil {
+		return err
+	}
+	if err := m.versions().SetVersion(migr.TargetVersion, false); err != nil {
+		return err
+	}
+	m.current.stop()
+
+	m.logPrintf("%v\n", migr.LogString())
+	return nil
+}
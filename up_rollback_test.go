@@ -0,0 +1,69 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	"github.com/golang-migrate/migrate/v4/source"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+func TestUpWithRollback(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+	m.databaseDrv = &failingStub{Stub: dbDrv, failOn: "CREATE 7"}
+
+	if err := m.UpWithRollback(); err == nil {
+		t.Fatal("expected error of the failed migration")
+	}
+
+	// version 5 has no up migration, but was applied before 7
+	if dbDrv.CurrentVersion != 5 || dbDrv.IsDirty {
+		t.Errorf("expected version 5 (dirty: false), got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+	equalDbSeq(t, 0, migrationSequence{mr("CREATE 1"), mr("CREATE 3"), mr("CREATE 4"), mr("DROP 7")}, dbDrv)
+}
+
+func TestUpWithRollbackDownFails(t *testing.T) {
+	migrations := source.NewMigrations()
+	migrations.Append(&source.Migration{Version: 1, Direction: source.Up, Identifier: "CREATE 1"})
+	migrations.Append(&source.Migration{Version: 2, Direction: source.Up, Identifier: "FAIL"})
+	migrations.Append(&source.Migration{Version: 2, Direction: source.Down, Identifier: "FAIL"})
+
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = migrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+	m.databaseDrv = &failingStub{Stub: dbDrv, failOn: "FAIL"}
+
+	err := m.UpWithRollback()
+	if err == nil {
+		t.Fatal("expected errors of the failed migration and its rollback")
+	}
+	if !strings.Contains(err.Error(), "roll back version 2") {
+		t.Errorf("expected error of the rollback, got %v", err)
+	}
+	// the failed version 2 is left dirty, not the target version 1
+	if dbDrv.CurrentVersion != 2 || !dbDrv.IsDirty {
+		t.Errorf("expected version 2 (dirty: true), got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+	equalDbSeq(t, 0, migrationSequence{mr("CREATE 1")}, dbDrv)
+}
+
+func TestUpWithRollbackNoDown(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+	m.databaseDrv = &failingStub{Stub: dbDrv, failOn: "CREATE 3"}
+
+	if err := m.UpWithRollback(); err == nil {
+		t.Fatal("expected error of the failed migration")
+	}
+
+	// 3 has no down migration
+	if dbDrv.CurrentVersion != 3 || !dbDrv.IsDirty {
+		t.Errorf("expected version 3 (dirty: true), got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+	equalDbSeq(t, 0, migrationSequence{mr("CREATE 1")}, dbDrv)
+}
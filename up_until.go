@@ -0,0 +1,85 @@
+package migrate
+
+import (
+	"fmt"
+)
+
+// ErrVersionMismatch is returned by UpFrom if the database isn't at the
+// expected version, e.g. because another deployment migrated it since.
+type ErrVersionMismatch struct {
+	Expected uint
+	Actual   int
+}
+
+// Error implements the error interface.
+func (e ErrVersionMismatch) Error() string {
+	return fmt.Sprintf("expected version %v, database is at version %v", e.Expected, e.Actual)
+}
+
+// UpUntil looks at the currently active migration version and migrates up
+// to version, including it. Together with UpFrom, it splits applying
+// migrations into two phases, e.g. to apply schema changes, wait for a
+// manual approval, and then apply the backfills:
+//
+//	m.UpUntil(20230101) // schema
+//	// approval
+//	m.UpFrom(20230101) // backfill
+//
+// Unlike Migrate, it never migrates down. It returns an error if
+// version is below the currently active version.
+func (m *Migrate) UpUntil(version uint) error {
+	if err := m.preflightUp(int(version), -1); err != nil {
+		return err
+	}
+
+	if err := m.lock(); err != nil {
+		return err
+	}
+
+	curVersion, dirty, err := m.versions().GetVersion()
+	if err != nil {
+		return m.unlockErr(err)
+	}
+
+	if dirty {
+		return m.unlockErr(ErrDirty{curVersion})
+	}
+
+	if curVersion > int(version) {
+		return m.unlockErr(fmt.Errorf("version %v is below the currently active version %v", version, curVersion))
+	}
+
+	ret := make(chan interface{}, m.PrefetchMigrations)
+	go m.read(curVersion, int(version), ret)
+	return m.unlockErr(m.runMigrations(ret))
+}
+
+// UpFrom migrates all the way up, like Up, if the database is at version
+// and not dirty. Otherwise it returns ErrVersionMismatch without applying
+// any migration. See UpUntil.
+func (m *Migrate) UpFrom(version uint) error {
+	if err := m.preflightUp(-1, -1); err != nil {
+		return err
+	}
+
+	if err := m.lock(); err != nil {
+		return err
+	}
+
+	curVersion, dirty, err := m.versions().GetVersion()
+	if err != nil {
+		return m.unlockErr(err)
+	}
+
+	if dirty {
+		return m.unlockErr(ErrDirty{curVersion})
+	}
+
+	if curVersion != int(version) {
+		return m.unlockErr(ErrVersionMismatch{Expected: version, Actual: curVersion})
+	}
+
+	ret := make(chan interface{}, m.PrefetchMigrations)
+	go m.readUp(curVersion, -1, ret)
+	return m.unlockErr(m.runMigrations(ret))
+}
@@ -0,0 +1,69 @@
+package migrate
+
+import (
+	"errors"
+	"testing"
+
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+func TestUpUntilUpFrom(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+
+	if err := m.UpUntil(4); err != nil {
+		t.Fatal(err)
+	}
+	if dbDrv.CurrentVersion != 4 || dbDrv.IsDirty {
+		t.Fatalf("expected clean version 4, got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+	if !dbDrv.EqualSequence([]string{"CREATE 1", "CREATE 3", "CREATE 4"}) {
+		t.Errorf("unexpected migrations %v", dbDrv.MigrationSequence)
+	}
+
+	if err := m.UpUntil(4); !errors.Is(err, ErrNoChange) {
+		t.Errorf("expected ErrNoChange, got %v", err)
+	}
+	if err := m.UpUntil(3); err == nil {
+		t.Error("expected error migrating until a lower version")
+	}
+
+	if err := m.UpFrom(4); err != nil {
+		t.Fatal(err)
+	}
+	if dbDrv.CurrentVersion != 7 || dbDrv.IsDirty {
+		t.Fatalf("expected clean version 7, got %v (dirty: %v)", dbDrv.CurrentVersion, dbDrv.IsDirty)
+	}
+	if !dbDrv.EqualSequence([]string{"CREATE 1", "CREATE 3", "CREATE 4", "CREATE 7"}) {
+		t.Errorf("unexpected migrations %v", dbDrv.MigrationSequence)
+	}
+}
+
+func TestUpFromVersionMismatch(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+
+	if err := m.UpUntil(3); err != nil {
+		t.Fatal(err)
+	}
+
+	// another deployment migrated further while waiting for approval
+	if err := m.Steps(1); err != nil {
+		t.Fatal(err)
+	}
+
+	err := m.UpFrom(3)
+	var merr ErrVersionMismatch
+	if !errors.As(err, &merr) {
+		t.Fatalf("expected ErrVersionMismatch, got %v", err)
+	}
+	if merr.Expected != 3 || merr.Actual != 4 {
+		t.Errorf("expected version 3, got %v; actual 4, got %v", merr.Expected, merr.Actual)
+	}
+	if dbDrv.CurrentVersion != 4 {
+		t.Errorf("expected version 4 to be left untouched, got %v", dbDrv.CurrentVersion)
+	}
+}
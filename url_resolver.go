@@ -0,0 +1,53 @@
+package migrate
+
+import (
+	"fmt"
+	"sync"
+
+	iurl "github.com/golang-migrate/migrate/v4/internal/url"
+)
+
+// URLResolver rewrites a source or database URL before it's opened, e.g. to
+// inject a password fetched from a secret store, or to resolve a host with
+// service discovery. See SetURLResolver.
+type URLResolver func(rawURL string) (string, error)
+
+var (
+	urlResolverMu sync.RWMutex
+	urlResolver   URLResolver
+)
+
+// SetURLResolver sets the resolver applied to the source and database URLs
+// by New, NewWithDatabaseInstance, NewWithSourceInstance and RunRaw before
+// they are parsed. The resolved URLs aren't logged, so they may contain
+// credentials. A nil fn resets it. Environment variables in the URLs are
+// expanded before they are passed to fn, but not in the URLs fn returns,
+// so they may contain `${` as is.
+func SetURLResolver(fn URLResolver) {
+	urlResolverMu.Lock()
+	defer urlResolverMu.Unlock()
+	urlResolver = fn
+}
+
+// resolveURL applies the resolver set with SetURLResolver to rawURL, if any.
+// Errors don't include rawURL, which may contain credentials.
+// Environment variables are expanded before applying the resolver, and the
+// resolved URL is escaped, so opening the driver doesn't expand it again.
+func resolveURL(rawURL string) (string, error) {
+	urlResolverMu.RLock()
+	fn := urlResolver
+	urlResolverMu.RUnlock()
+
+	if fn == nil {
+		return rawURL, nil
+	}
+	expanded, err := iurl.ExpandEnv(rawURL)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := fn(expanded)
+	if err != nil {
+		return "", fmt.Errorf("resolve URL: %w", err)
+	}
+	return iurl.EscapeEnv(resolved), nil
+}
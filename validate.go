@@ -0,0 +1,222 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// ErrInvalidMigration is returned by Validate for each migration which
+// can't be read from the source or whose headers don't parse.
+type ErrInvalidMigration struct {
+	Version   uint
+	Direction source.Direction
+	Err       error
+}
+
+// Error implements the error interface.
+func (e ErrInvalidMigration) Error() string {
+	return fmt.Sprintf("invalid %v migration of version %v: %v", e.Direction, e.Version, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e ErrInvalidMigration) Unwrap() error {
+	return e.Err
+}
+
+// Validate reads and hashes the up and down migrations of every version in
+// the source and parses their headers, without connecting to the database,
+// i.e. as a gate in CI. The returned error holds an ErrInvalidMigration for
+// each invalid migration, ordered by version, or the error listing the
+// versions. Use ValidateConcurrent for large sources, and SourceFingerprints
+// for the hashes.
+func (m *Migrate) Validate() error {
+	var result error
+	err := m.eachSourceVersion(func(version uint) {
+		_, errs := validateVersion(m.sourceDrv, version)
+		for _, err := range errs {
+			result = multierror.Append(result, err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return result
+}
+
+// ValidateConcurrent validates the source like Validate, reading the
+// migrations of up to workers versions at a time, and returns the same
+// errors. A workers value below 1 uses GOMAXPROCS workers.
+// The reads of source drivers not implementing source.ConcurrentReader are
+// serialized, so only drivers implementing it are read faster.
+func (m *Migrate) ValidateConcurrent(workers int) error {
+	_, err := m.SourceFingerprints(workers)
+	return err
+}
+
+// SourceFingerprints validates the source like ValidateConcurrent, and
+// returns the fingerprint of every version as returned by Fingerprint,
+// e.g. to compare them with the ones recorded by another database.
+// The fingerprints are only returned if the source is valid.
+func (m *Migrate) SourceFingerprints(workers int) (map[uint]string, error) {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	src := m.sourceDrv
+	if cr, ok := src.(source.ConcurrentReader); !ok || !cr.ConcurrentReads() {
+		src = &serialReader{Driver: src}
+	}
+
+	// the versions are listed first, as only reads are serialized
+	var versions []uint
+	if err := m.eachSourceVersion(func(version uint) {
+		versions = append(versions, version)
+	}); err != nil {
+		return nil, err
+	}
+
+	fingerprints := make([]string, len(versions))
+	results := make([][]error, len(versions))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fingerprints[i], results[i] = validateVersion(src, versions[i])
+			}
+		}()
+	}
+	for i := range versions {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var result error
+	for _, errs := range results {
+		for _, err := range errs {
+			result = multierror.Append(result, err)
+		}
+	}
+	if result != nil {
+		return nil, result
+	}
+
+	byVersion := make(map[uint]string, len(versions))
+	for i, version := range versions {
+		byVersion[version] = fingerprints[i]
+	}
+	return byVersion, nil
+}
+
+// eachSourceVersion calls fn with each version of the source in order.
+func (m *Migrate) eachSourceVersion(fn func(version uint)) error {
+	version, err := m.sourceDrv.First()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	it, err := source.Iterate(m.sourceDrv, version)
+	if err != nil {
+		return err
+	}
+	for ok := true; ok; version, ok = it.Next() {
+		fn(version)
+	}
+	return it.Err()
+}
+
+// validateVersion reads the up and down migrations of version from
+// sourceDrv, returning the fingerprint of version and an
+// ErrInvalidMigration for each invalid migration.
+func validateVersion(sourceDrv source.Driver, version uint) (fp string, errs []error) {
+	upSum, err := validateMigration(sourceDrv.ReadUp(version))
+	if err != nil {
+		errs = append(errs, ErrInvalidMigration{Version: version, Direction: source.Up, Err: err})
+	}
+	downSum, err := validateMigration(sourceDrv.ReadDown(version))
+	if err != nil {
+		errs = append(errs, ErrInvalidMigration{Version: version, Direction: source.Down, Err: err})
+	}
+	return fingerprint(upSum, downSum), errs
+}
+
+// validateMigration reads the migration r returned by ReadUp or ReadDown,
+// parsing its headers, and returns its hash like migrationSum.
+// A missing migration is valid.
+func validateMigration(r io.ReadCloser, _ string, err error) (sum []byte, result error) {
+	h := sha256.New()
+	if errors.Is(err, os.ErrNotExist) {
+		return h.Sum(nil), nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errClose := r.Close(); errClose != nil {
+			result = multierror.Append(result, errClose)
+		}
+	}()
+
+	// the headers are hashed, too
+	_, body, err := readHeaders(io.TeeReader(r, h))
+	if err != nil {
+		return nil, err
+	}
+	if _, err = io.Copy(ioutil.Discard, body); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// serialReader serializes the reads of a source driver not declaring
+// them safe for concurrent use. A read holds the lock until the migration
+// has been closed.
+type serialReader struct {
+	source.Driver
+	mu sync.Mutex
+}
+
+func (s *serialReader) ReadUp(version uint) (io.ReadCloser, string, error) {
+	s.mu.Lock()
+	r, identifier, err := s.Driver.ReadUp(version)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, "", err
+	}
+	return &unlockCloser{ReadCloser: r, mu: &s.mu}, identifier, nil
+}
+
+func (s *serialReader) ReadDown(version uint) (io.ReadCloser, string, error) {
+	s.mu.Lock()
+	r, identifier, err := s.Driver.ReadDown(version)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, "", err
+	}
+	return &unlockCloser{ReadCloser: r, mu: &s.mu}, identifier, nil
+}
+
+// unlockCloser unlocks mu when the migration is closed.
+type unlockCloser struct {
+	io.ReadCloser
+	mu *sync.Mutex
+}
+
+func (u *unlockCloser) Close() error {
+	defer u.mu.Unlock()
+	return u.ReadCloser.Close()
+}
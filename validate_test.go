@@ -0,0 +1,118 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/golang-migrate/migrate/v4/source"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+// unreadableStub fails reading the down migration of version failOn.
+type unreadableStub struct {
+	*sStub.Stub
+	failOn     uint
+	concurrent bool
+}
+
+func (s *unreadableStub) ReadDown(version uint) (io.ReadCloser, string, error) {
+	if version == s.failOn {
+		return nil, "", errors.New("unreadable")
+	}
+	return s.Stub.ReadDown(version)
+}
+
+func (s *unreadableStub) ConcurrentReads() bool {
+	return s.concurrent
+}
+
+// validateSource returns a source of n versions with an invalid timeout
+// header in every tenth up migration and an unreadable down migration.
+func validateSource(n uint, failOn uint, concurrent bool) source.Driver {
+	migrations := source.NewMigrations()
+	for v := uint(1); v <= n; v++ {
+		up := fmt.Sprintf("CREATE %v", v)
+		if v%10 == 0 {
+			up = "-- migrate:timeout soon\n" + up
+		}
+		migrations.Append(&source.Migration{Version: v, Direction: source.Up, Identifier: up})
+		migrations.Append(&source.Migration{Version: v, Direction: source.Down, Identifier: fmt.Sprintf("DROP %v", v)})
+	}
+	return &unreadableStub{Stub: &sStub.Stub{Migrations: migrations}, failOn: failOn, concurrent: concurrent}
+}
+
+func TestValidate(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	if err := m.Validate(); err != nil {
+		t.Errorf("expected valid source, got %v", err)
+	}
+	if err := m.ValidateConcurrent(4); err != nil {
+		t.Errorf("expected valid source, got %v", err)
+	}
+
+	m.sourceDrv = validateSource(35, 7, false)
+	err := m.Validate()
+	merr, ok := err.(*multierror.Error)
+	if !ok {
+		t.Fatalf("expected errors of invalid migrations, got %v", err)
+	}
+	expected := []ErrInvalidMigration{
+		{Version: 7, Direction: source.Down},
+		{Version: 10, Direction: source.Up},
+		{Version: 20, Direction: source.Up},
+		{Version: 30, Direction: source.Up},
+	}
+	if len(merr.Errors) != len(expected) {
+		t.Fatalf("expected %v errors, got %v", len(expected), err)
+	}
+	for i, e := range merr.Errors {
+		var invalid ErrInvalidMigration
+		if !errors.As(e, &invalid) || invalid.Version != expected[i].Version || invalid.Direction != expected[i].Direction {
+			t.Errorf("expected invalid %v migration of version %v, got %v", expected[i].Direction, expected[i].Version, e)
+		}
+	}
+}
+
+func TestValidateConcurrent(t *testing.T) {
+	for _, concurrent := range []bool{false, true} {
+		t.Run(fmt.Sprintf("concurrent reads %v", concurrent), func(t *testing.T) {
+			m, _ := New("stub://", "stub://")
+			m.sourceDrv = validateSource(200, 42, concurrent)
+
+			serial := m.Validate()
+			for _, workers := range []int{0, 1, 8} {
+				if err := m.ValidateConcurrent(workers); fmt.Sprint(err) != fmt.Sprint(serial) {
+					t.Errorf("expected the errors of Validate with %v workers:\n%v\ngot:\n%v", workers, serial, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSourceFingerprints(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+
+	fingerprints, err := m.SourceFingerprints(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fingerprints) != 5 {
+		t.Fatalf("expected fingerprints of 5 versions, got %v", fingerprints)
+	}
+	for version, fp := range fingerprints {
+		if expected, _ := m.Fingerprint(version); fp != expected {
+			t.Errorf("expected fingerprint %v of version %v, got %v", expected, version, fp)
+		}
+	}
+
+	m.sourceDrv = validateSource(35, 7, false)
+	if fingerprints, err := m.SourceFingerprints(2); err == nil || fingerprints != nil {
+		t.Errorf("expected errors of invalid migrations only, got %v (%v)", err, fingerprints)
+	}
+}
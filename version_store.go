@@ -0,0 +1,153 @@
+package migrate
+
+import (
+	"context"
+	"sync"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// VersionStore keeps track of the currently active migration version.
+// By default the version is stored by the database driver itself, i.e.
+// in a schema_migrations table. See SetVersionStore to keep it elsewhere.
+type VersionStore interface {
+	// GetVersion returns the currently active version and if it is dirty.
+	// When no migration has been applied, it must return version -1.
+	GetVersion() (version int, dirty bool, err error)
+
+	// SetVersion saves version and dirty state.
+	// version must be >= -1. -1 means NilVersion.
+	SetVersion(version int, dirty bool) error
+
+	// Lock should acquire a lock so that only one migration process
+	// can run at a time. Return database.ErrLocked if already locked.
+	Lock() error
+
+	// Unlock should release the lock.
+	Unlock() error
+}
+
+// SetVersionStore sets an external store for the migration version, i.e. a
+// configuration service. Once set, the version and lock are only read from
+// and written to store, and the database driver is only used to run
+// migrations. The version table of the database driver is never consulted,
+// but most drivers still create it when they are opened, i.e. by
+// WithInstance, before the store can be set.
+func (m *Migrate) SetVersionStore(store VersionStore) {
+	m.versionStore = store
+}
+
+// versions returns the VersionStore set with SetVersionStore,
+// or the database driver if no store has been set.
+func (m *Migrate) versions() VersionStore {
+	if m.versionStore != nil {
+		return m.versionStore
+	}
+	return driverVersionStore{Driver: m.databaseDrv, epoch: &m.epoch}
+}
+
+// readVersion returns the currently active version like
+// versions().GetVersion, but doesn't refresh the epoch, so it can be used
+// without holding the lock, e.g. from another goroutine.
+func (m *Migrate) readVersion() (version int, dirty bool, err error) {
+	if m.versionStore != nil {
+		return m.versionStore.GetVersion()
+	}
+	return m.databaseDrv.Version()
+}
+
+// versionEpoch is the epoch of the version last read from or
+// written to a database.EpochVersioner. It's only refreshed while
+// holding the lock, see readVersion.
+type versionEpoch struct {
+	mu    sync.Mutex
+	value uint64
+	valid bool
+}
+
+// get returns the epoch, and false if it has to be read again.
+func (e *versionEpoch) get() (value uint64, valid bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.value, e.valid
+}
+
+// set records the epoch read from or written to the database driver.
+func (e *versionEpoch) set(value uint64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.value, e.valid = value, true
+}
+
+// invalidate makes the next write read the epoch again, i.e. after it was
+// changed outside of driverVersionStore.
+func (e *versionEpoch) invalidate() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.valid = false
+}
+
+// driverVersionStore keeps the version in the database driver.
+// If the driver implements database.EpochVersioner, the version is only
+// written if it hasn't been modified since it was last read or written.
+type driverVersionStore struct {
+	database.Driver
+	epoch *versionEpoch
+}
+
+// GetVersion implements VersionStore.
+func (s driverVersionStore) GetVersion() (version int, dirty bool, err error) {
+	ev, ok := s.Driver.(database.EpochVersioner)
+	if !ok {
+		return s.Version()
+	}
+
+	version, dirty, epoch, err := ev.VersionEpoch()
+	if err != nil {
+		return 0, false, err
+	}
+	s.epoch.set(epoch)
+	return version, dirty, nil
+}
+
+// SetVersion implements VersionStore.
+func (s driverVersionStore) SetVersion(version int, dirty bool) error {
+	ev, ok := s.Driver.(database.EpochVersioner)
+	if !ok {
+		return s.Driver.SetVersion(version, dirty)
+	}
+
+	epoch, valid := s.epoch.get()
+	if !valid {
+		// the version hasn't been read before, i.e. when forcing a version
+		if _, _, err := s.GetVersion(); err != nil {
+			return err
+		}
+		epoch, _ = s.epoch.get()
+	}
+
+	if err := ev.SetVersionEpoch(version, dirty, epoch); err != nil {
+		s.epoch.invalidate()
+		return err
+	}
+	s.epoch.set(epoch + 1)
+	return nil
+}
+
+// LockContext implements database.ContextLocker, falling back to Lock
+// if the database driver doesn't implement it.
+func (s driverVersionStore) LockContext(ctx context.Context) error {
+	if cl, ok := s.Driver.(database.ContextLocker); ok {
+		return cl.LockContext(ctx)
+	}
+	return s.Lock()
+}
+
+// UnlockContext implements database.ContextLocker, falling back to Unlock
+// if the database driver doesn't implement it.
+func (s driverVersionStore) UnlockContext(ctx context.Context) error {
+	if cl, ok := s.Driver.(database.ContextLocker); ok {
+		return cl.UnlockContext(ctx)
+	}
+	return s.Unlock()
+}
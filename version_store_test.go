@@ -0,0 +1,99 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	dStub "github.com/golang-migrate/migrate/v4/database/stub"
+	sStub "github.com/golang-migrate/migrate/v4/source/stub"
+)
+
+// memVersionStore is an in-memory VersionStore.
+type memVersionStore struct {
+	version  int
+	dirty    bool
+	isLocked bool
+	history  []int
+}
+
+func (s *memVersionStore) GetVersion() (int, bool, error) {
+	return s.version, s.dirty, nil
+}
+
+func (s *memVersionStore) SetVersion(version int, dirty bool) error {
+	s.version, s.dirty = version, dirty
+	if !dirty {
+		s.history = append(s.history, version)
+	}
+	return nil
+}
+
+func (s *memVersionStore) Lock() error {
+	if s.isLocked {
+		return database.ErrLocked
+	}
+	s.isLocked = true
+	return nil
+}
+
+func (s *memVersionStore) Unlock() error {
+	s.isLocked = false
+	return nil
+}
+
+func TestSetVersionStore(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+	dbDrv := m.databaseDrv.(*dStub.Stub)
+
+	store := &memVersionStore{version: database.NilVersion}
+	m.SetVersionStore(store)
+
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	v, dirty, err := m.Version()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 7 || dirty {
+		t.Errorf("expected clean version 7, got %v (dirty: %v)", v, dirty)
+	}
+	equalDbSeq(t, 0, migrationSequence{mr("CREATE 1"), mr("CREATE 3"), mr("CREATE 4"), mr("CREATE 7")}, dbDrv)
+
+	if err := m.Down(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := m.Version(); err != ErrNilVersion {
+		t.Errorf("expected ErrNilVersion, got %v", err)
+	}
+
+	expectedHistory := []int{1, 3, 4, 5, 7, 5, 4, 3, 1, database.NilVersion}
+	if len(store.history) != len(expectedHistory) {
+		t.Fatalf("expected version history %v, got %v", expectedHistory, store.history)
+	}
+	for i := range expectedHistory {
+		if store.history[i] != expectedHistory[i] {
+			t.Fatalf("expected version history %v, got %v", expectedHistory, store.history)
+		}
+	}
+
+	// the database driver must not be used for versioning or locking
+	if dbDrv.CurrentVersion != database.NilVersion {
+		t.Errorf("expected database driver version to be untouched, got %v", dbDrv.CurrentVersion)
+	}
+	if store.isLocked || dbDrv.IsLocked {
+		t.Error("expected store and database to be unlocked")
+	}
+}
+
+func TestSetVersionStoreLocked(t *testing.T) {
+	m, _ := New("stub://", "stub://")
+	m.sourceDrv.(*sStub.Stub).Migrations = sourceStubMigrations
+
+	m.SetVersionStore(&memVersionStore{version: database.NilVersion, isLocked: true})
+
+	if err := m.Up(); err != database.ErrLocked {
+		t.Fatalf("expected database.ErrLocked, got %v", err)
+	}
+}